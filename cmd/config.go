@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/console"
 	"github.com/criteo/command-launcher/internal/context"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -14,7 +15,10 @@ import (
 )
 
 type ConfigFlags struct {
-	Json bool
+	Json        bool
+	ShowOrigin  bool
+	ProfileFrom string
+	Markdown    bool
 }
 
 var (
@@ -33,7 +37,17 @@ func AddConfigCmd(rootCmd *cobra.Command, appCtx context.LauncherContext) {
 
     set configuration
     %s config [key] [value]
-	`, appCtx.AppName(), appCtx.AppName()),
+
+    show where the configurations come from
+    %s config --show-origin
+
+  The configurations are merged from the lowest priority: the default values,
+  the system file, the user file in the app home, the nearest %s.json file
+  of the working dir or its parents, the %s file, the environment
+  variables, and the --%s flags. The configurations are written to the
+  user file, or the %s file when it is set.
+	`, appCtx.AppName(), appCtx.AppName(), appCtx.AppName(), appCtx.AppName(),
+			appCtx.ConfigurationFileEnvVar(), config.SET_CONFIG_FLAG, appCtx.ConfigurationFileEnvVar()),
 		Run: func(cmd *cobra.Command, args []string) {
 			// list all configs
 			if len(args) == 0 {
@@ -50,8 +64,10 @@ func AddConfigCmd(rootCmd *cobra.Command, appCtx context.LauncherContext) {
 					printSettings(map[string]interface{}{
 						args[0]: viper.Get(args[0]),
 					}, true)
+				} else if configFlags.ShowOrigin {
+					fmt.Printf("%v\t%s\n", displayValue(args[0], viper.Get(args[0])), config.Origin(args[0]))
 				} else {
-					fmt.Println(viper.Get(args[0]))
+					fmt.Println(displayValue(args[0], viper.Get(args[0])))
 				}
 
 			}
@@ -62,28 +78,252 @@ func AddConfigCmd(rootCmd *cobra.Command, appCtx context.LauncherContext) {
 					fmt.Println(err)
 					return
 				}
-				if err := viper.WriteConfig(); err != nil {
+				if err := config.WriteConfig(); err != nil {
 					log.Error("cannot write the default configuration: ", err)
 					return
 				}
 			}
 		},
+		ValidArgsFunction: settingKeysCompletion,
+	}
+
+	configCmd.Flags().BoolVarP(&configFlags.Json, "json", "", false, "output in JSON format")
+	configCmd.Flags().BoolVarP(&configFlags.ShowOrigin, "show-origin", "", false, "show the file, the environment variable or the flag of each value")
+	addConfigSettingCmds(configCmd, appCtx)
+	addConfigProfileCmd(configCmd, appCtx)
+	rootCmd.AddCommand(configCmd)
+}
+
+func addConfigSettingCmds(configCmd *cobra.Command, appCtx context.LauncherContext) {
+	unsetCmd := &cobra.Command{
+		Use:   "unset [key]",
+		Short: "Remove a configuration",
+		Long:  "Remove a configuration from the configuration file, the user one or the one of the profile in use. Its value is inherited from the other configuration layers, or is the default one.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.UnsetValue(args[0]); err != nil {
+				return err
+			}
+			if err := config.WriteConfig(); err != nil {
+				return fmt.Errorf("cannot write the configuration: %v", err)
+			}
+			console.Success("Config %s removed from %s\n", strings.ToLower(args[0]), config.Metadata().File)
+			return nil
+		},
+		ValidArgsFunction: settingKeysCompletion,
+	}
+
+	resetCmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Remove all configurations",
+		Long:  "Remove all configurations from the configuration file, the user one or the one of the profile in use, except the remotes. Their values are inherited from the other configuration layers, or are the default ones.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := config.ResetConfig()
+			if err != nil {
+				return err
+			}
+			if err := config.WriteConfig(); err != nil {
+				return fmt.Errorf("cannot write the configuration: %v", err)
+			}
+			if len(removed) == 0 {
+				fmt.Printf("No config to remove from %s\n", config.Metadata().File)
+				return nil
+			}
+			console.Success("Config %s removed from %s\n", strings.Join(removed, ", "), config.Metadata().File)
+			return nil
+		},
+	}
+
+	describeCmd := &cobra.Command{
+		Use:   "describe [key]",
+		Short: "Describe a configuration",
+		Long:  "Describe a configuration: its type, its default value, its current value and where it comes from. All configurations are described when no key is specified.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFlags.Markdown {
+				fmt.Println(config.MarkdownTable())
+				return nil
+			}
+			if len(args) == 0 {
+				for _, s := range config.Settings {
+					fmt.Printf("%-40v: %s\n", strings.ToLower(s.Key), s.Description)
+				}
+				return nil
+			}
+
+			setting, found := config.FindSetting(args[0])
+			if !found {
+				return fmt.Errorf("unsupported config %s", args[0])
+			}
+			fmt.Printf("%s\n", strings.ToLower(setting.Key))
+			fmt.Printf("  description: %s\n", setting.Description)
+			fmt.Printf("  type:        %s\n", setting.TypeName())
+			if value := setting.DefaultValue(); value != nil {
+				fmt.Printf("  default:     %v\n", setting.DisplayValue(value))
+			}
+			fmt.Printf("  value:       %v\n", setting.DisplayValue(viper.Get(setting.Key)))
+			fmt.Printf("  origin:      %s\n", config.Origin(setting.Key))
+			if setting.Secret {
+				fmt.Printf("  secret:      true\n")
+			}
+			if setting.ManagedBy != "" {
+				fmt.Printf("  managed by:  %s\n", setting.ManagedBy)
+			}
+			return nil
+		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) > 0 {
 				return []string{}, cobra.ShellCompDirectiveNoFileComp
 			}
+			keys := []string{}
+			for _, s := range config.Settings {
+				keys = append(keys, strings.ToLower(s.Key))
+			}
+			return keys, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	describeCmd.Flags().BoolVarP(&configFlags.Markdown, "markdown", "", false, "output the documentation of all configurations in markdown")
+	describeCmd.Flags().MarkHidden("markdown")
+
+	configCmd.AddCommand(unsetCmd)
+	configCmd.AddCommand(resetCmd)
+	configCmd.AddCommand(describeCmd)
+}
+
+func settingKeysCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	lowerKeys := []string{}
+	for _, k := range config.SettingKeys {
+		lowerKeys = append(lowerKeys, strings.ToLower(k))
+	}
+	return lowerKeys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// displayValue masks the value of the secret settings
+func displayValue(key string, value interface{}) interface{} {
+	if setting, found := config.FindSetting(key); found {
+		return setting.DisplayValue(value)
+	}
+	return value
+}
+
+func addConfigProfileCmd(configCmd *cobra.Command, appCtx context.LauncherContext) {
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage the configuration profiles",
+		Long: fmt.Sprintf(`
+Manage the configuration profiles. A profile overlays the base configuration,
+the configurations it does not define are inherited. Each profile has its own
+repositories.
+
+The configuration changes are written to the profile in use. Use a profile
+for one run with the --%s flag or the %s environment variable.`,
+			config.PROFILE_FLAG, appCtx.ProfileEnvVar()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+			return nil
+		},
+	}
 
-			lowerKeys := []string{}
-			for _, k := range config.SettingKeys {
-				lowerKeys = append(lowerKeys, strings.ToLower(k))
+	profileCreateCmd := &cobra.Command{
+		Use:   "create [profile]",
+		Short: "Create a configuration profile",
+		Args:  cobra.ExactArgs(1),
+		Example: fmt.Sprintf(`
+  %s config profile create sandbox
+  %s --profile sandbox config command_repository_base_url https://sandbox.example.com/remote`,
+			appCtx.AppName(), appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.CreateProfile(args[0], configFlags.ProfileFrom); err != nil {
+				return err
 			}
+			console.Success("Profile '%s' created\n", args[0])
+			return nil
+		},
+	}
+	profileCreateCmd.Flags().StringVar(&configFlags.ProfileFrom, "from", "", "copy the configurations of another profile")
+	profileCreateCmd.RegisterFlagCompletionFunc("from", profileCompletion(false))
 
-			return lowerKeys, cobra.ShellCompDirectiveNoFileComp
+	profileUseCmd := &cobra.Command{
+		Use:   "use [profile]",
+		Short: "Use a configuration profile by default",
+		Long:  fmt.Sprintf("Use a configuration profile by default, the '%s' profile uses the base configuration", config.DEFAULT_PROFILE),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.UseProfile(args[0]); err != nil {
+				return err
+			}
+			console.Success("Profile '%s' used by default\n", args[0])
+			return nil
 		},
+		ValidArgsFunction: profileCompletion(true),
 	}
 
-	configCmd.Flags().BoolVarP(&configFlags.Json, "json", "", false, "output in JSON format")
-	rootCmd.AddCommand(configCmd)
+	profileListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the configuration profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			active, current := config.ActiveProfile(), config.CurrentProfile()
+			if active == "" {
+				active = config.DEFAULT_PROFILE
+			}
+			if current == "" || !config.ProfileExists(current) {
+				current = config.DEFAULT_PROFILE
+			}
+			for _, name := range append([]string{config.DEFAULT_PROFILE}, config.Profiles()...) {
+				marker, details := " ", ""
+				if name == active {
+					marker = "*"
+				}
+				if name == current {
+					details = " (current)"
+				} else if name == active {
+					details = fmt.Sprintf(" (%s)", config.Metadata().ProfileReason)
+				}
+				fmt.Printf("%s %s%s\n", marker, name, details)
+			}
+			return nil
+		},
+	}
+
+	profileDeleteCmd := &cobra.Command{
+		Use:   "delete [profile]",
+		Short: "Delete a configuration profile and its repositories",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.DeleteProfile(args[0]); err != nil {
+				return err
+			}
+			console.Success("Profile '%s' deleted\n", args[0])
+			return nil
+		},
+		ValidArgsFunction: profileCompletion(false),
+	}
+
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+	configCmd.AddCommand(profileCmd)
+}
+
+func profileCompletion(withDefault bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return []string{}, cobra.ShellCompDirectiveNoFileComp
+		}
+		names := config.Profiles()
+		if withDefault {
+			names = append([]string{config.DEFAULT_PROFILE}, names...)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
 }
 
 // get printable settings in alphabet order
@@ -100,20 +340,35 @@ func printableSettingsInOrder(settings map[string]interface{}) []string {
 			remotes, _ := config.Remotes()
 			for _, remote := range remotes {
 				key := fmt.Sprintf("extra_remotes.%s.remote_base_url", remote.Name)
-				sorted = append(sorted, fmt.Sprintf("%-40v: %v", key, remote.RemoteBaseUrl))
+				sorted = append(sorted, settingLine(key, remote.RemoteBaseUrl, k))
 				key = fmt.Sprintf("extra_remotes.%s.repository_dir", remote.Name)
-				sorted = append(sorted, fmt.Sprintf("%-40v: %v", key, remote.RepositoryDir))
+				sorted = append(sorted, settingLine(key, remote.RepositoryDir, k))
 				key = fmt.Sprintf("extra_remotes.%s.sync_policy", remote.Name)
-				sorted = append(sorted, fmt.Sprintf("%-40v: %v", key, remote.SyncPolicy))
+				sorted = append(sorted, settingLine(key, remote.SyncPolicy, k))
+				key = fmt.Sprintf("extra_remotes.%s.disabled", remote.Name)
+				sorted = append(sorted, settingLine(key, remote.Disabled, k))
+				key = fmt.Sprintf("extra_remotes.%s.include", remote.Name)
+				sorted = append(sorted, settingLine(key, strings.Join(remote.Include, ","), k))
+				key = fmt.Sprintf("extra_remotes.%s.exclude", remote.Name)
+				sorted = append(sorted, settingLine(key, strings.Join(remote.Exclude, ","), k))
 			}
 		} else {
-			sorted = append(sorted, fmt.Sprintf("%-40v: %v", k, settings[k]))
+			sorted = append(sorted, settingLine(k, displayValue(k, settings[k]), k))
 		}
 	}
 
 	return sorted
 }
 
+// settingLine formats a setting, with the origin of its configuration key
+// when the --show-origin option is set
+func settingLine(key string, value interface{}, configKey string) string {
+	if configFlags.ShowOrigin {
+		return fmt.Sprintf("%-40v: %-40v %s", key, value, config.Origin(configKey))
+	}
+	return fmt.Sprintf("%-40v: %v", key, value)
+}
+
 func printSettings(settings map[string]interface{}, jsonFormat bool) {
 	if jsonFormat {
 		printInJson(settings)
@@ -123,6 +378,21 @@ func printSettings(settings map[string]interface{}, jsonFormat bool) {
 }
 
 func printInJson(settings map[string]interface{}) {
+	masked := map[string]interface{}{}
+	for k, v := range settings {
+		masked[k] = displayValue(k, v)
+	}
+	settings = masked
+	if configFlags.ShowOrigin {
+		withOrigins := map[string]interface{}{}
+		for k, v := range settings {
+			withOrigins[k] = map[string]interface{}{
+				"value":  v,
+				"origin": config.Origin(k),
+			}
+		}
+		settings = withOrigins
+	}
 	val, err := json.MarshalIndent(settings, "", "    ")
 	if err != nil {
 		fmt.Println(err)
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/spf13/viper"
+)
+
+// the file recording the shell session in which the command conflicts have been reported
+const CONFLICTS_WARNING_FILE = "conflicts-warning"
+
+func initConflictRules() error {
+	priority := config.StringList(config.SOURCE_PRIORITY_KEY)
+	preferRules := viper.GetStringMapString(config.COMMAND_PREFER_RULES_KEY)
+	if len(priority) == 0 && len(preferRules) == 0 {
+		return nil
+	}
+	return rootCtxt.backend.SetConflictRules(priority, preferRules)
+}
+
+// warnConflicts reports the renamed commands once per shell session, and
+// again when the conflicts change
+func warnConflicts(out io.Writer) {
+	conflicts := rootCtxt.backend.Conflicts()
+	if len(conflicts) == 0 {
+		return
+	}
+
+	lines := conflictLines(conflicts)
+	session := fmt.Sprintf("%d:%x", os.Getppid(), sha256.Sum256([]byte(strings.Join(lines, "\n"))))
+	stateFile := filepath.Join(config.AppDir(), CONFLICTS_WARNING_FILE)
+	if previous, err := os.ReadFile(stateFile); err == nil && string(previous) == session {
+		return
+	}
+
+	fmt.Fprintf(out, "Warning: %d command conflict(s), see the %s and %s configurations\n",
+		len(conflicts), strings.ToLower(config.SOURCE_PRIORITY_KEY), strings.ToLower(config.COMMAND_PREFER_RULES_KEY))
+	for _, line := range lines {
+		fmt.Fprintf(out, "  - %s\n", line)
+	}
+	os.WriteFile(stateFile, []byte(session), 0644)
+}
+
+func conflictLines(conflicts []backend.CommandConflict) []string {
+	lines := []string{}
+	for _, c := range conflicts {
+		winner := "a built-in command"
+		if c.Winner != nil {
+			winner = fmt.Sprintf("%s/%s", c.Winner.RepositoryID(), c.Winner.PackageName())
+		}
+		lines = append(lines, fmt.Sprintf("'%s' is kept from %s, the one from %s/%s is renamed to '%s'",
+			c.Name, winner, c.Loser.RepositoryID(), c.Loser.PackageName(), c.RenamedTo))
+	}
+	return lines
+}
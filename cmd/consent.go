@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/criteo/command-launcher/cmd/consent"
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/console"
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type ConsentFlags struct {
+	all bool
+}
+
+var (
+	consentFlags = ConsentFlags{}
+)
+
+func AddConsentCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back backend.Backend) {
+	consentCmd := &cobra.Command{
+		Use:   "consent",
+		Short: "Manage the access of the commands to the resources",
+		Long: fmt.Sprintf(`
+Manage the access of the commands to the resources requiring a user consent:
+%s.
+
+In a non-interactive session, the resources without consent are handled by the
+%s config: %s.`,
+			strings.Join(consent.AvailableConsents, ", "),
+			strings.ToLower(config.USER_CONSENT_POLICY_KEY),
+			strings.Join(config.ValidConsentPolicies(), ", ")),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+			return nil
+		},
+	}
+
+	consentListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the consents of the commands",
+		Long:  "List the resources granted and denied to the commands, with the expiration of the consents",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			consents := consent.ListConsents()
+			if len(consents) == 0 {
+				fmt.Println("No consent found")
+			}
+			for _, c := range consents {
+				fmt.Printf("%-30s : granted [%s], denied [%s], expires %s\n",
+					strings.TrimSpace(c.Group+" "+c.Name),
+					strings.Join(c.Granted, ", "),
+					strings.Join(c.Denied, ", "),
+					c.ExpiresAt.Format(time.RFC3339),
+				)
+			}
+			fmt.Printf("\nnon-interactive policy: %s", viper.GetString(config.USER_CONSENT_POLICY_KEY))
+			if viper.GetString(config.USER_CONSENT_POLICY_KEY) == config.CONSENT_POLICY_ALLOW_LIST {
+				fmt.Printf(", allowed resources [%s]", strings.Join(config.StringList(config.USER_CONSENT_ALLOWED_RESOURCES_KEY), ", "))
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+
+	consentGrantCmd := &cobra.Command{
+		Use:   "grant [group] [name] [resources...]",
+		Short: "Grant the access to resources to a command",
+		Long: fmt.Sprintf(`
+Grant the access to resources to a command without prompt, for example before
+running it in a non-interactive session. All the resources requested by the
+command are granted when no resource is specified.
+
+Example:
+  %s consent grant infra create-pod USERNAME AUTH_TOKEN`, appCtx.AppName()),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, resources, err := consentCommandArgs(args, back)
+			if err != nil {
+				return err
+			}
+			if len(resources) == 0 {
+				for _, r := range c.RequestedResources() {
+					if consent.IsAvailableConsent(r) {
+						resources = append(resources, r)
+					}
+				}
+			}
+			if len(resources) == 0 {
+				return fmt.Errorf("command '%s' requests no resource requiring a consent", runtimeCommandLine(c))
+			}
+			if err := consent.GrantConsents(c.RuntimeGroup(), c.RuntimeName(), resources); err != nil {
+				return fmt.Errorf("cannot save the consent: %v", err)
+			}
+			console.Success("Access to %s granted to command '%s'\n", strings.Join(resources, ", "), runtimeCommandLine(c))
+			return nil
+		},
+		ValidArgsFunction: consentArgsCompletion(back),
+	}
+
+	consentRevokeCmd := &cobra.Command{
+		Use:   "revoke [group] [name] [resources...]",
+		Short: "Revoke the access to resources of a command",
+		Long: fmt.Sprintf(`
+Revoke the access to resources of a command, all its consents and denials are
+revoked when no resource is specified. You will be asked again on the next run
+of the command.
+
+Example:
+  %s consent revoke infra create-pod AUTH_TOKEN
+  %s consent revoke --all`, appCtx.AppName(), appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if consentFlags.all {
+				if len(args) > 0 {
+					return fmt.Errorf("the --all option does not accept a command")
+				}
+				for _, c := range consent.ListConsents() {
+					if err := consent.RevokeConsents(c.Group, c.Name, []string{}); err != nil {
+						return fmt.Errorf("cannot revoke the consent of command '%s': %v", strings.TrimSpace(c.Group+" "+c.Name), err)
+					}
+				}
+				console.Success("All consents revoked\n")
+				return nil
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("a command or the --all option is required")
+			}
+
+			c, resources, err := consentCommandArgs(args, back)
+			if err != nil {
+				return err
+			}
+			if err := consent.RevokeConsents(c.RuntimeGroup(), c.RuntimeName(), resources); err != nil {
+				return err
+			}
+			if len(resources) == 0 {
+				console.Success("Consent of command '%s' revoked\n", runtimeCommandLine(c))
+			} else {
+				console.Success("Access to %s revoked for command '%s'\n", strings.Join(resources, ", "), runtimeCommandLine(c))
+			}
+			return nil
+		},
+		ValidArgsFunction: consentArgsCompletion(back),
+	}
+	consentRevokeCmd.Flags().BoolVarP(&consentFlags.all, "all", "", false, "revoke the consents of all commands")
+
+	consentCmd.AddCommand(consentListCmd)
+	consentCmd.AddCommand(consentGrantCmd)
+	consentCmd.AddCommand(consentRevokeCmd)
+	rootCmd.AddCommand(consentCmd)
+}
+
+// consentCommandArgs splits the args into the command, with an optional
+// group, and the resources following it
+func consentCommandArgs(args []string, back backend.Backend) (command.Command, []string, error) {
+	cmdArgs, resources := []string{}, []string{}
+	for i, arg := range args {
+		if consent.IsAvailableConsent(arg) {
+			for _, r := range args[i:] {
+				if !consent.IsAvailableConsent(r) {
+					return nil, nil, fmt.Errorf("invalid resource %s, expected one of: %s", r, strings.Join(consent.AvailableConsents, ", "))
+				}
+				resources = append(resources, strings.ToUpper(r))
+			}
+			break
+		}
+		cmdArgs = append(cmdArgs, arg)
+	}
+	if len(cmdArgs) == 0 || len(cmdArgs) > 2 {
+		return nil, nil, fmt.Errorf("invalid command %s, expected [group] [name] followed by the resources", strings.Join(cmdArgs, " "))
+	}
+
+	group, name := "", cmdArgs[0]
+	if len(cmdArgs) == 2 {
+		group, name = cmdArgs[0], cmdArgs[1]
+	}
+	c, err := back.FindCommand(group, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no command %s found", strings.Join(cmdArgs, " "))
+	}
+	return c, resources, nil
+}
+
+func consentArgsCompletion(back backend.Backend) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names := []string{}
+		if len(args) > 0 && consent.IsAvailableConsent(args[len(args)-1]) || len(args) == 2 {
+			return consent.AvailableConsents, cobra.ShellCompDirectiveNoFileComp
+		}
+		switch len(args) {
+		case 0:
+			for _, c := range back.GroupCommands() {
+				names = append(names, c.RuntimeName())
+			}
+			for _, c := range back.ExecutableCommands() {
+				if c.RuntimeGroup() == "" {
+					names = append(names, c.RuntimeName())
+				}
+			}
+		case 1:
+			for _, c := range back.ExecutableCommands() {
+				if c.RuntimeGroup() == args[0] {
+					names = append(names, c.RuntimeName())
+				}
+			}
+			names = append(names, consent.AvailableConsents...)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
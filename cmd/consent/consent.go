@@ -1,19 +1,33 @@
 package consent
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/criteo/command-launcher/internal/config"
 	"github.com/criteo/command-launcher/internal/console"
+	"github.com/criteo/command-launcher/internal/context"
 	"github.com/criteo/command-launcher/internal/helper"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 )
 
 type Consent struct {
 	ExpiresAt int64    `json:"expiresAt"`
 	Consents  []string `json:"consents"`
+	// the resources refused by the user, only for the command consents
+	Denied []string `json:"denied,omitempty"`
+	// the digest of the trusted content, only for the workspace consents
+	Digest string `json:"digest,omitempty"`
 }
 
 const (
@@ -28,8 +42,46 @@ var AvailableConsents = []string{
 	USERNAME, PASSWORD, AUTH_TOKEN, LOG_LEVEL, DEBUG_FLAGS,
 }
 
+// the file indexing the commands with a consent, the vault keys cannot be listed
+const COMMAND_CONSENT_INDEX_FILE = "consents.json"
+
+// ErrConsentRequired is returned when a command requests resources without
+// consent in a non-interactive session, and the consent policy is "fail"
+var ErrConsentRequired = errors.New("user consent required")
+
+// CommandConsent is the consent of a command to access the resources
+type CommandConsent struct {
+	Group     string
+	Name      string
+	Granted   []string
+	Denied    []string
+	ExpiresAt time.Time
+}
+
+// the commands of the consent index
+type commandConsentEntry struct {
+	Group string `json:"group"`
+	Name  string `json:"name"`
+}
+
+// isInteractive checks if the user can answer the consent requests,
+// replaced in tests
+var isInteractive = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// the output of the notices, on the standard error to keep the output of the
+// command unchanged, replaced in tests
+var noticeOutput io.Writer = os.Stderr
+
 // GetConsents function returns the user consent of a particular command
 // This function returns a list of agreed consents.
+//
+// The user is only asked for the requested resources not answered yet, each
+// resource is granted or denied individually. In a non-interactive session,
+// the unanswered resources are handled by the USER_CONSENT_POLICY config.
 func GetConsents(cmdGroup string, cmdName string, requests []string, enabled bool) ([]string, error) {
 	if !enabled {
 		return AvailableConsents, nil
@@ -40,24 +92,137 @@ func GetConsents(cmdGroup string, cmdName string, requests []string, enabled boo
 	}
 
 	consent, err := getCmdConsents(cmdGroup, cmdName)
-	if err == nil {
-		return consent.Consents, nil
+	if err != nil {
+		consent = &Consent{}
 	}
 
-	if requestConsent(cmdGroup, cmdName, requests) {
-		// user explicitly consented
-		keyLife := viper.GetDuration(config.USER_CONSENT_LIFE_KEY).Seconds()
-		if keyLife <= 0 {
-			// default value: expire in seconds for 30 days = 3600 * 24 * 30 = 2592000
-			keyLife = 2592000
+	granted := []string{}
+	pending := []string{}
+	withheld := []string{}
+	for _, request := range requests {
+		if containsResource(consent.Consents, request) {
+			granted = append(granted, request)
+		} else if containsResource(consent.Denied, request) {
+			withheld = append(withheld, request)
+		} else {
+			pending = append(pending, request)
 		}
-		if err := saveCmdConsents(cmdGroup, cmdName, requests, int64(keyLife)); err != nil {
-			return requests, err
+	}
+	if len(withheld) > 0 {
+		printWithheldResources(cmdGroup, cmdName, withheld, time.Unix(consent.ExpiresAt, 0))
+	}
+	if len(pending) == 0 {
+		return granted, nil
+	}
+
+	if !isInteractive() {
+		return applyConsentPolicy(cmdGroup, cmdName, granted, pending)
+	}
+
+	allowed, denied := requestConsent(cmdGroup, cmdName, pending)
+	if len(allowed)+len(denied) == 0 {
+		// refused for this run only, the user is asked again on the next run
+		return granted, nil
+	}
+	if consent.ExpiresAt == 0 || time.Unix(consent.ExpiresAt, 0).Before(time.Now()) {
+		consent.ExpiresAt = time.Now().Unix() + consentLife()
+	}
+	consent.Consents = append(consent.Consents, allowed...)
+	consent.Denied = append(consent.Denied, denied...)
+	if err := saveCmdConsent(cmdGroup, cmdName, consent); err != nil {
+		return append(granted, allowed...), err
+	}
+
+	return append(granted, allowed...), nil
+}
+
+// printWithheldResources reminds the user of the resources previously denied
+// to a command, they are not passed to the command until the denial expires
+func printWithheldResources(cmdGroup string, cmdName string, withheld []string, expiresAt time.Time) {
+	fullName := strings.TrimSpace(cmdGroup + " " + cmdName)
+	appName := "cola"
+	if ctx, err := context.AppContext(); err == nil {
+		appName = ctx.AppName()
+	}
+	fmt.Fprintf(noticeOutput, "Command '%s' runs without access to %s, denied until %s, run '%s consent revoke %s %s' to be asked again\n",
+		fullName, strings.Join(withheld, ", "), expiresAt.Format(time.RFC3339), appName, fullName, strings.Join(withheld, " "))
+}
+
+// applyConsentPolicy returns the consents of a non-interactive session,
+// the pending resources are not saved, the user is asked on the next
+// interactive session
+func applyConsentPolicy(cmdGroup string, cmdName string, granted []string, pending []string) ([]string, error) {
+	switch viper.GetString(config.USER_CONSENT_POLICY_KEY) {
+	case config.CONSENT_POLICY_FAIL:
+		return granted, fmt.Errorf("%w: command '%s' requests access to %s in a non-interactive session, grant it with the consent grant command",
+			ErrConsentRequired, strings.TrimSpace(cmdGroup+" "+cmdName), strings.Join(pending, ", "))
+	case config.CONSENT_POLICY_ALLOW_LIST:
+		allowList := config.StringList(config.USER_CONSENT_ALLOWED_RESOURCES_KEY)
+		for _, request := range pending {
+			if containsResource(allowList, request) {
+				granted = append(granted, request)
+			}
 		}
-		return requests, nil
-	} // user refuse consent
+	}
+	return granted, nil
+}
 
-	return []string{}, nil
+// ListConsents returns the consents of the commands, sorted by command.
+// The vault keys cannot be listed, only the commands of the consent index are
+// returned: a consent saved before the index is listed once its command ran.
+func ListConsents() []CommandConsent {
+	consents := []CommandConsent{}
+	for _, entry := range readConsentIndex() {
+		consent, err := getCmdConsents(entry.Group, entry.Name)
+		if err != nil {
+			continue
+		}
+		consents = append(consents, CommandConsent{
+			Group:     entry.Group,
+			Name:      entry.Name,
+			Granted:   consent.Consents,
+			Denied:    consent.Denied,
+			ExpiresAt: time.Unix(consent.ExpiresAt, 0),
+		})
+	}
+	return consents
+}
+
+// GrantConsents grants the access to resources to a command without prompt,
+// the grant lasts USER_CONSENT_LIFE
+func GrantConsents(cmdGroup string, cmdName string, resources []string) error {
+	consent, err := getCmdConsents(cmdGroup, cmdName)
+	if err != nil {
+		consent = &Consent{}
+	}
+	consent.ExpiresAt = time.Now().Unix() + consentLife()
+	consent.Denied = removeResources(consent.Denied, resources)
+	consent.Consents = append(removeResources(consent.Consents, resources), resources...)
+	return saveCmdConsent(cmdGroup, cmdName, consent)
+}
+
+// RevokeConsents revokes the access to resources of a command, all its
+// consents and denials are forgotten when no resource is specified.
+// The user is asked again on the next run of the command.
+func RevokeConsents(cmdGroup string, cmdName string, resources []string) error {
+	consent, err := getCmdConsents(cmdGroup, cmdName)
+	if err != nil {
+		return fmt.Errorf("no consent found for command '%s'", strings.TrimSpace(cmdGroup+" "+cmdName))
+	}
+	if len(resources) > 0 {
+		consent.Consents = removeResources(consent.Consents, resources)
+		consent.Denied = removeResources(consent.Denied, resources)
+	}
+	if len(resources) == 0 || len(consent.Consents)+len(consent.Denied) == 0 {
+		// the vault has no deletion, an expired record is ignored
+		consent = &Consent{ExpiresAt: 0, Consents: []string{}}
+	}
+	return saveCmdConsent(cmdGroup, cmdName, consent)
+}
+
+// IsAvailableConsent checks if a resource requires the user consent
+func IsAvailableConsent(resource string) bool {
+	return containsResource(AvailableConsents, resource)
 }
 
 func getCmdConsents(cmdGroup string, cmdName string) (*Consent, error) {
@@ -75,6 +240,12 @@ func getCmdConsents(cmdGroup string, cmdName string) (*Consent, error) {
 		// expired
 		return nil, fmt.Errorf("consent expired")
 	}
+	// the consents saved before the consent index are added once read
+	if !isConsentIndexed(cmdGroup, cmdName) {
+		if err := updateConsentIndex(cmdGroup, cmdName, true); err != nil {
+			log.Debugf("cannot add the command %s to the consent index: %v", secretKey, err)
+		}
+	}
 	return &consent, nil
 }
 
@@ -82,33 +253,147 @@ func getConsentKey(cmdGroup string, cmdName string) string {
 	return fmt.Sprintf("%s_%s", cmdGroup, cmdName)
 }
 
+func consentLife() int64 {
+	keyLife := viper.GetDuration(config.USER_CONSENT_LIFE_KEY).Seconds()
+	if keyLife <= 0 {
+		// default value: expire in seconds for 30 days = 3600 * 24 * 30 = 2592000
+		keyLife = 2592000
+	}
+	return int64(keyLife)
+}
+
 func saveCmdConsents(cmdGroup string, cmdName string, requests []string, duration int64) error {
-	secretKey := getConsentKey(cmdGroup, cmdName)
-	secretValue, err := json.Marshal(Consent{
+	return saveCmdConsent(cmdGroup, cmdName, &Consent{
 		ExpiresAt: time.Now().Unix() + duration,
 		Consents:  requests,
 	})
+}
+
+func saveCmdConsent(cmdGroup string, cmdName string, consent *Consent) error {
+	secretKey := getConsentKey(cmdGroup, cmdName)
+	secretValue, err := json.Marshal(consent)
 	if err != nil {
 		return err
 	}
 	if err := helper.SetSecret(secretKey, string(secretValue)); err != nil {
 		return err
 	}
-	return nil
+	return updateConsentIndex(cmdGroup, cmdName, consent.ExpiresAt > time.Now().Unix())
+}
+
+func readConsentIndex() []commandConsentEntry {
+	entries := []commandConsentEntry{}
+	payload, err := os.ReadFile(filepath.Join(config.AppDir(), COMMAND_CONSENT_INDEX_FILE))
+	if err != nil {
+		return entries
+	}
+	if err := json.Unmarshal(payload, &entries); err != nil {
+		return []commandConsentEntry{}
+	}
+	return entries
+}
+
+func isConsentIndexed(cmdGroup string, cmdName string) bool {
+	for _, entry := range readConsentIndex() {
+		if entry.Group == cmdGroup && entry.Name == cmdName {
+			return true
+		}
+	}
+	return false
 }
 
-func requestConsent(cmdGroup string, cmdName string, requests []string) bool {
-	fmt.Printf("Command '%s %s' requests access to the following resources:\n", cmdGroup, cmdName)
+// updateConsentIndex adds or removes a command from the consent index
+func updateConsentIndex(cmdGroup string, cmdName string, present bool) error {
+	entries := []commandConsentEntry{}
+	for _, entry := range readConsentIndex() {
+		if entry.Group != cmdGroup || entry.Name != cmdName {
+			entries = append(entries, entry)
+		}
+	}
+	if present {
+		entries = append(entries, commandConsentEntry{Group: cmdGroup, Name: cmdName})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return getConsentKey(entries[i].Group, entries[i].Name) < getConsentKey(entries[j].Group, entries[j].Name)
+	})
+
+	payload, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.AppDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(config.AppDir(), COMMAND_CONSENT_INDEX_FILE), payload, 0600)
+}
+
+// requestConsent asks the user to authorize the access to the resources,
+// all at once or one by one, and returns the allowed and the denied resources.
+// A resource is only denied when the user explicitly denies it one by one,
+// a refusal is not remembered, the user is asked again on the next run.
+func requestConsent(cmdGroup string, cmdName string, requests []string) ([]string, []string) {
+	fmt.Printf("Command '%s' requests access to the following resources:\n", strings.TrimSpace(cmdGroup+" "+cmdName))
 	for _, request := range requests {
 		fmt.Printf("  - %s\n", request)
 	}
 	fmt.Println()
-	console.Reminder("authorize the access? [yN]")
-	var resp int
-	if _, err := fmt.Scanf("%c", &resp); err != nil || (resp != 'y' && resp != 'Y') {
-		fmt.Printf("Authorization refused by user\n")
-		fmt.Printf("-----------------------------\n\n")
-		return false
-	}
-	return true
+
+	if len(requests) == 1 {
+		console.Reminder("authorize the access? [yN]")
+	} else {
+		console.Reminder("authorize the access? [yNs] (s: select the resources one by one)")
+	}
+
+	switch readAnswer() {
+	case "y":
+		return requests, []string{}
+	case "s":
+		allowed, denied := []string{}, []string{}
+		for _, request := range requests {
+			console.Reminder("authorize the access to %s? [yNd] (d: deny until the consent expires)", request)
+			switch readAnswer() {
+			case "y":
+				allowed = append(allowed, request)
+			case "d":
+				denied = append(denied, request)
+			}
+		}
+		return allowed, denied
+	}
+
+	fmt.Printf("Authorization refused by user\n")
+	fmt.Printf("-----------------------------\n\n")
+	return []string{}, []string{}
+}
+
+// readAnswer reads the lower case first letter of the user answer
+func readAnswer() string {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return ""
+	}
+	line = strings.ToLower(strings.TrimSpace(line))
+	if line == "" {
+		return ""
+	}
+	return line[:1]
+}
+
+func containsResource(resources []string, resource string) bool {
+	for _, r := range resources {
+		if strings.EqualFold(r, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeResources(resources []string, removed []string) []string {
+	kept := []string{}
+	for _, r := range resources {
+		if !containsResource(removed, r) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
 }
@@ -1,9 +1,19 @@
 package consent
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/criteo/command-launcher/internal/config"
 	"github.com/criteo/command-launcher/internal/context"
+	"github.com/criteo/command-launcher/internal/helper"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,3 +42,116 @@ func TestEmptyConsents(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(consent))
 }
+
+func TestNonInteractiveConsentPolicies(t *testing.T) {
+	context.InitContext("test-vault", "1.0.0", "1")
+	isInteractive = func() bool { return false }
+	defer func() {
+		viper.Set(config.USER_CONSENT_POLICY_KEY, config.CONSENT_POLICY_DENY)
+		viper.Set(config.USER_CONSENT_ALLOWED_RESOURCES_KEY, "")
+	}()
+	requests := []string{"USERNAME", "AUTH_TOKEN"}
+
+	viper.Set(config.USER_CONSENT_POLICY_KEY, config.CONSENT_POLICY_DENY)
+	consents, err := GetConsents("non-exist-group", "no-exist-cmd", requests, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(consents))
+
+	viper.Set(config.USER_CONSENT_POLICY_KEY, config.CONSENT_POLICY_ALLOW_LIST)
+	viper.Set(config.USER_CONSENT_ALLOWED_RESOURCES_KEY, "auth_token, LOG_LEVEL")
+	consents, err = GetConsents("non-exist-group", "no-exist-cmd", requests, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AUTH_TOKEN"}, consents)
+
+	viper.Set(config.USER_CONSENT_POLICY_KEY, config.CONSENT_POLICY_FAIL)
+	consents, err = GetConsents("non-exist-group", "no-exist-cmd", requests, true)
+	assert.True(t, errors.Is(err, ErrConsentRequired))
+	assert.Contains(t, err.Error(), "USERNAME, AUTH_TOKEN")
+	assert.Equal(t, 0, len(consents))
+}
+
+func TestConsentResources(t *testing.T) {
+	assert.True(t, IsAvailableConsent("auth_token"))
+	assert.False(t, IsAvailableConsent("PACKAGE_DIR"))
+	assert.Equal(t, []string{"USERNAME", "LOG_LEVEL"}, removeResources([]string{"USERNAME", "PASSWORD", "LOG_LEVEL"}, []string{"password"}))
+}
+
+func TestWithheldResources(t *testing.T) {
+	setupTestVault(t)
+	isInteractive = func() bool { return false }
+	output := &bytes.Buffer{}
+	noticeOutput = output
+	defer func() { noticeOutput = os.Stderr }()
+
+	assert.Nil(t, saveCmdConsent("infra", "deploy", &Consent{
+		ExpiresAt: time.Now().Unix() + 3600,
+		Consents:  []string{"USERNAME"},
+		Denied:    []string{"PASSWORD", "AUTH_TOKEN"},
+	}))
+
+	consents, err := GetConsents("infra", "deploy", []string{"USERNAME", "PASSWORD"}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"USERNAME"}, consents)
+	assert.Contains(t, output.String(), "Command 'infra deploy' runs without access to PASSWORD, denied until ")
+	assert.Contains(t, output.String(), "run 'test-workspace-vault consent revoke infra deploy PASSWORD' to be asked again")
+
+	// nothing is withheld
+	output.Reset()
+	_, err = GetConsents("infra", "deploy", []string{"USERNAME"}, true)
+	assert.Nil(t, err)
+	assert.Empty(t, output.String())
+}
+
+func TestConsentIndexBackfill(t *testing.T) {
+	setupTestVault(t)
+	isInteractive = func() bool { return false }
+
+	// a consent saved before the consent index
+	payload, err := json.Marshal(Consent{ExpiresAt: time.Now().Unix() + 3600, Consents: []string{"USERNAME"}})
+	assert.Nil(t, err)
+	assert.Nil(t, helper.SetSecret(getConsentKey("infra", "deploy"), string(payload)))
+	assert.Empty(t, ListConsents())
+
+	// it is indexed once the command ran
+	consents, err := GetConsents("infra", "deploy", []string{"USERNAME"}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"USERNAME"}, consents)
+	listed := ListConsents()
+	assert.Len(t, listed, 1)
+	assert.Equal(t, "infra", listed[0].Group)
+	assert.Equal(t, "deploy", listed[0].Name)
+	assert.Equal(t, []string{"USERNAME"}, listed[0].Granted)
+}
+
+func TestConsentPromptDenials(t *testing.T) {
+	setupTestVault(t)
+	isInteractive = func() bool { return true }
+	defer func() {
+		isInteractive = func() bool { return false }
+		stdinReader = bufio.NewReader(os.Stdin)
+	}()
+	requests := []string{"USERNAME", "PASSWORD", "AUTH_TOKEN"}
+
+	// a refusal is not remembered
+	stdinReader = bufio.NewReader(strings.NewReader("\n"))
+	consents, err := GetConsents("infra", "deploy", requests, true)
+	assert.Nil(t, err)
+	assert.Empty(t, consents)
+	_, err = getCmdConsents("infra", "deploy")
+	assert.NotNil(t, err)
+
+	// only the explicit denials are remembered
+	stdinReader = bufio.NewReader(strings.NewReader("s\ny\n\nd\n"))
+	consents, err = GetConsents("infra", "deploy", requests, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"USERNAME"}, consents)
+	consent, err := getCmdConsents("infra", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AUTH_TOKEN"}, consent.Denied)
+
+	// the skipped resource is asked again
+	stdinReader = bufio.NewReader(strings.NewReader("y\n"))
+	consents, err = GetConsents("infra", "deploy", requests, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"USERNAME", "PASSWORD"}, consents)
+}
@@ -4,23 +4,52 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/criteo/command-launcher/internal/backend"
 	"github.com/criteo/command-launcher/internal/config"
 	"github.com/criteo/command-launcher/internal/console"
 	"github.com/criteo/command-launcher/internal/helper"
 	"github.com/spf13/viper"
 )
 
+// the folder of the trusted workspace snapshots, used to show the changes
+// of a workspace since it has been trusted
+const WORKSPACE_TRUST_DIR = "workspace-trust"
+
+// the trust states of a workspace
+const (
+	WORKSPACE_TRUSTED   = "trusted"
+	WORKSPACE_CHANGED   = "changed"
+	WORKSPACE_DENIED    = "denied"
+	WORKSPACE_UNTRUSTED = "untrusted"
+)
+
+// WorkspaceTrust is the trust state of a workspace, and its expiration
+type WorkspaceTrust struct {
+	State     string
+	ExpiresAt time.Time
+}
+
+// the snapshot of a trusted workspace, the digest of the consent record in
+// the vault is the reference, the snapshot is only used to report the changes
+type workspaceTrustRecord struct {
+	Dir string `json:"dir"`
+	backend.WorkspaceSnapshot
+}
+
 // CheckWorkspaceConsent checks if the user has consented to run commands
-// from a workspace at the given directory path.
-// Returns true if consent exists and is not expired.
-func CheckWorkspaceConsent(workspaceDir string) bool {
+// from a workspace at the given directory path, with its current content.
+// Returns true if consent exists, is not expired, and the content is unchanged.
+func CheckWorkspaceConsent(workspaceDir string, snapshot *backend.WorkspaceSnapshot) bool {
 	consent, err := getWorkspaceConsent(workspaceDir)
 	if err != nil {
 		return false
 	}
-	return hasConsentValue(consent, "workspace")
+	return hasConsentValue(consent, "workspace") && consent.Digest == snapshot.Digest
 }
 
 // IsWorkspaceConsentDenied checks if the user has explicitly denied consent
@@ -33,47 +62,148 @@ func IsWorkspaceConsentDenied(workspaceDir string) bool {
 	return hasConsentValue(consent, "denied")
 }
 
+// GetWorkspaceTrust returns the trust state of a workspace with its current
+// content, the content is ignored when the snapshot is nil
+func GetWorkspaceTrust(workspaceDir string, snapshot *backend.WorkspaceSnapshot) WorkspaceTrust {
+	consent, err := getWorkspaceConsent(workspaceDir)
+	if err != nil {
+		return WorkspaceTrust{State: WORKSPACE_UNTRUSTED}
+	}
+	trust := WorkspaceTrust{State: WORKSPACE_UNTRUSTED, ExpiresAt: time.Unix(consent.ExpiresAt, 0)}
+	if hasConsentValue(consent, "denied") {
+		trust.State = WORKSPACE_DENIED
+	} else if hasConsentValue(consent, "workspace") {
+		trust.State = WORKSPACE_TRUSTED
+		if snapshot != nil && consent.Digest != snapshot.Digest {
+			trust.State = WORKSPACE_CHANGED
+		}
+	}
+	return trust
+}
+
+// RemoveWorkspaceConsent forgets the consent or the denial of a workspace,
+// the user is asked again on the next workspace command
+func RemoveWorkspaceConsent(workspaceDir string) error {
+	if _, err := getWorkspaceConsent(workspaceDir); err == nil {
+		// the vault has no deletion, an expired record is ignored
+		secretValue, err := json.Marshal(Consent{ExpiresAt: 0, Consents: []string{}})
+		if err != nil {
+			return err
+		}
+		if err := helper.SetSecret(workspaceConsentKey(workspaceDir), string(secretValue)); err != nil {
+			return err
+		}
+	}
+	err := os.Remove(filepath.Join(config.AppDir(), WORKSPACE_TRUST_DIR, workspaceConsentKey(workspaceDir)+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// TrustedWorkspaceDirs returns the directories of the workspaces trusted
+// with their content
+func TrustedWorkspaceDirs() []string {
+	dirs := []string{}
+	entries, err := os.ReadDir(filepath.Join(config.AppDir(), WORKSPACE_TRUST_DIR))
+	if err != nil {
+		return dirs
+	}
+	for _, entry := range entries {
+		payload, err := os.ReadFile(filepath.Join(config.AppDir(), WORKSPACE_TRUST_DIR, entry.Name()))
+		if err != nil {
+			continue
+		}
+		record := workspaceTrustRecord{}
+		if err := json.Unmarshal(payload, &record); err == nil && record.Dir != "" {
+			dirs = append(dirs, record.Dir)
+		}
+	}
+	return dirs
+}
+
 // RequestWorkspaceConsent prompts the user to trust a workspace.
-// Displays the workspace path and asks for y/N confirmation.
+// Displays the workspace path, and the changed commands when the workspace
+// has been trusted with a different content, and asks for y/N confirmation.
 // On approval, saves consent with expiration from USER_CONSENT_LIFE_KEY config.
 // On denial, saves denial with the same expiration.
-func RequestWorkspaceConsent(workspaceDir string) bool {
+// In a non-interactive session, the workspace is not trusted and nothing is
+// saved, an error is returned, wrapping ErrConsentRequired with the "fail"
+// consent policy.
+func RequestWorkspaceConsent(workspaceDir string, snapshot *backend.WorkspaceSnapshot) (bool, error) {
+	if !isInteractive() {
+		err := fmt.Errorf("workspace %s is not trusted with its current content, trust it with the workspace trust command", workspaceDir)
+		if viper.GetString(config.USER_CONSENT_POLICY_KEY) == config.CONSENT_POLICY_FAIL {
+			err = fmt.Errorf("%w: %v", ErrConsentRequired, err)
+		}
+		return false, err
+	}
+
 	fmt.Printf("This command is provided by workspace: %s\n", workspaceDir)
+	if consent, err := getWorkspaceConsent(workspaceDir); err == nil && hasConsentValue(consent, "workspace") {
+		if consent.Digest == "" {
+			fmt.Printf("This workspace has been trusted before its content was recorded, please review it again.\n")
+		} else {
+			printWorkspaceChanges(workspaceDir, snapshot)
+		}
+	}
 	console.Reminder("Do you trust and want to run commands from this workspace? [yN]")
 
-	var resp int
-	if _, err := fmt.Scanf("%c", &resp); err != nil || (resp != 'y' && resp != 'Y') {
+	if readAnswer() != "y" {
 		fmt.Printf("Workspace command execution denied.\n")
 		fmt.Printf("-----------------------------\n\n")
-		if err := saveWorkspaceConsentRecord(workspaceDir, "denied"); err != nil {
+		if err := saveWorkspaceConsentRecord(workspaceDir, "denied", ""); err != nil {
 			fmt.Printf("Warning: failed to save workspace denial: %v\n", err)
 		}
-		return false
+		return false, nil
 	}
 
-	if err := SaveWorkspaceConsent(workspaceDir); err != nil {
+	if err := SaveWorkspaceConsent(workspaceDir, snapshot); err != nil {
 		fmt.Printf("Warning: failed to save workspace consent: %v\n", err)
 	}
 
-	return true
+	return true, nil
 }
 
-// SaveWorkspaceConsent persists consent for a workspace directory.
-func SaveWorkspaceConsent(workspaceDir string) error {
-	return saveWorkspaceConsentRecord(workspaceDir, "workspace")
+// SaveWorkspaceConsent persists consent for a workspace directory and its content.
+func SaveWorkspaceConsent(workspaceDir string, snapshot *backend.WorkspaceSnapshot) error {
+	if err := saveWorkspaceConsentRecord(workspaceDir, "workspace", snapshot.Digest); err != nil {
+		return err
+	}
+	return saveWorkspaceTrustRecord(workspaceDir, snapshot)
 }
 
-func saveWorkspaceConsentRecord(workspaceDir string, consentType string) error {
-	keyLife := viper.GetDuration(config.USER_CONSENT_LIFE_KEY).Seconds()
-	if keyLife <= 0 {
-		// default: 30 days
-		keyLife = 2592000
+// printWorkspaceChanges reports the commands changed since the workspace has been trusted
+func printWorkspaceChanges(workspaceDir string, snapshot *backend.WorkspaceSnapshot) {
+	previous, err := getWorkspaceTrustRecord(workspaceDir)
+	if err != nil {
+		fmt.Printf("The content of this workspace has changed since you trusted it.\n")
+		return
+	}
+
+	changes := snapshot.Changes(&previous.WorkspaceSnapshot)
+	if changes.IsEmpty() {
+		fmt.Printf("The packages of this workspace have changed since you trusted it, its commands are unchanged.\n")
+		return
+	}
+	fmt.Printf("The commands of this workspace have changed since you trusted it:\n")
+	if len(changes.Added) > 0 {
+		fmt.Printf("  added:   %s\n", strings.Join(changes.Added, ", "))
+	}
+	if len(changes.Changed) > 0 {
+		fmt.Printf("  changed: %s\n", strings.Join(changes.Changed, ", "))
+	}
+	if len(changes.Removed) > 0 {
+		fmt.Printf("  removed: %s\n", strings.Join(changes.Removed, ", "))
 	}
+}
 
+func saveWorkspaceConsentRecord(workspaceDir string, consentType string, digest string) error {
 	key := workspaceConsentKey(workspaceDir)
 	secretValue, err := json.Marshal(Consent{
-		ExpiresAt: time.Now().Unix() + int64(keyLife),
+		ExpiresAt: time.Now().Unix() + consentLife(),
 		Consents:  []string{consentType},
+		Digest:    digest,
 	})
 	if err != nil {
 		return err
@@ -101,6 +231,30 @@ func getWorkspaceConsent(workspaceDir string) (*Consent, error) {
 	return &consent, nil
 }
 
+func saveWorkspaceTrustRecord(workspaceDir string, snapshot *backend.WorkspaceSnapshot) error {
+	dir := filepath.Join(config.AppDir(), WORKSPACE_TRUST_DIR)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create the workspace trust folder: %v", err)
+	}
+	payload, err := json.MarshalIndent(workspaceTrustRecord{Dir: workspaceDir, WorkspaceSnapshot: *snapshot}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, workspaceConsentKey(workspaceDir)+".json"), payload, 0644)
+}
+
+func getWorkspaceTrustRecord(workspaceDir string) (*workspaceTrustRecord, error) {
+	payload, err := os.ReadFile(filepath.Join(config.AppDir(), WORKSPACE_TRUST_DIR, workspaceConsentKey(workspaceDir)+".json"))
+	if err != nil {
+		return nil, err
+	}
+	record := workspaceTrustRecord{}
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
 func hasConsentValue(consent *Consent, value string) bool {
 	for _, c := range consent.Consents {
 		if c == value {
@@ -0,0 +1,89 @@
+package consent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTestVault uses a file vault and an app home dedicated to the test
+func setupTestVault(t *testing.T) {
+	t.Helper()
+	ctx := context.InitContext("test-workspace-vault", "1.0.0", "1")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CDT_VAULT_SECRET", "test-secret")
+	t.Setenv(ctx.AppHomeEnvVar(), t.TempDir())
+}
+
+func TestGetWorkspaceTrust(t *testing.T) {
+	setupTestVault(t)
+	workspaceDir := t.TempDir()
+	snapshot := &backend.WorkspaceSnapshot{Digest: "digest-1", Commands: map[string]string{"build": "cmd-1"}}
+
+	assert.Equal(t, WORKSPACE_UNTRUSTED, GetWorkspaceTrust(workspaceDir, snapshot).State)
+
+	assert.Nil(t, SaveWorkspaceConsent(workspaceDir, snapshot))
+	trust := GetWorkspaceTrust(workspaceDir, snapshot)
+	assert.Equal(t, WORKSPACE_TRUSTED, trust.State)
+	assert.False(t, trust.ExpiresAt.IsZero())
+	assert.True(t, CheckWorkspaceConsent(workspaceDir, snapshot))
+
+	// a changed content, ignored without snapshot
+	changed := &backend.WorkspaceSnapshot{Digest: "digest-2", Commands: map[string]string{"build": "cmd-2"}}
+	assert.Equal(t, WORKSPACE_CHANGED, GetWorkspaceTrust(workspaceDir, changed).State)
+	assert.False(t, CheckWorkspaceConsent(workspaceDir, changed))
+	assert.Equal(t, WORKSPACE_TRUSTED, GetWorkspaceTrust(workspaceDir, nil).State)
+
+	assert.Nil(t, saveWorkspaceConsentRecord(workspaceDir, "denied", ""))
+	assert.Equal(t, WORKSPACE_DENIED, GetWorkspaceTrust(workspaceDir, snapshot).State)
+	assert.True(t, IsWorkspaceConsentDenied(workspaceDir))
+}
+
+func TestRemoveWorkspaceConsent(t *testing.T) {
+	setupTestVault(t)
+	trustedDir := t.TempDir()
+	deniedDir := t.TempDir()
+	snapshot := &backend.WorkspaceSnapshot{Digest: "digest-1", Commands: map[string]string{"build": "cmd-1"}}
+
+	assert.Nil(t, SaveWorkspaceConsent(trustedDir, snapshot))
+	assert.Nil(t, saveWorkspaceConsentRecord(deniedDir, "denied", ""))
+	// only the workspaces trusted with their content are listed
+	assert.Equal(t, []string{trustedDir}, TrustedWorkspaceDirs())
+
+	assert.Nil(t, RemoveWorkspaceConsent(trustedDir))
+	assert.Equal(t, WORKSPACE_UNTRUSTED, GetWorkspaceTrust(trustedDir, snapshot).State)
+	assert.Empty(t, TrustedWorkspaceDirs())
+
+	assert.Nil(t, RemoveWorkspaceConsent(deniedDir))
+	assert.Equal(t, WORKSPACE_UNTRUSTED, GetWorkspaceTrust(deniedDir, nil).State)
+
+	// a workspace without consent
+	assert.Nil(t, RemoveWorkspaceConsent(t.TempDir()))
+}
+
+func TestRequestWorkspaceConsentNonInteractive(t *testing.T) {
+	setupTestVault(t)
+	isInteractive = func() bool { return false }
+	defer viper.Set(config.USER_CONSENT_POLICY_KEY, config.CONSENT_POLICY_DENY)
+	workspaceDir := t.TempDir()
+	snapshot := &backend.WorkspaceSnapshot{Digest: "digest-1", Commands: map[string]string{"build": "cmd-1"}}
+
+	viper.Set(config.USER_CONSENT_POLICY_KEY, config.CONSENT_POLICY_DENY)
+	trusted, err := RequestWorkspaceConsent(workspaceDir, snapshot)
+	assert.False(t, trusted)
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrConsentRequired))
+
+	viper.Set(config.USER_CONSENT_POLICY_KEY, config.CONSENT_POLICY_FAIL)
+	trusted, err = RequestWorkspaceConsent(workspaceDir, snapshot)
+	assert.False(t, trusted)
+	assert.True(t, errors.Is(err, ErrConsentRequired))
+
+	// no denial is saved, the workspace stays loaded
+	assert.Equal(t, WORKSPACE_UNTRUSTED, GetWorkspaceTrust(workspaceDir, snapshot).State)
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/spf13/cobra"
+)
+
+/*
+A command can be called in the namespace of its package, whatever its runtime
+name is after a conflict or a rename:
+
+	cola @[repository]/[package] [group] [name] [args...]
+	cola --from [repository]:[package] [group] [name] [args...]
+
+The group and the name are the ones defined in the package manifest. The
+namespaced command line is rewritten to the runtime group and name of the
+command before being parsed.
+*/
+
+// FROM_FLAG selects the package of the command to run, ex: --from dropin:my-package
+const FROM_FLAG = "from"
+
+type commandNamespace struct {
+	repo string
+	pkg  string
+}
+
+func (ns commandNamespace) String() string {
+	return fmt.Sprintf("%s:%s", ns.repo, ns.pkg)
+}
+
+// namespacedArgs rewrites the namespaced command placed before the first
+// positional arg to its runtime group and name
+func namespacedArgs(args []string, back backend.Backend) ([]string, error) {
+	completing := len(args) > 0 && (args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd)
+	isLast := func(i int) bool { return completing && i == len(args)-1 }
+
+	out := []string{}
+	i := 0
+	if completing {
+		out = append(out, args[0])
+		i = 1
+	}
+
+	var ns *commandNamespace
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			break
+		}
+		value, found := "", false
+		if arg == "--"+FROM_FLAG {
+			if i+1 >= len(args) || isLast(i+1) {
+				// the namespace is being completed
+				return args, nil
+			}
+			value, found = args[i+1], true
+			i++
+		} else if v, ok := strings.CutPrefix(arg, "--"+FROM_FLAG+"="); ok {
+			if isLast(i) {
+				return args, nil
+			}
+			value, found = v, true
+		}
+		if !found {
+			out = append(out, arg)
+			continue
+		}
+
+		repo, pkg, ok := strings.Cut(value, ":")
+		if !ok || repo == "" || pkg == "" {
+			return nil, fmt.Errorf("invalid namespace %s, must be in form of repository:package", value)
+		}
+		ns = &commandNamespace{repo: repo, pkg: pkg}
+	}
+
+	if ns == nil && i < len(args) && strings.HasPrefix(args[i], "@") {
+		if isLast(i) {
+			// the namespace is being completed
+			return args, nil
+		}
+		repo, pkg, ok := strings.Cut(strings.TrimPrefix(args[i], "@"), "/")
+		if !ok || repo == "" || pkg == "" {
+			return nil, fmt.Errorf("invalid namespace %s, must be in form of @repository/package", args[i])
+		}
+		ns = &commandNamespace{repo: repo, pkg: pkg}
+		i++
+	}
+
+	if ns == nil {
+		return args, nil
+	}
+
+	words := args[i:]
+	if completing {
+		// the last word is the one to complete
+		if len(words) == 1 {
+			// keep the namespace as a flag, so that the completion of the
+			// root command knows the package
+			return append(out, "--"+FROM_FLAG, ns.String(), words[0]), nil
+		}
+		runtimeWords, consumed, err := namespacedCommand(back, *ns, words[:len(words)-1])
+		if err != nil {
+			return append(out, words...), nil
+		}
+		out = append(out, runtimeWords...)
+		return append(out, words[consumed:]...), nil
+	}
+
+	runtimeWords, consumed, err := namespacedCommand(back, *ns, words)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, runtimeWords...)
+	return append(out, words[consumed:]...), nil
+}
+
+// namespacedCommand finds the command named by the first words in the
+// namespace, and returns its runtime group and name, and the number of words used
+func namespacedCommand(back backend.Backend, ns commandNamespace, words []string) ([]string, int, error) {
+	if len(words) == 0 || strings.HasPrefix(words[0], "-") {
+		return []string{}, 0, fmt.Errorf("no command specified after the namespace %s", ns)
+	}
+
+	if len(words) > 1 && !strings.HasPrefix(words[1], "-") {
+		fullName := command.CmdReverseID(ns.repo, ns.pkg, words[0], words[1])
+		if cmd, err := back.FindCommandByFullName(fullName); err == nil {
+			return []string{cmd.RuntimeGroup(), cmd.RuntimeName()}, 2, nil
+		}
+	}
+
+	fullName := command.CmdReverseID(ns.repo, ns.pkg, "", words[0])
+	cmd, err := back.FindCommandByFullName(fullName)
+	if err != nil {
+		return []string{}, 0, fmt.Errorf("no command %s found in the namespace %s", words[0], ns)
+	}
+	return []string{cmd.RuntimeName()}, 1, nil
+}
+
+// completeNamespace completes the namespaces, in form of "@repository/package"
+// or "repository:package" for the --from flag
+func completeNamespace(back backend.Backend, toComplete string, asFlag bool) []string {
+	namespaces := map[string]bool{}
+	for _, cmd := range allCommands(back) {
+		ns := fmt.Sprintf("@%s/%s", cmd.RepositoryID(), cmd.PackageName())
+		if asFlag {
+			ns = commandNamespace{repo: cmd.RepositoryID(), pkg: cmd.PackageName()}.String()
+		}
+		if strings.HasPrefix(ns, toComplete) {
+			namespaces[ns] = true
+		}
+	}
+	return sortedKeys(namespaces)
+}
+
+// completeNamespacedCommand completes the top level commands of a namespace
+// with their names defined in the package manifest
+func completeNamespacedCommand(back backend.Backend, from string, toComplete string) []string {
+	repo, pkg, _ := strings.Cut(from, ":")
+	names := map[string]bool{}
+	for _, cmd := range allCommands(back) {
+		if cmd.RepositoryID() != repo || cmd.PackageName() != pkg || cmd.Group() != "" {
+			continue
+		}
+		if strings.HasPrefix(cmd.Name(), toComplete) {
+			names[cmd.Name()] = true
+		}
+	}
+	return sortedKeys(names)
+}
+
+func allCommands(back backend.Backend) []command.Command {
+	cmds := append([]command.Command{}, back.GroupCommands()...)
+	return append(cmds, back.ExecutableCommands()...)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
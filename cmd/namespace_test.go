@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+func createNamespaceTestBackend(t *testing.T) backend.Backend {
+	t.Helper()
+	dropinDir := t.TempDir()
+	for _, pkg := range []string{"a", "b"} {
+		pkgDir := filepath.Join(dropinDir, pkg)
+		assert.Nil(t, os.MkdirAll(pkgDir, 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(pkgDir, "manifest.mf"), []byte(`{
+  "pkgName": "`+pkg+`",
+  "version": "1.0.0",
+  "cmds": [
+    {"name": "infra", "type": "group"},
+    {"name": "deploy", "type": "executable", "group": "infra", "executable": "echo"},
+    {"name": "hello", "type": "executable", "executable": "echo"}
+  ]
+}`), 0644))
+	}
+
+	back, err := backend.NewDefaultBackend(t.TempDir(), []*backend.PackageSource{}, backend.NewDropinSource(dropinDir), backend.NewManagedSource("default", t.TempDir(), "", backend.SYNC_POLICY_NEVER))
+	assert.Nil(t, err)
+	return back
+}
+
+func TestNamespacedArgs(t *testing.T) {
+	back := createNamespaceTestBackend(t)
+
+	args, err := namespacedArgs([]string{"@dropin/b", "infra", "deploy", "--flag", "arg"}, back)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"infra@@b@dropin", "deploy", "--flag", "arg"}, args)
+
+	args, err = namespacedArgs([]string{"--from", "dropin:b", "hello", "infra"}, back)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello@@b@dropin", "infra"}, args)
+
+	// the winner of the conflict keeps its name
+	args, err = namespacedArgs([]string{"--from=dropin:a", "infra", "deploy"}, back)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"infra", "deploy"}, args)
+
+	// not namespaced
+	args, err = namespacedArgs([]string{"infra", "@dropin/b"}, back)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"infra", "@dropin/b"}, args)
+
+	for _, invalid := range [][]string{{"@dropin"}, {"@dropin/c", "hello"}, {"@dropin/b"}, {"--from", "dropin", "hello"}, {"@dropin/b", "unknown"}} {
+		_, err = namespacedArgs(invalid, back)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestNamespacedArgsCompletion(t *testing.T) {
+	back := createNamespaceTestBackend(t)
+
+	// the namespace is completed by the root command
+	args, err := namespacedArgs([]string{"__complete", "@dro"}, back)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"__complete", "@dro"}, args)
+	assert.Equal(t, []string{"@dropin/a", "@dropin/b"}, completeNamespace(back, "@dro", false))
+	assert.Equal(t, []string{"dropin:b"}, completeNamespace(back, "dropin:b", true))
+
+	// the top level command is completed in the namespace
+	args, err = namespacedArgs([]string{"__complete", "@dropin/b", "he"}, back)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"__complete", "--from", "dropin:b", "he"}, args)
+	assert.Equal(t, []string{"hello"}, completeNamespacedCommand(back, "dropin:b", "he"))
+
+	// the completed words are rewritten
+	args, err = namespacedArgs([]string{"__complete", "@dropin/b", "infra", ""}, back)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"__complete", "infra@@b@dropin", ""}, args)
+}
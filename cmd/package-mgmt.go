@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,6 +33,13 @@ type PackageFlags struct {
 	remote     bool
 	workspace  bool
 	includeCmd bool
+	pauseFor   string
+	remoteName string
+	gitRef     string
+	gitPath    string
+	repair     bool
+	sideBySide bool
+	pinFor     string
 }
 
 var (
@@ -107,46 +115,154 @@ func AddPackageCmd(rootCmd *cobra.Command, appCtx context.LauncherContext) {
 	packageListCmd.MarkFlagsMutuallyExclusive("all", "dropin", "local", "remote", "workspace")
 
 	packageInstallCmd := &cobra.Command{
-		Use:   "install [package_name]",
-		Short: "Install a dropin package",
-		Long:  "Install a dropin package package from a git repo or from a zip file or from its name",
-		Args:  cobra.MaximumNArgs(1),
+		Use:   "install [package_name[@version]]",
+		Short: "Install a package",
+		Long: `Install a package from a git repo or from a zip file into the dropin repository,
+or from its name from a remote registry into the managed repository of the remote`,
+		Args: cobra.MaximumNArgs(1),
 		Example: fmt.Sprintf(`
-  %s install --git https://example.com/my-repo.git my-pkg`, appCtx.AppName()),
+  %s package install --git https://example.com/my-repo.git
+  %s package install --git https://example.com/monorepo.git --ref v1.2.0 --path packages/my-pkg
+  %s package install --file https://example.com/my-pkg-1.0.0.pkg
+  %s package install my-pkg
+  %s package install my-pkg@1.0.0 --remote my-remote
+  %s package install my-pkg --dropin
+  %s package install my-pkg@1.0.0 --side-by-side`, appCtx.AppName(), appCtx.AppName(), appCtx.AppName(), appCtx.AppName(), appCtx.AppName(), appCtx.AppName(), appCtx.AppName()),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if packageFlags.gitUrl == "" && (packageFlags.gitRef != "" || packageFlags.gitPath != "") {
+				return fmt.Errorf("--ref and --path can only be used with --git")
+			}
+
 			if packageFlags.fileUrl != "" {
-				return installZipFile(packageFlags.fileUrl)
+				return installPackageFile(packageFlags.fileUrl)
 			}
 
 			if packageFlags.gitUrl != "" {
-				return installGitRepo(packageFlags.gitUrl)
+				return installGitRepo(packageFlags.gitUrl, packageFlags.gitRef, packageFlags.gitPath)
 			}
 
-			return nil
+			if len(args) == 1 {
+				if packageFlags.sideBySide {
+					return installPackageVersion(appCtx.AppName(), args[0], packageFlags.remoteName)
+				}
+				return installRemotePackage(appCtx.AppName(), args[0], packageFlags.remoteName, packageFlags.dropin, packageFlags.pinFor)
+			}
+
+			return cmd.Help()
 		},
-		ValidArgsFunction: noArgCompletion,
+		ValidArgsFunction: packageNameValidatonFunc(false, false, true),
 	}
-	packageInstallCmd.Flags().StringVar(&packageFlags.fileUrl, "file", "", "URL or path of a package file")
+	packageInstallCmd.Flags().StringVar(&packageFlags.fileUrl, "file", "", "URL or path of a package file (zip, tar.gz, or tar.zst)")
 	packageInstallCmd.Flags().StringVar(&packageFlags.gitUrl, "git", "", "URL of a Git repo of package")
+	packageInstallCmd.Flags().StringVar(&packageFlags.gitRef, "ref", "", "Tag, branch, or commit sha of the Git repo to install")
+	packageInstallCmd.Flags().StringVar(&packageFlags.gitPath, "path", "", "Folder of the package inside the Git repo")
+	packageInstallCmd.Flags().StringVar(&packageFlags.remoteName, "remote", "default", "Name of the remote to install the package from")
+	packageInstallCmd.Flags().BoolVar(&packageFlags.dropin, "dropin", false, "Install the package from the remote into the dropin repository")
+	packageInstallCmd.Flags().BoolVar(&packageFlags.sideBySide, "side-by-side", false, "Install the package version from the remote side by side with the installed version")
+	packageInstallCmd.Flags().StringVar(&packageFlags.pinFor, "pin-for", DEFAULT_PIN_DURATION, "Duration of the update pause of a package version installed in a managed repository, ex: 7d, 2w")
 	packageInstallCmd.MarkFlagsMutuallyExclusive("git", "file")
+	packageInstallCmd.MarkFlagsMutuallyExclusive("dropin", "side-by-side")
+	packageInstallCmd.RegisterFlagCompletionFunc("remote", remoteNameCompletion)
+
+	packageUpdateCmd := &cobra.Command{
+		Use:   "update [package_name]",
+		Short: "Update a dropin package installed from a Git repo",
+		Long:  "Fetch the Git repo that a dropin package has been installed from, and re-install the package from the recorded ref and path",
+		Args:  cobra.ExactArgs(1),
+		Example: fmt.Sprintf(`
+  %s package update my-pkg`, appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateGitPackage(args[0])
+		},
+		ValidArgsFunction: packageNameValidatonFunc(false, true, false),
+	}
+
+	packageLinkCmd := &cobra.Command{
+		Use:   "link [package_dir]",
+		Short: "Link a package folder into the dropin repository",
+		Long: `Link a package folder into the dropin repository for development.
+
+The package is not copied, a symbolic link is created in the dropin repository instead:
+the changes in the package folder are immediately available.`,
+		Args: cobra.ExactArgs(1),
+		Example: fmt.Sprintf(`
+  %s package link ./my-pkg`, appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return linkPackage(args[0])
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) >= 1 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		},
+	}
+
+	packageUnlinkCmd := &cobra.Command{
+		Use:   "unlink [package_name]",
+		Short: "Unlink a linked dropin package",
+		Long:  "Remove the link of a package linked into the dropin repository, the linked folder is kept",
+		Args:  cobra.ExactArgs(1),
+		Example: fmt.Sprintf(`
+  %s package unlink my-pkg`, appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			folder, err := findPackageFolder(args[0])
+			if err != nil {
+				return err
+			}
+			if _, linked := pkg.LinkTarget(folder); !linked {
+				return fmt.Errorf("package %s is not linked, use '%s package delete' to remove it", args[0], appCtx.AppName())
+			}
+			if err := pkg.UnlinkPackage(folder); err != nil {
+				return err
+			}
+			console.Success("Package %s unlinked from the dropin repository\n", args[0])
+			return nil
+		},
+		ValidArgsFunction: linkedPackageNameCompletion,
+	}
 
 	packageDeleteCmd := &cobra.Command{
-		Use:   "delete [package_name]",
+		Use:   "delete [package_name[@version]]",
 		Short: "Remove a dropin package",
-		Long:  "Remove a dropin package from its name",
-		Args:  cobra.ExactArgs(1),
+		Long: `Remove a dropin package from its name,
+or a package version installed side by side in the managed repository of a remote`,
+		Args: cobra.ExactArgs(1),
 		Example: fmt.Sprintf(`
-  %s delete my-pkg`, appCtx.AppName()),
+  %s delete my-pkg
+  %s delete my-pkg@1.0.0 --remote my-remote`, appCtx.AppName(), appCtx.AppName()),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if name, version, found := strings.Cut(args[0], "@"); found {
+				source, err := managedPackageSource(packageFlags.remoteName)
+				if err != nil {
+					return err
+				}
+				if err := source.Repo.UninstallVersion(name, version); err != nil {
+					return err
+				}
+				console.Success("Package %s@%s removed from the managed repository '%s'\n", name, version, source.Name)
+				return nil
+			}
+
 			folder, err := findPackageFolder(args[0])
 			if err != nil {
 				return err
 			}
 
+			if viper.GetBool(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY) {
+				if installedPkg, err := rootCtxt.backend.DropinRepository().Package(args[0]); err == nil {
+					if err := pkg.ExecHookFromPackage(installedPkg, pkg.UNINSTALL_HOOK, folder); err != nil {
+						return err
+					}
+				}
+			}
+
 			return os.RemoveAll(folder)
 		},
 		ValidArgsFunction: packageNameValidatonFunc(false, true, false),
 	}
+	packageDeleteCmd.Flags().StringVar(&packageFlags.remoteName, "remote", "default", "Name of the remote that manages the package version")
+	packageDeleteCmd.RegisterFlagCompletionFunc("remote", remoteNameCompletion)
 
 	packageSetupCmd := &cobra.Command{
 		Use:   "setup [package_name]",
@@ -187,30 +303,155 @@ To enable the automatic setup during package installation, enable the configurat
 		ValidArgsFunction: packageNameValidatonFunc(true, true, false),
 	}
 
+	packageVerifyCmd := &cobra.Command{
+		Use:   "verify [package_name]",
+		Short: "Verify the integrity of installed packages",
+		Long: `Verify the files of installed packages against the sha256 hashes recorded during their installation.
+
+Report the modified, missing, and extra files of each package. With --repair, the exact
+installed version is re-installed from its remote, or from its git origin for dropin packages.
+All installed packages are verified when no package name is specified.`,
+		Args: cobra.MaximumNArgs(1),
+		Example: fmt.Sprintf(`
+  %s package verify
+  %s package verify my-pkg --repair`, appCtx.AppName(), appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return verifyPackages(name, packageFlags.repair)
+		},
+		ValidArgsFunction: packageNameValidatonFunc(true, true, false),
+	}
+	packageVerifyCmd.Flags().BoolVar(&packageFlags.repair, "repair", false, "Re-install the packages that failed the verification")
+
 	packagePauseCmd := &cobra.Command{
 		Use:   "pause [package_name]",
 		Short: "Pause update for a package",
-		Long:  "Pause update for a package",
+		Long:  "Pause the automatic update of a managed package for a given duration (24h by default)",
 		Args:  cobra.ExactArgs(1),
 		Example: fmt.Sprintf(`
-  %s pause my-pkg`, appCtx.AppName()),
+  %s package pause my-pkg
+  %s package pause my-pkg --for 7d --remote my-remote`, appCtx.AppName(), appCtx.AppName()),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := rootCtxt.backend.DefaultRepository().PausePackageUpdate(args[0])
+			duration, err := helper.ParseDuration(packageFlags.pauseFor)
+			if err != nil || duration <= 0 {
+				return fmt.Errorf("invalid pause duration %s, must be a positive duration like 12h, 7d or 2w", packageFlags.pauseFor)
+			}
+			source, err := managedPackageSource(packageFlags.remoteName)
 			if err != nil {
 				return err
 			}
-			console.Success("Package %s updates are paused", args[0])
+			reason := fmt.Sprintf("manually paused by %s package pause", appCtx.AppName())
+			if err := source.Repo.PausePackageUpdate(args[0], duration, reason); err != nil {
+				return err
+			}
+			console.Success("Package %s updates are paused in remote '%s' until %s\n",
+				args[0], source.Name, time.Now().Add(duration).Format(time.RFC3339))
 			return nil
 		},
 		ValidArgsFunction: packageNameValidatonFunc(true, true, false),
 	}
+	packagePauseCmd.Flags().StringVar(&packageFlags.pauseFor, "for", updateConfig.DEFAULT_UPDATE_PAUSE_DURATION.String(), "Duration of the pause, ex: 12h, 7d, 2w")
+	packagePauseCmd.Flags().StringVar(&packageFlags.remoteName, "remote", "default", "Name of the remote that manages the package")
+	packagePauseCmd.RegisterFlagCompletionFunc("remote", remoteNameCompletion)
+
+	packageResumeCmd := &cobra.Command{
+		Use:   "resume [package_name]",
+		Short: "Resume update for a paused package",
+		Long:  "Resume the automatic update of a paused managed package",
+		Args:  cobra.ExactArgs(1),
+		Example: fmt.Sprintf(`
+  %s package resume my-pkg --remote my-remote`, appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source, err := managedPackageSource(packageFlags.remoteName)
+			if err != nil {
+				return err
+			}
+			paused, err := source.Repo.IsPackageUpdatePaused(args[0])
+			if err != nil {
+				return err
+			}
+			if !paused {
+				return fmt.Errorf("package %s is not paused in remote '%s'", args[0], source.Name)
+			}
+			if err := source.Repo.ResumePackageUpdate(args[0]); err != nil {
+				return err
+			}
+			console.Success("Package %s updates are resumed in remote '%s'\n", args[0], source.Name)
+			return nil
+		},
+		ValidArgsFunction: pausedPackageNameCompletion,
+	}
+	packageResumeCmd.Flags().StringVar(&packageFlags.remoteName, "remote", "default", "Name of the remote that manages the package")
+	packageResumeCmd.RegisterFlagCompletionFunc("remote", remoteNameCompletion)
+
+	packagePausedCmd := &cobra.Command{
+		Use:   "paused",
+		Short: "List paused packages",
+		Long:  "List the paused packages of all managed repositories, with the expiration and the reason of the pause",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printPausedPackages()
+			return nil
+		},
+		ValidArgsFunction: noArgCompletion,
+	}
+
+	packageDisableCmd := &cobra.Command{
+		Use:   "disable [[source:]package_name]",
+		Short: "Disable a package",
+		Long: `Disable a package: it stays installed, but its commands are hidden until it is enabled again.
+The package is only disabled in its source, prefix its name with the source when several sources provide it`,
+		Args: cobra.ExactArgs(1),
+		Example: fmt.Sprintf(`
+  %s package disable my-pkg
+  %s package disable dropin:my-pkg`, appCtx.AppName(), appCtx.AppName()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := rootCtxt.backend.DisablePackage(args[0]); err != nil {
+				return err
+			}
+			console.Success("Package %s disabled\n", args[0])
+			return nil
+		},
+		ValidArgsFunction: packageNameValidatonFunc(true, true, false),
+	}
+
+	packageEnableCmd := &cobra.Command{
+		Use:   "enable [[source:]package_name]",
+		Short: "Enable a disabled package",
+		Long:  "Enable a disabled package, its commands are available again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := rootCtxt.backend.EnablePackage(args[0]); err != nil {
+				return err
+			}
+			console.Success("Package %s enabled\n", args[0])
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return rootCtxt.backend.DisabledPackages(), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
 
 	packageCmd.AddCommand(packageListCmd)
 	packageCmd.AddCommand(packageInstallCmd)
+	packageCmd.AddCommand(packageUpdateCmd)
 	packageCmd.AddCommand(packageDeleteCmd)
+	packageCmd.AddCommand(packageLinkCmd)
+	packageCmd.AddCommand(packageUnlinkCmd)
 	packageCmd.AddCommand(packageSetupCmd)
 	packageCmd.AddCommand(packagePauseCmd)
+	packageCmd.AddCommand(packageResumeCmd)
+	packageCmd.AddCommand(packagePausedCmd)
 	packageCmd.AddCommand(packageInspectCmd)
+	packageCmd.AddCommand(packageVerifyCmd)
+	packageCmd.AddCommand(packageDisableCmd)
+	packageCmd.AddCommand(packageEnableCmd)
 	rootCmd.AddCommand(packageCmd)
 }
 
@@ -234,7 +475,26 @@ func packageNameValidatonFunc(includeLocal bool, includeDropin bool, includeRemo
 		}
 
 		if includeRemote {
-			remote := remote.CreateRemoteRepository(viper.GetString(config.COMMAND_REPOSITORY_BASE_URL_KEY))
+			remoteUrl := viper.GetString(config.COMMAND_REPOSITORY_BASE_URL_KEY)
+			// follow the --remote flag when the command has one
+			if remoteName, err := c.Flags().GetString("remote"); err == nil && remoteName != "" {
+				if source, err := managedPackageSource(remoteName); err == nil {
+					remoteUrl = source.RemoteBaseURL
+				}
+			}
+			remote := remote.CreateRemoteRepository(remoteUrl)
+
+			// complete the versions once the package name is typed
+			if name, _, found := strings.Cut(toComplete, "@"); found {
+				versions := []string{}
+				if pkgVersions, err := remote.Versions(name); err == nil {
+					for _, v := range pkgVersions {
+						versions = append(versions, fmt.Sprintf("%s@%s", name, v))
+					}
+				}
+				return versions, cobra.ShellCompDirectiveNoFileComp
+			}
+
 			if packages, err := remote.All(); err == nil {
 				for _, pkg := range packages {
 					pkgTable[pkg.Name] = pkg.Version
@@ -251,6 +511,38 @@ func packageNameValidatonFunc(includeLocal bool, includeDropin bool, includeRemo
 	}
 }
 
+func pausedPackageNameCompletion(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 1 {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	pkgNames := []string{}
+	for _, p := range pausedPackages() {
+		pkgNames = append(pkgNames, fmt.Sprintf("%s\tpaused in remote '%s'", p.name, p.source.Name))
+	}
+	return pkgNames, cobra.ShellCompDirectiveNoFileComp
+}
+
+func linkedPackageNameCompletion(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 1 {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	pkgNames := []string{}
+	for _, p := range rootCtxt.backend.DropinRepository().InstalledPackages() {
+		if target, linked := linkedPackageTarget(p); linked {
+			pkgNames = append(pkgNames, fmt.Sprintf("%s\tlinked to %s", p.Name(), target))
+		}
+	}
+	return pkgNames, cobra.ShellCompDirectiveNoFileComp
+}
+
+func remoteNameCompletion(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	remoteNames := []string{}
+	for _, remote := range getAllRemotes() {
+		remoteNames = append(remoteNames, fmt.Sprintf("%s\t%s", remote.Name, remote.RemoteBaseUrl))
+	}
+	return remoteNames, cobra.ShellCompDirectiveNoFileComp
+}
+
 func noArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	return nil, cobra.ShellCompDirectiveNoFileComp
 }
@@ -258,10 +550,19 @@ func noArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]st
 func printPackages(repo repository.PackageRepository, name string, includeCmd bool) {
 	console.Highlight("=== %s ===\n", strings.Title(name))
 	for _, pkg := range repo.InstalledPackages() {
+		status := []string{}
+		if target, linked := linkedPackageTarget(pkg); linked {
+			status = append(status, fmt.Sprintf("linked -> %s", target))
+		}
+		if rootCtxt.backend.IsPackageDisabled(repo.Name(), pkg.Name()) {
+			status = append(status, "disabled")
+		}
 		if includeCmd {
-			fmt.Printf("  Package: %s (v%s)\n", pkg.Name(), pkg.Version())
+			fmt.Printf("  Package: %s (%s)\n", pkg.Name(), strings.Join(append([]string{"v" + pkg.Version()}, status...), ", "))
 			printCommands(pkg.Commands())
 			fmt.Println()
+		} else if len(status) > 0 {
+			fmt.Printf("  - %-50s %s (%s)\n", pkg.Name(), pkg.Version(), strings.Join(status, ", "))
 		} else {
 			fmt.Printf("  - %-50s %s\n", pkg.Name(), pkg.Version())
 		}
@@ -317,22 +618,22 @@ func printCommands(commands []command.Command) {
 	}
 }
 
-func installGitRepo(gitUrl string) error {
+func installGitRepo(gitUrl string, ref string, subPath string) error {
 	_, err := url.Parse(gitUrl)
 	if err != nil {
 		return fmt.Errorf("invalid url or pathname: %v", err)
 	}
 
-	path := viper.GetString(config.DROPIN_FOLDER_KEY)
-	gitPkg, err := pkg.CreateGitRepoPackage(gitUrl)
+	gitPkg, err := pkg.CreateGitRepoPackage(gitUrl, ref, subPath)
 	if err != nil {
-		os.RemoveAll(path)
 		return fmt.Errorf("failed to install git package %s: %v", gitUrl, err)
 	}
+	defer pkg.RemoveGitClone(gitPkg)
 
-	mf, err := gitPkg.InstallTo(viper.GetString(config.DROPIN_FOLDER_KEY))
+	dropinDir := viper.GetString(config.DROPIN_FOLDER_KEY)
+	mf, err := gitPkg.InstallTo(dropinDir)
 	if err != nil {
-		os.RemoveAll(path)
+		os.RemoveAll(filepath.Join(dropinDir, gitPkg.Name()))
 		return fmt.Errorf("failed to install git package %s: %v", gitUrl, err)
 	}
 
@@ -340,7 +641,58 @@ func installGitRepo(gitUrl string) error {
 	return nil
 }
 
-func installZipFile(fileUrl string) error {
+// updateGitPackage re-installs a dropin package from the git origin recorded
+// during its installation, the installed version is restored on failure
+func updateGitPackage(pkgName string) error {
+	folder, err := findPackageFolder(pkgName)
+	if err != nil {
+		return err
+	}
+
+	origin, err := pkg.ReadGitOrigin(folder)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("package %s was not installed from a git repository, no origin to update from", pkgName)
+	} else if err != nil {
+		return fmt.Errorf("cannot read the origin of the package %s: %v", pkgName, err)
+	}
+
+	gitPkg, err := pkg.CreateGitRepoPackage(origin.Url, origin.Ref, origin.Path)
+	if err != nil {
+		return fmt.Errorf("failed to update git package %s: %v", pkgName, err)
+	}
+	defer pkg.RemoveGitClone(gitPkg)
+	if gitPkg.Name() != pkgName {
+		return fmt.Errorf("failed to update git package %s: the repository now contains the package %s", pkgName, gitPkg.Name())
+	}
+
+	if newOrigin, ok := pkg.GitOriginOf(gitPkg); ok && newOrigin.Commit == origin.Commit {
+		console.Success("Package %s is up-to-date (commit %s)\n", pkgName, origin.Commit)
+		return nil
+	}
+
+	backupDir, err := pkg.BackupDir(folder)
+	if err != nil {
+		return fmt.Errorf("failed to update git package %s: %v", pkgName, err)
+	}
+	defer os.RemoveAll(backupDir)
+
+	if err := os.RemoveAll(folder); err != nil {
+		return fmt.Errorf("failed to update git package %s: %v", pkgName, err)
+	}
+
+	mf, err := gitPkg.InstallTo(filepath.Dir(folder))
+	if err != nil {
+		if restoreErr := pkg.RestoreDir(backupDir, folder); restoreErr != nil {
+			console.Error("Failed to restore the package %s: %v\n", pkgName, restoreErr)
+		}
+		return fmt.Errorf("failed to update git package %s: %v", pkgName, err)
+	}
+
+	console.Success("Package %s updated to version %s in the dropin repository\n", mf.Name(), mf.Version())
+	return nil
+}
+
+func installPackageFile(fileUrl string) error {
 	url, err := url.Parse(fileUrl)
 	if err != nil {
 		return fmt.Errorf("invalid url or pathname: %v", err)
@@ -366,21 +718,122 @@ func installZipFile(fileUrl string) error {
 		pathname = url.Path
 	}
 
-	zipPkg, err := pkg.CreateZipPackage(pathname)
+	archivePkg, err := pkg.CreateArchivePackage(pathname, "")
 	if err != nil {
-		return fmt.Errorf("cannot create the package from the zip file: %v", err)
+		return fmt.Errorf("cannot create the package from the package file: %v", err)
 	}
 
-	targetDir := filepath.Join(viper.GetString(config.DROPIN_FOLDER_KEY), zipPkg.Name())
-	mf, err := zipPkg.InstallTo(targetDir)
+	targetDir := filepath.Join(viper.GetString(config.DROPIN_FOLDER_KEY), archivePkg.Name())
+	mf, err := archivePkg.InstallTo(targetDir)
 	if err != nil {
-		return fmt.Errorf("failed to install zip package %s: %v", fileUrl, err)
+		return fmt.Errorf("failed to install package %s: %v", fileUrl, err)
 	}
 
 	console.Success("Package '%s' version %s installed in the dropin repository", mf.Name(), mf.Version())
 	return nil
 }
 
+// DEFAULT_PIN_DURATION is the default update pause of a package version
+// installed in a managed repository
+const DEFAULT_PIN_DURATION = "30d"
+
+// installRemotePackage installs a package from a remote registry, the package
+// reference is in form of name[@version], the latest version available for the
+// user's partition is installed when no version is specified.
+//
+// A specific version installed in a managed repository is pinned: its updates
+// are paused for the pin duration, otherwise the next sync would revert it.
+func installRemotePackage(appName string, pkgRef string, remoteName string, toDropin bool, pinFor string) error {
+	name, version, _ := strings.Cut(pkgRef, "@")
+	if name == "" {
+		return fmt.Errorf("invalid package name %s", pkgRef)
+	}
+	pinDuration, err := helper.ParseDuration(pinFor)
+	if err != nil || pinDuration <= 0 {
+		return fmt.Errorf("invalid pin duration %s, must be a positive duration like 12h, 7d or 2w", pinFor)
+	}
+
+	source, err := managedPackageSource(remoteName)
+	if err != nil {
+		return err
+	}
+
+	remotePkg, err := source.FetchPackage(&rootCtxt.user, name, version,
+		viper.GetBool(config.VERIFY_PACKAGE_CHECKSUM_KEY),
+		viper.GetBool(config.VERIFY_PACKAGE_SIGNATURE_KEY),
+	)
+	if err != nil {
+		return err
+	}
+
+	if toDropin {
+		targetDir := filepath.Join(viper.GetString(config.DROPIN_FOLDER_KEY), remotePkg.Name())
+		mf, err := remotePkg.InstallTo(targetDir)
+		if err != nil {
+			return fmt.Errorf("failed to install package %s: %v", pkgRef, err)
+		}
+		// the remote is recorded to repair the package later
+		origin := pkg.RemoteOrigin{Remote: source.Name, Version: mf.Version()}
+		if err := origin.WriteToDir(targetDir); err != nil {
+			return fmt.Errorf("cannot record the origin of the package %s: %v", mf.Name(), err)
+		}
+		console.Success("Package '%s' version %s installed in the dropin repository\n", mf.Name(), mf.Version())
+		return nil
+	}
+
+	if _, err := source.Repo.Package(remotePkg.Name()); err == nil {
+		err = source.Repo.Update(remotePkg)
+	} else {
+		err = source.Repo.Install(remotePkg)
+	}
+	if err != nil {
+		return err
+	}
+	console.Success("Package '%s' version %s installed in the managed repository '%s'\n", remotePkg.Name(), remotePkg.Version(), source.Name)
+
+	if version == "" {
+		return nil
+	}
+	reason := fmt.Sprintf("version %s pinned by %s package install", remotePkg.Version(), appName)
+	if err := source.Repo.PausePackageUpdate(remotePkg.Name(), pinDuration, reason); err != nil {
+		return fmt.Errorf("failed to pin package %s to version %s: %v", remotePkg.Name(), remotePkg.Version(), err)
+	}
+	console.Reminder(
+		"Package updates are paused until %s to keep this version, run `%s package resume %s --remote %s` to follow the remote registry again.\n",
+		time.Now().Add(pinDuration).Format(time.RFC3339), appName, remotePkg.Name(), source.Name,
+	)
+	return nil
+}
+
+// installPackageVersion installs a version of a package from a remote side
+// by side with the installed version, the installed version stays the default
+func installPackageVersion(appName string, pkgRef string, remoteName string) error {
+	name, version, _ := strings.Cut(pkgRef, "@")
+	if name == "" || version == "" {
+		return fmt.Errorf("invalid package version %s, must be in form of package@version", pkgRef)
+	}
+
+	source, err := managedPackageSource(remoteName)
+	if err != nil {
+		return err
+	}
+
+	remotePkg, err := source.FetchPackage(&rootCtxt.user, name, version,
+		viper.GetBool(config.VERIFY_PACKAGE_CHECKSUM_KEY),
+		viper.GetBool(config.VERIFY_PACKAGE_SIGNATURE_KEY),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := source.Repo.InstallVersion(remotePkg); err != nil {
+		return err
+	}
+	console.Success("Package '%s' version %s installed side by side in the managed repository '%s'\n", name, version, source.Name)
+	console.Reminder("Run `%s --%s %s@%s <command>` to use this version.\n", appName, PKG_VERSION_FLAG, name, version)
+	return nil
+}
+
 type packageMatch struct {
 	pkg    command.PackageManifest
 	source *backend.PackageSource
@@ -426,15 +879,46 @@ func printPackageDetails(pkg command.PackageManifest, source *backend.PackageSou
 		fmt.Printf("  Remote URL: %s\n", source.RemoteBaseURL)
 		fmt.Printf("  Registry:   %s\n", source.RemoteRegistryURL)
 		fmt.Printf("  Sync:       %s\n", source.SyncPolicy)
+		for _, field := range syncStatus(source.RepoDir, source.SyncPolicy) {
+			fmt.Printf("    %-12s: %s\n", field.label, field.value)
+		}
 	}
 
 	if repoFolder, err := source.Repo.RepositoryFolder(); err == nil {
 		fmt.Printf("  Local Path: %s\n", repoFolder)
 	}
 
+	if target, linked := linkedPackageTarget(pkg); linked {
+		fmt.Printf("  Linked:     %s\n", target)
+	}
+
+	if rootCtxt.backend.IsPackageDisabled(source.Name, pkg.Name()) {
+		fmt.Printf("  Disabled:   true\n")
+	}
+
+	if versions := source.Repo.PackageVersions(pkg.Name()); len(versions) > 0 {
+		fmt.Printf("  Side-by-side Versions: %s\n", strings.Join(versions, ", "))
+	}
+
+	if origin, err := installedRemoteOrigin(pkg); err == nil {
+		fmt.Printf("  Remote:     %s\n", origin.Remote)
+	}
+
+	if origin, err := installedGitOrigin(pkg); err == nil {
+		fmt.Printf("  Git URL:    %s\n", origin.Url)
+		if origin.Ref != "" {
+			fmt.Printf("  Git Ref:    %s\n", origin.Ref)
+		}
+		if origin.Path != "" {
+			fmt.Printf("  Git Path:   %s\n", origin.Path)
+		}
+		fmt.Printf("  Commit:     %s\n", origin.Commit)
+	}
+
 	if source.IsManaged {
 		paused := false
 		pausedUntil := time.Time{}
+		pauseReason := ""
 		if exists, err := updateConfig.IsUpdateConfigExists(source.RepoDir); err != nil {
 			log.Warnf("failed to check update config in %s: %v", source.RepoDir, err)
 		} else if exists {
@@ -442,12 +926,16 @@ func printPackageDetails(pkg command.PackageManifest, source *backend.PackageSou
 				if cfg.IsPackagePaused(pkg.Name()) {
 					paused = true
 					pausedUntil = cfg.PausedUntil[pkg.Name()]
+					pauseReason = cfg.PauseReason(pkg.Name())
 				}
 			}
 		}
 		fmt.Printf("  Paused:     %v\n", paused)
 		if paused {
 			fmt.Printf("  Paused Until: %s\n", pausedUntil.Format(time.RFC3339))
+			if pauseReason != "" {
+				fmt.Printf("  Pause Reason: %s\n", pauseReason)
+			}
 		}
 	}
 
@@ -456,6 +944,288 @@ func printPackageDetails(pkg command.PackageManifest, source *backend.PackageSou
 	printCommands(pkg.Commands())
 }
 
+// verifyPackages verifies the installed files of the managed and dropin
+// packages, all of them are verified when the name is empty
+func verifyPackages(pkgName string, repair bool) error {
+	matches := []packageMatch{}
+	for _, s := range rootCtxt.backend.AllPackageSources() {
+		if s.Repo == nil || s.CustomRepoIndex != nil {
+			continue
+		}
+		for _, p := range s.Repo.InstalledPackages() {
+			if pkgName == "" || p.Name() == pkgName {
+				matches = append(matches, packageMatch{pkg: p, source: s})
+			}
+		}
+	}
+
+	if pkgName != "" && len(matches) == 0 {
+		return fmt.Errorf("no package named %s found", pkgName)
+	}
+
+	failed := 0
+	for _, m := range matches {
+		label := fmt.Sprintf("%s@%s (%s)", m.pkg.Name(), m.pkg.Version(), m.source.Name)
+		if len(m.pkg.Commands()) == 0 {
+			console.Warn("%s: cannot find the package folder, skipped\n", label)
+			continue
+		}
+		pkgDir := m.pkg.Commands()[0].PackageDir()
+
+		if target, linked := pkg.LinkTarget(pkgDir); linked {
+			fmt.Printf("%s: linked to %s, skipped\n", label, target)
+			continue
+		}
+
+		report, err := pkg.VerifyPackageFiles(pkgDir)
+		if os.IsNotExist(err) {
+			console.Warn("%s: no file hashes recorded, re-install the package to verify it\n", label)
+			continue
+		} else if err != nil {
+			console.Error("%s: %v\n", label, err)
+			failed++
+			continue
+		}
+
+		if report.IsIntact() {
+			console.Success("%s: OK\n", label)
+			if len(report.Extra) > 0 {
+				console.Warn("%s: %d extra file(s) added after the installation\n", label, len(report.Extra))
+				printExtraFiles(report)
+			}
+			continue
+		}
+
+		console.Error("%s: %d modified, %d missing, %d extra file(s)\n", label, len(report.Modified), len(report.Missing), len(report.Extra))
+		printIntegrityReport(report)
+		if !repair {
+			failed++
+			continue
+		}
+
+		if err := repairPackage(m.pkg, m.source, pkgDir); err != nil {
+			console.Error("%s: repair failed: %v\n", label, err)
+			failed++
+			continue
+		}
+		console.Success("%s: repaired\n", label)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d package(s) failed the verification", failed)
+	}
+	return nil
+}
+
+func printIntegrityReport(report *pkg.IntegrityReport) {
+	for _, file := range report.Modified {
+		fmt.Printf("    modified: %s\n", file)
+	}
+	for _, file := range report.Missing {
+		fmt.Printf("    missing:  %s\n", file)
+	}
+	printExtraFiles(report)
+}
+
+func printExtraFiles(report *pkg.IntegrityReport) {
+	for _, file := range report.Extra {
+		fmt.Printf("    extra:    %s\n", file)
+	}
+}
+
+// repairPackage re-installs the exact installed version of a package: from
+// the remote of a managed package, or from the remote or the git origin
+// recorded for a dropin package
+func repairPackage(mf command.PackageManifest, source *backend.PackageSource, pkgDir string) error {
+	if source.IsManaged {
+		return repairRemotePackage(mf, source, pkgDir)
+	}
+
+	if remoteOrigin, err := pkg.ReadRemoteOrigin(pkgDir); err == nil {
+		remoteSource, err := managedPackageSource(remoteOrigin.Remote)
+		if err != nil {
+			return err
+		}
+		if err := repairRemotePackage(mf, remoteSource, pkgDir); err != nil {
+			return err
+		}
+		return remoteOrigin.WriteToDir(pkgDir)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("cannot read the origin of the package: %v", err)
+	}
+
+	origin, err := pkg.ReadGitOrigin(pkgDir)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("the package was not installed from a remote or a git repository, re-install it manually")
+	} else if err != nil {
+		return fmt.Errorf("cannot read the origin of the package: %v", err)
+	}
+
+	gitPkg, err := pkg.CreateGitRepoPackage(origin.Url, origin.Commit, origin.Path)
+	if err != nil {
+		return err
+	}
+	defer pkg.RemoveGitClone(gitPkg)
+
+	backupDir, err := pkg.BackupDir(pkgDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(backupDir)
+
+	if err := os.RemoveAll(pkgDir); err != nil {
+		return err
+	}
+	if _, err := gitPkg.InstallTo(filepath.Dir(pkgDir)); err != nil {
+		if restoreErr := pkg.RestoreDir(backupDir, pkgDir); restoreErr != nil {
+			console.Error("Failed to restore the package %s: %v\n", mf.Name(), restoreErr)
+		}
+		return err
+	}
+
+	// keep the recorded ref to follow it on the next update
+	origin.Commit = ""
+	if newOrigin, ok := pkg.GitOriginOf(gitPkg); ok {
+		origin.Commit = newOrigin.Commit
+	}
+	return origin.WriteToDir(pkgDir)
+}
+
+// repairRemotePackage downloads again the installed version of a package from
+// the remote of the source, the package filter of the remote is ignored: it
+// only selects the packages to install
+func repairRemotePackage(mf command.PackageManifest, source *backend.PackageSource, pkgDir string) error {
+	remotePkg, err := source.FetchInstalledPackage(mf.Name(), mf.Version(),
+		viper.GetBool(config.VERIFY_PACKAGE_CHECKSUM_KEY),
+		viper.GetBool(config.VERIFY_PACKAGE_SIGNATURE_KEY),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = remotePkg.InstallTo(pkgDir)
+	return err
+}
+
+// managedPackageSource finds the package source of a remote by its name
+func managedPackageSource(remoteName string) (*backend.PackageSource, error) {
+	for _, s := range rootCtxt.backend.AllPackageSources() {
+		if s.IsManaged && s.Repo != nil && s.Name == remoteName {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no remote named %s found", remoteName)
+}
+
+type pausedPackage struct {
+	name   string
+	until  time.Time
+	reason string
+	source *backend.PackageSource
+}
+
+// pausedPackages returns the paused packages of all managed repositories
+func pausedPackages() []pausedPackage {
+	paused := []pausedPackage{}
+	for _, s := range rootCtxt.backend.AllPackageSources() {
+		if !s.IsManaged {
+			continue
+		}
+		if exists, err := updateConfig.IsUpdateConfigExists(s.RepoDir); err != nil || !exists {
+			continue
+		}
+		cfg, err := updateConfig.ReadFromDir(s.RepoDir)
+		if err != nil {
+			log.Warnf("failed to read update config in %s: %v", s.RepoDir, err)
+			continue
+		}
+		names := []string{}
+		for name := range cfg.PausedUntil {
+			if cfg.IsPackagePaused(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			paused = append(paused, pausedPackage{
+				name:   name,
+				until:  cfg.PausedUntil[name],
+				reason: cfg.PauseReason(name),
+				source: s,
+			})
+		}
+	}
+	return paused
+}
+
+func printPausedPackages() {
+	paused := pausedPackages()
+	if len(paused) == 0 {
+		fmt.Println("No paused package")
+		return
+	}
+	for _, p := range paused {
+		fmt.Printf("  - %-30s %-15s until %s\n", p.name, p.source.Name, p.until.Format(time.RFC3339))
+		if p.reason != "" {
+			fmt.Printf("    %s\n", p.reason)
+		}
+	}
+}
+
+// linkPackage links a package folder into the dropin repository, after
+// checking that no other package has the same name
+func linkPackage(dir string) error {
+	mf, err := pkg.CreateFolderPackage(dir)
+	if err != nil {
+		return fmt.Errorf("cannot read the package manifest in %s: %v", dir, err)
+	}
+
+	dropinRepo := rootCtxt.backend.DropinRepository()
+	if installed, err := dropinRepo.Package(mf.Name()); err == nil {
+		return fmt.Errorf("a package named %s is already installed in the dropin repository (version %s)", mf.Name(), installed.Version())
+	}
+
+	linked, err := pkg.LinkPackage(dir, viper.GetString(config.DROPIN_FOLDER_KEY))
+	if err != nil {
+		return err
+	}
+
+	for _, s := range rootCtxt.backend.AllPackageSources() {
+		if s.Repo == nil || s.Repo == dropinRepo {
+			continue
+		}
+		if _, err := s.Repo.Package(linked.Name()); err == nil {
+			console.Warn("A package named %s is also installed in the %s repository, their commands may conflict\n", linked.Name(), s.Name)
+		}
+	}
+
+	console.Success("Package %s linked into the dropin repository\n", linked.Name())
+	return nil
+}
+
+// linkedPackageTarget returns the folder that an installed package is linked to
+func linkedPackageTarget(mf command.PackageManifest) (string, bool) {
+	if len(mf.Commands()) == 0 {
+		return "", false
+	}
+	return pkg.LinkTarget(mf.Commands()[0].PackageDir())
+}
+
+// installedGitOrigin returns the git origin of an installed package
+func installedGitOrigin(mf command.PackageManifest) (*pkg.GitOrigin, error) {
+	if len(mf.Commands()) == 0 {
+		return nil, fmt.Errorf("cannot find the package folder of %s", mf.Name())
+	}
+	return pkg.ReadGitOrigin(mf.Commands()[0].PackageDir())
+}
+
+// installedRemoteOrigin returns the remote origin of an installed dropin package
+func installedRemoteOrigin(mf command.PackageManifest) (*pkg.RemoteOrigin, error) {
+	if len(mf.Commands()) == 0 {
+		return nil, fmt.Errorf("cannot find the package folder of %s", mf.Name())
+	}
+	return pkg.ReadRemoteOrigin(mf.Commands()[0].PackageDir())
+}
+
 func findPackageFolder(pkgName string) (string, error) {
 	if pkgName == "" {
 		return "", fmt.Errorf("invalid package name")
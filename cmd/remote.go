@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/criteo/command-launcher/internal/backend"
 	"github.com/criteo/command-launcher/internal/config"
 	"github.com/criteo/command-launcher/internal/context"
+	"github.com/criteo/command-launcher/internal/remote"
+	"github.com/criteo/command-launcher/internal/syncPolicy"
+	"github.com/criteo/command-launcher/internal/updater"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -33,7 +37,12 @@ func AddRemoteCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back b
 		RunE: func(cmd *cobra.Command, args []string) error {
 			allRemotes := getAllRemotes()
 			for _, v := range allRemotes {
-				fmt.Printf("%-15s : %s\n", v.Name, v.RemoteBaseUrl)
+				if v.Disabled {
+					fmt.Printf("%-15s : %s (disabled)\n", v.Name, v.RemoteBaseUrl)
+				} else {
+					fmt.Printf("%-15s : %s\n", v.Name, v.RemoteBaseUrl)
+				}
+				printRemoteSyncStatus(v)
 			}
 			return nil
 		},
@@ -55,7 +64,7 @@ func AddRemoteCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back b
 				return fmt.Errorf("can't delete the default remote repository")
 			}
 			config.RemoveRemote(args[0])
-			if err := viper.WriteConfig(); err != nil {
+			if err := config.WriteConfig(); err != nil {
 				log.Error("cannot write the default configuration: ", err)
 				return err
 			}
@@ -77,6 +86,7 @@ func AddRemoteCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back b
 	}
 
 	var addSyncPolicy string
+	var addInclude, addExclude []string
 	remoteAddCmd := &cobra.Command{
 		Use:   "add [remote name] [remote base url]",
 		Short: "Add command launcher remote",
@@ -89,18 +99,28 @@ func AddRemoteCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back b
 			if args[0] == "default" {
 				return fmt.Errorf("can't add remote named 'default', it is a reserved remote name")
 			}
-			if addSyncPolicy != "" && !config.IsValidSyncPolicy(addSyncPolicy) {
-				return fmt.Errorf("invalid sync policy: %s, must be one of: %s", addSyncPolicy, strings.Join(config.ValidSyncPolicies(), ", "))
+			if addSyncPolicy != "" {
+				if _, err := syncPolicy.Parse(addSyncPolicy); err != nil {
+					return err
+				}
 			}
-			syncPolicy := addSyncPolicy
-			if syncPolicy == "" {
-				syncPolicy = "always"
+			if _, err := remote.NewPackageFilter(addInclude, addExclude); err != nil {
+				return err
+			}
+			policy := addSyncPolicy
+			if policy == "" {
+				policy = syncPolicy.ALWAYS
 			}
-			repoDir := filepath.Join(config.AppDir(), args[0])
-			if err := config.AddRemote(args[0], repoDir, args[1], syncPolicy); err != nil {
+			repoDir := filepath.Join(config.ProfileHome(), args[0])
+			if err := config.AddRemote(args[0], repoDir, args[1], policy); err != nil {
 				return err
 			}
-			if err := viper.WriteConfig(); err != nil {
+			if len(addInclude) > 0 || len(addExclude) > 0 {
+				if err := config.SetRemotePackageFilter(args[0], addInclude, addExclude); err != nil {
+					return err
+				}
+			}
+			if err := config.WriteConfig(); err != nil {
 				log.Error("cannot write the default configuration: ", err)
 				return err
 			}
@@ -110,12 +130,15 @@ func AddRemoteCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back b
 			return []string{}, cobra.ShellCompDirectiveNoFileComp
 		},
 	}
-	remoteAddCmd.Flags().StringVar(&addSyncPolicy, "sync-policy", "", "sync policy for the remote (never, always, hourly, daily, weekly, monthly)")
+	remoteAddCmd.Flags().StringVar(&addSyncPolicy, "sync-policy", "", syncPolicyFlagUsage)
+	remoteAddCmd.Flags().StringSliceVar(&addInclude, "include", []string{}, includeFlagUsage)
+	remoteAddCmd.Flags().StringSliceVar(&addExclude, "exclude", []string{}, excludeFlagUsage)
 	remoteAddCmd.RegisterFlagCompletionFunc("sync-policy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return config.ValidSyncPolicies(), cobra.ShellCompDirectiveNoFileComp
 	})
 
 	var setSyncPolicy string
+	var setInclude, setExclude []string
 	remoteSetCmd := &cobra.Command{
 		Use:   "set [remote name]",
 		Short: "Update settings for an existing remote",
@@ -128,20 +151,49 @@ func AddRemoteCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back b
 			if args[0] == "default" {
 				return fmt.Errorf("can't modify the default remote")
 			}
-			if setSyncPolicy == "" {
-				return fmt.Errorf("no settings to update, use --sync-policy to set the sync policy")
+			filterChanged := cmd.Flags().Changed("include") || cmd.Flags().Changed("exclude")
+			if setSyncPolicy == "" && !filterChanged {
+				return fmt.Errorf("no settings to update, use --sync-policy to set the sync policy, or --include and --exclude to set the package filter")
 			}
-			if !config.IsValidSyncPolicy(setSyncPolicy) {
-				return fmt.Errorf("invalid sync policy: %s, must be one of: %s", setSyncPolicy, strings.Join(config.ValidSyncPolicies(), ", "))
+			if setSyncPolicy != "" {
+				if _, err := syncPolicy.Parse(setSyncPolicy); err != nil {
+					return err
+				}
+				if err := config.UpdateRemote(args[0], setSyncPolicy); err != nil {
+					return err
+				}
 			}
-			if err := config.UpdateRemote(args[0], setSyncPolicy); err != nil {
-				return err
+			if filterChanged {
+				// keep the patterns that are not specified
+				include, exclude := setInclude, setExclude
+				remotes, _ := config.Remotes()
+				for _, r := range remotes {
+					if r.Name == args[0] {
+						if !cmd.Flags().Changed("include") {
+							include = r.Include
+						}
+						if !cmd.Flags().Changed("exclude") {
+							exclude = r.Exclude
+						}
+					}
+				}
+				if _, err := remote.NewPackageFilter(include, exclude); err != nil {
+					return err
+				}
+				if err := config.SetRemotePackageFilter(args[0], include, exclude); err != nil {
+					return err
+				}
 			}
-			if err := viper.WriteConfig(); err != nil {
+			if err := config.WriteConfig(); err != nil {
 				log.Error("cannot write the default configuration: ", err)
 				return err
 			}
-			fmt.Printf("Remote '%s' sync policy updated to '%s'\n", args[0], setSyncPolicy)
+			if setSyncPolicy != "" {
+				fmt.Printf("Remote '%s' sync policy updated to '%s'\n", args[0], setSyncPolicy)
+			}
+			if filterChanged {
+				fmt.Printf("Remote '%s' package filter updated, run the update command to apply it\n", args[0])
+			}
 			return nil
 		},
 		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -158,18 +210,168 @@ func AddRemoteCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back b
 			return remoteNames, cobra.ShellCompDirectiveNoFileComp
 		},
 	}
-	remoteSetCmd.Flags().StringVar(&setSyncPolicy, "sync-policy", "", "sync policy for the remote (never, always, hourly, daily, weekly, monthly)")
+	remoteSetCmd.Flags().StringVar(&setSyncPolicy, "sync-policy", "", syncPolicyFlagUsage)
+	remoteSetCmd.Flags().StringSliceVar(&setInclude, "include", []string{}, includeFlagUsage+", an empty value includes all packages")
+	remoteSetCmd.Flags().StringSliceVar(&setExclude, "exclude", []string{}, excludeFlagUsage+", an empty value excludes none")
 	remoteSetCmd.RegisterFlagCompletionFunc("sync-policy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return config.ValidSyncPolicies(), cobra.ShellCompDirectiveNoFileComp
 	})
 
+	remoteDisableCmd := &cobra.Command{
+		Use:   "disable [remote name]",
+		Short: "Disable a command launcher remote",
+		Long:  "Disable a command launcher remote: its local repository is kept, but its packages are not loaded, and it is not synchronized",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setRemoteDisabled(args[0], true)
+		},
+		ValidArgsFunction: remoteNameFilterCompletion(false),
+	}
+
+	remoteEnableCmd := &cobra.Command{
+		Use:   "enable [remote name]",
+		Short: "Enable a disabled command launcher remote",
+		Long:  "Enable a disabled command launcher remote",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setRemoteDisabled(args[0], false)
+		},
+		ValidArgsFunction: remoteNameFilterCompletion(true),
+	}
+
 	remoteCmd.AddCommand(remoteAddCmd)
 	remoteCmd.AddCommand(remoteListCmd)
 	remoteCmd.AddCommand(remoteDeleteCmd)
 	remoteCmd.AddCommand(remoteSetCmd)
+	remoteCmd.AddCommand(remoteDisableCmd)
+	remoteCmd.AddCommand(remoteEnableCmd)
 	rootCmd.AddCommand(remoteCmd)
 }
 
+const includeFlagUsage = "glob patterns of the package names to install from the remote, ex: infra-*"
+const excludeFlagUsage = "glob patterns of the package names not to install from the remote, ex: *-experimental"
+
+const syncPolicyFlagUsage = "sync policy for the remote (never, always, hourly, daily, weekly, monthly, a duration like 6h or 2d, or a cron expression like \"0 9 * * MON\")"
+
+type syncStatusField struct {
+	label string
+	value string
+}
+
+// syncStatus describes the synchronization of a managed repository, it is
+// shared by the remote list and package inspect commands
+func syncStatus(repoDir string, policy string) []syncStatusField {
+	fields := []syncStatusField{}
+
+	state, err := updater.ReadSyncState(repoDir)
+	if err != nil {
+		return append(fields, syncStatusField{"sync error", err.Error()})
+	}
+	last, next, err := updater.SyncSchedule(repoDir, policy)
+	if err != nil {
+		return append(fields, syncStatusField{"sync error", err.Error()})
+	}
+
+	fields = append(fields,
+		syncStatusField{"last attempt", formatSyncTime(state.LastAttempt)},
+		syncStatusField{"last sync", formatSyncTime(last)},
+	)
+
+	nextSync := "on next run"
+	if strings.EqualFold(policy, syncPolicy.NEVER) {
+		nextSync = "never"
+	} else if time.Now().Before(next) {
+		nextSync = next.Format(time.RFC3339)
+	}
+	fields = append(fields, syncStatusField{"next sync", nextSync})
+
+	if state.ConsecutiveFailures > 0 {
+		fields = append(fields,
+			syncStatusField{"last error", state.LastError},
+			syncStatusField{"failures", fmt.Sprintf("%d consecutive", state.ConsecutiveFailures)},
+		)
+	}
+	return fields
+}
+
+func formatSyncTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func printRemoteSyncStatus(remote config.ExtraRemote) {
+	fmt.Printf("  %-13s : %s\n", "sync policy", remote.SyncPolicy)
+	if len(remote.Include) > 0 {
+		fmt.Printf("  %-13s : %s\n", "include", strings.Join(remote.Include, ", "))
+	}
+	if len(remote.Exclude) > 0 {
+		fmt.Printf("  %-13s : %s\n", "exclude", strings.Join(remote.Exclude, ", "))
+	}
+	if remote.Disabled {
+		// a disabled remote is not synchronized
+		return
+	}
+	for _, field := range syncStatus(remote.RepositoryDir, remote.SyncPolicy) {
+		fmt.Printf("  %-13s : %s\n", field.label, field.value)
+	}
+}
+
+func setRemoteDisabled(name string, disabled bool) error {
+	if name == "default" {
+		return fmt.Errorf("can't modify the default remote")
+	}
+	if err := config.SetRemoteDisabled(name, disabled); err != nil {
+		return err
+	}
+	if err := config.WriteConfig(); err != nil {
+		log.Error("cannot write the default configuration: ", err)
+		return err
+	}
+	if disabled {
+		fmt.Printf("Remote '%s' disabled, its local repository is kept\n", name)
+	} else {
+		fmt.Printf("Remote '%s' enabled\n", name)
+	}
+	return nil
+}
+
+// remoteNameFilterCompletion completes the extra remotes that are disabled, or enabled
+func remoteNameFilterCompletion(disabled bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 1 {
+			return []string{}, cobra.ShellCompDirectiveNoFileComp
+		}
+		remotes, _ := config.Remotes()
+		remoteNames := []string{}
+		for _, remote := range remotes {
+			if remote.Disabled == disabled {
+				remoteNames = append(remoteNames, fmt.Sprintf("%s\t%s", remote.Name, remote.RemoteBaseUrl))
+			}
+		}
+		return remoteNames, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// remotePackageFilter returns the package filter of a remote, an invalid
+// filter is ignored, so that the installed packages are never removed by mistake
+func remotePackageFilter(name string, include []string, exclude []string) remote.PackageFilter {
+	filter, err := remote.NewPackageFilter(include, exclude)
+	if err != nil {
+		log.Warnf("Ignore the package filter of the remote %s: %v", name, err)
+		return remote.PackageFilter{}
+	}
+	return filter
+}
+
+func defaultRemotePackageFilter() remote.PackageFilter {
+	return remotePackageFilter("default",
+		config.StringList(config.COMMAND_REPOSITORY_INCLUDE_KEY),
+		config.StringList(config.COMMAND_REPOSITORY_EXCLUDE_KEY),
+	)
+}
+
 func getAllRemotes() []config.ExtraRemote {
 	allRemoteNames := []config.ExtraRemote{
 		{
@@ -177,6 +379,8 @@ func getAllRemotes() []config.ExtraRemote {
 			RemoteBaseUrl: viper.GetString(config.COMMAND_REPOSITORY_BASE_URL_KEY),
 			RepositoryDir: viper.GetString(config.LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY),
 			SyncPolicy:    backend.SYNC_POLICY_ALWAYS,
+			Include:       config.StringList(config.COMMAND_REPOSITORY_INCLUDE_KEY),
+			Exclude:       config.StringList(config.COMMAND_REPOSITORY_EXCLUDE_KEY),
 		},
 	}
 	remotes, _ := config.Remotes()
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/criteo/command-launcher/cmd/consent"
@@ -18,6 +21,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -48,9 +52,127 @@ var (
 	rootCtxt = rootContext{}
 )
 
+// PKG_VERSION_FLAG selects the version of a package to run, ex: --pkg-version mypkg@1.2.0
+const PKG_VERSION_FLAG = "pkg-version"
+
+var pinnedPackageVersions = []string{}
+
+// the configurations overridden from the command line, ex: --set-config log_level=debug
+var configFlagValues = []string{}
+
+// the configuration profile selected from the command line, ex: --profile sandbox
+var configProfile = ""
+
 func InitCommands(appName string, appLongName string, version string, buildNum string) {
 	rootCmd = createRootCmd(appName, appLongName)
+
+	rootCmd.PersistentFlags().StringArray(PKG_VERSION_FLAG, []string{},
+		"Run a specific installed version of a package instead of the default one, ex: --pkg-version mypkg@1.2.0")
+	rootCmd.PersistentFlags().String(config.PROFILE_FLAG, "",
+		"Use a configuration profile for this run, ex: --profile sandbox")
+	rootCmd.RegisterFlagCompletionFunc(config.PROFILE_FLAG, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return append([]string{config.DEFAULT_PROFILE}, config.Profiles()...), cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.PersistentFlags().StringArray(config.SET_CONFIG_FLAG, []string{},
+		"Override a configuration for this run, ex: --set-config log_level=debug")
+	rootCmd.PersistentFlags().String(FROM_FLAG, "",
+		"Run a command of a specific package whatever its runtime name, ex: --from dropin:my-package")
+	rootCmd.RegisterFlagCompletionFunc(FROM_FLAG, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeNamespace(rootCtxt.backend, toComplete, true), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	// the package versions must be known before creating the package commands,
+	// and the configurations before loading the packages
+	leading, args, err := leadingFlagArgs(os.Args[1:], rootCmd.PersistentFlags())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	pinnedPackageVersions = leading.PackageVersions
+	configFlagValues = leading.ConfigValues
+	configProfile = leading.Profile
+
 	initApp(appName, version, buildNum)
+
+	// the namespaced commands are resolved once the package commands are loaded
+	args, err = namespacedArgs(args, rootCtxt.backend)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	rootCmd.SetArgs(args)
+}
+
+// leadingFlags are the flags placed before the command to run, they are
+// extracted from the command line before loading the packages
+type leadingFlags struct {
+	PackageVersions []string
+	ConfigValues    []string
+	Profile         string
+}
+
+// leadingFlagArgs extracts the --pkg-version, --set-config, and --profile
+// flags placed before the command to run in one pass, and returns them with
+// the remaining args. The flags are the root flags, to know the other flags
+// taking a value, which are kept with their value.
+func leadingFlagArgs(args []string, flags *pflag.FlagSet) (leadingFlags, []string, error) {
+	leading := leadingFlags{PackageVersions: []string{}, ConfigValues: []string{}}
+	remaining := []string{}
+
+	i := 0
+	// the completion command receives the command line to complete
+	if isCompletionRequest(args) {
+		remaining = append(remaining, args[0])
+		i = 1
+	}
+
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			break
+		}
+
+		var flag *pflag.Flag
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if strings.HasPrefix(arg, "--") {
+			flag = flags.Lookup(name)
+		} else if len(arg) == 2 {
+			flag = flags.ShorthandLookup(arg[1:])
+		}
+		takesValue := flag != nil && flag.NoOptDefVal == ""
+
+		switch {
+		case flag == nil || (flag.Name != PKG_VERSION_FLAG && flag.Name != config.SET_CONFIG_FLAG && flag.Name != config.PROFILE_FLAG):
+			remaining = append(remaining, arg)
+			if takesValue && !hasValue && i+1 < len(args) {
+				remaining = append(remaining, args[i+1])
+				i++
+			}
+			continue
+		case !hasValue:
+			if i+1 >= len(args) {
+				return leading, nil, fmt.Errorf("flag needs an argument: --%s", flag.Name)
+			}
+			value = args[i+1]
+			i++
+		}
+
+		switch flag.Name {
+		case PKG_VERSION_FLAG:
+			name, version, ok := strings.Cut(value, "@")
+			if !ok || name == "" || version == "" {
+				return leading, nil, fmt.Errorf("invalid package version %s, must be in form of package@version", value)
+			}
+			leading.PackageVersions = append(leading.PackageVersions, value)
+		case config.SET_CONFIG_FLAG:
+			leading.ConfigValues = append(leading.ConfigValues, value)
+		case config.PROFILE_FLAG:
+			leading.Profile = value
+		}
+	}
+
+	remaining = append(remaining, args[i:]...)
+	return leading, remaining, nil
 }
 
 func createRootCmd(appName string, appLongName string) *cobra.Command {
@@ -65,12 +187,24 @@ Happy Coding!
 Example:
   %s --help
 `, appLongName, appName),
-		PersistentPreRun: preRun,
+		PersistentPreRunE: preRun,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				cmd.Help()
 			}
 		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			if from, _ := cmd.Flags().GetString(FROM_FLAG); from != "" {
+				return completeNamespacedCommand(rootCtxt.backend, from, toComplete), cobra.ShellCompDirectiveNoFileComp
+			}
+			if strings.HasPrefix(toComplete, "@") {
+				return completeNamespace(rootCtxt.backend, toComplete, false), cobra.ShellCompDirectiveNoFileComp
+			}
+			return []string{}, cobra.ShellCompDirectiveNoFileComp
+		},
 		PersistentPostRun: postRun,
 		SilenceUsage:      true,
 	}
@@ -79,7 +213,11 @@ Example:
 func initApp(appName string, appVersion string, buildNum string) {
 	log.SetLevel(log.FatalLevel)
 	rootCtxt.appCtx = ctx.InitContext(appName, appVersion, buildNum)
-	config.LoadConfig(rootCtxt.appCtx)
+	config.LoadConfig(rootCtxt.appCtx, configProfile)
+	if err := config.SetFlagValues(configFlagValues); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	config.InitLog(rootCtxt.appCtx.AppName())
 
 	rootCtxt.cmdUpdaters = make([]*updater.CmdUpdater, 0)
@@ -99,7 +237,20 @@ func Execute() {
 	os.Exit(frontend.RootExitCode)
 }
 
-func preRun(cmd *cobra.Command, args []string) {
+func preRun(cmd *cobra.Command, args []string) error {
+	// the package versions and the configurations are selected before loading
+	// the packages, the flags are extracted from the command line only before the command
+	for _, flag := range [][2]string{{PKG_VERSION_FLAG, "mypkg@1.2.0"}, {config.SET_CONFIG_FLAG, "log_level=debug"}, {config.PROFILE_FLAG, "sandbox"}} {
+		if cmd.Flags().Changed(flag[0]) {
+			return fmt.Errorf("the --%s flag must be placed before the command to run, ex: %s --%s %s %s",
+				flag[0], rootCtxt.appCtx.AppName(), flag[0], flag[1], strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name())))
+		}
+	}
+
+	if !strings.HasPrefix(cmd.Name(), cobra.ShellCompRequestCmd) {
+		warnConflicts(os.Stderr)
+	}
+
 	if selfUpdateEnabled(cmd, args) {
 		initSelfUpdater()
 		rootCtxt.selfUpdater.CheckUpdateAsync()
@@ -119,6 +270,7 @@ func preRun(cmd *cobra.Command, args []string) {
 	rootCtxt.metrics = metrics.NewCompositeMetricsCollector(graphite, extensible)
 	repo, pkg, group, name := cmdAndSubCmd(cmd)
 	rootCtxt.metrics.Collect(rootCtxt.user.Partition, repo, pkg, group, name)
+	return nil
 }
 
 func postRun(cmd *cobra.Command, args []string) {
@@ -166,6 +318,76 @@ func metricsEnabled(cmd *cobra.Command, args []string) bool {
 }
 
 
+// EXTERNAL_SOURCES_CACHE_DIR stores the cached output of the external package
+// source providers, in the app home
+const EXTERNAL_SOURCES_CACHE_DIR = "external-sources"
+
+// isCompletionRequest checks if the command line is a shell completion request
+func isCompletionRequest(args []string) bool {
+	return len(args) > 0 && (args[0] == cobra.ShellCompRequestCmd || args[0] == cobra.ShellCompNoDescRequestCmd)
+}
+
+// externalProviderCache returns the cache of a provider output, it depends on
+// the provider and on the working directory where the provider is called.
+// The providers are never called during the shell completion.
+func externalProviderCache(name string, provider string, wd string) *repository.ExternalProviderCache {
+	ttl := viper.GetDuration(config.PACKAGE_SOURCE_PROVIDER_CACHE_KEY)
+	completing := isCompletionRequest(os.Args[1:])
+	if ttl <= 0 && !completing {
+		return nil
+	}
+	key := sha256.Sum256([]byte(provider + "\x00" + wd))
+	return &repository.ExternalProviderCache{
+		File:     filepath.Join(config.AppDir(), EXTERNAL_SOURCES_CACHE_DIR, fmt.Sprintf("%s-%x.json", name, key[:8])),
+		TTL:      ttl,
+		ReadOnly: completing,
+	}
+}
+
+// externalSources creates the package sources of the external providers,
+// they are called in the working directory, and ordered by name
+func externalSources(remotes []config.ExtraRemote) []*backend.PackageSource {
+	providers := viper.GetStringMapString(config.PACKAGE_SOURCE_PROVIDERS_KEY)
+	if len(providers) == 0 {
+		return []*backend.PackageSource{}
+	}
+
+	reserved := map[string]bool{"default": true, "dropin": true}
+	for _, remote := range remotes {
+		reserved[remote.Name] = true
+	}
+	names := []string{}
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Warnf("external package sources: failed to get working directory: %v", err)
+		return []*backend.PackageSource{}
+	}
+
+	sources := []*backend.PackageSource{}
+	for _, name := range names {
+		if reserved[name] || strings.ContainsAny(name, ":/@") {
+			log.Warnf("external package source %s: invalid name, it must be unique and must not contain ':', '/' or '@'", name)
+			continue
+		}
+		src, err := backend.NewExternalSource(name, wd,
+			strings.Fields(providers[name]),
+			viper.GetDuration(config.PACKAGE_SOURCE_PROVIDER_TIMEOUT_KEY),
+			externalProviderCache(name, providers[name], wd),
+		)
+		if err != nil {
+			log.Warnf("external package source %s: %v", name, err)
+			continue
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
 func initUser() {
 	var err error = nil
 	rootCtxt.user, err = user.GetUser()
@@ -206,42 +428,75 @@ func initBackend() {
 	remotes, _ := config.Remotes()
 	extraSources := []*backend.PackageSource{}
 	for _, remote := range remotes {
-		extraSources = append(extraSources, backend.NewManagedSource(
+		if remote.Disabled {
+			continue
+		}
+		source := backend.NewManagedSource(
 			remote.Name,
 			remote.RepositoryDir,
 			remote.RemoteBaseUrl,
 			remote.SyncPolicy,
-		))
+		)
+		source.PackageFilter = remotePackageFilter(remote.Name, remote.Include, remote.Exclude)
+		extraSources = append(extraSources, source)
 	}
 
+	extraSources = append(extraSources, externalSources(remotes)...)
+
+	defaultSource := backend.NewManagedSource(
+		"default",
+		viper.GetString(config.LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY),
+		viper.GetString(config.COMMAND_REPOSITORY_BASE_URL_KEY),
+		backend.SYNC_POLICY_ALWAYS,
+	)
+	defaultSource.PackageFilter = defaultRemotePackageFilter()
+
 	// Discover workspace packages if enabled.
 	// Sources with prior denial are excluded. Sources without any consent
 	// record are loaded so their commands appear in autocompletion; consent
-	// is checked at execution time.
+	// is checked at execution time. The registry packages are only downloaded
+	// for a trusted workspace, and never during the completion; otherwise the
+	// cached versions are used.
 	workspaceSources := []*backend.PackageSource{}
 	if viper.GetBool(config.ENABLE_WORKSPACE_PACKAGES_KEY) {
 		wd, err := os.Getwd()
 		if err != nil {
 			log.Warnf("workspace packages: failed to get working directory: %v", err)
 		}
-		for _, src := range backend.DiscoverWorkspaceSources(wd, rootCtxt.appCtx.AppName()) {
-			if !consent.IsWorkspaceConsentDenied(src.RepoDir) {
-				workspaceSources = append(workspaceSources, src)
+		remoteSources := map[string]*backend.PackageSource{defaultSource.Name: defaultSource}
+		for _, src := range extraSources {
+			if src.IsManaged {
+				remoteSources[src.Name] = src
+			}
+		}
+		for _, ws := range backend.DiscoverWorkspaces(wd, rootCtxt.appCtx.AppName()) {
+			trust := consent.GetWorkspaceTrust(ws.Dir, nil)
+			if trust.State == consent.WORKSPACE_DENIED {
+				continue
 			}
+			ws.InstallRegistryPackages(config.ProfileHome(), remoteSources, &rootCtxt.user,
+				viper.GetBool(config.VERIFY_PACKAGE_CHECKSUM_KEY),
+				viper.GetBool(config.VERIFY_PACKAGE_SIGNATURE_KEY),
+				trust.State == consent.WORKSPACE_TRUSTED && !isCompletionRequest(os.Args[1:]),
+			)
+			if len(ws.PackagePaths) == 0 {
+				continue
+			}
+			src, err := backend.NewWorkspaceSource(ws.Dir, ws.PackagePaths)
+			if err != nil {
+				log.Warnf("workspace: failed to create source from %s: %v", ws.Dir, err)
+				continue
+			}
+			workspaceSources = append(workspaceSources, src)
 		}
 	}
 
 	var err error
 	rootCtxt.backend, err = backend.NewDefaultBackend(
-		config.AppDir(),
+		config.ProfileHome(),
 		workspaceSources,
 		backend.NewDropinSource(viper.GetString(config.DROPIN_FOLDER_KEY)),
-		backend.NewManagedSource(
-			"default",
-			viper.GetString(config.LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY),
-			viper.GetString(config.COMMAND_REPOSITORY_BASE_URL_KEY),
-			backend.SYNC_POLICY_ALWAYS,
-		),
+		defaultSource,
 		extraSources...,
 	)
 	if err != nil {
@@ -271,6 +526,17 @@ func initBackend() {
 		}
 		rootCtxt.backend.Reload()
 	}
+
+	if err := initConflictRules(); err != nil {
+		log.Warnf("Failed to apply the source priority: %v", err)
+	}
+
+	for _, pinned := range pinnedPackageVersions {
+		name, version, _ := strings.Cut(pinned, "@")
+		if err := rootCtxt.backend.UsePackageVersion(name, version); err != nil {
+			log.Fatalf("Failed to use the package %s: %v\n", pinned, err)
+		}
+	}
 }
 
 func initFrontend() {
@@ -322,4 +588,7 @@ func addBuiltinCommands() {
 	AddPackageCmd(rootCmd, rootCtxt.appCtx)
 	AddRenameCmd(rootCmd, rootCtxt.appCtx, rootCtxt.backend)
 	AddRemoteCmd(rootCmd, rootCtxt.appCtx, rootCtxt.backend)
+	AddWhichCmd(rootCmd, rootCtxt.appCtx, rootCtxt.backend)
+	AddWorkspaceCmd(rootCmd, rootCtxt.appCtx, rootCtxt.backend)
+	AddConsentCmd(rootCmd, rootCtxt.appCtx, rootCtxt.backend)
 }
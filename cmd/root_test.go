@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// createLeadingFlagsTestCmd creates a root command with the root flags
+func createLeadingFlagsTestCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.PersistentFlags().StringArray(PKG_VERSION_FLAG, []string{}, "")
+	cmd.PersistentFlags().String(config.PROFILE_FLAG, "", "")
+	cmd.PersistentFlags().StringArray(config.SET_CONFIG_FLAG, []string{}, "")
+	cmd.PersistentFlags().String(FROM_FLAG, "", "")
+	cmd.PersistentFlags().BoolP("verbose", "v", false, "")
+	return cmd
+}
+
+func TestPackageVersionArgs(t *testing.T) {
+	flags := createLeadingFlagsTestCmd().PersistentFlags()
+	leading, args, err := leadingFlagArgs([]string{"--pkg-version", "mypkg@1.2.0", "--pkg-version=other@2.0.0", "infra", "deploy", "--pkg-version", "arg"}, flags)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mypkg@1.2.0", "other@2.0.0"}, leading.PackageVersions)
+	// the flags of the command are kept
+	assert.Equal(t, []string{"infra", "deploy", "--pkg-version", "arg"}, args)
+
+	leading, args, err = leadingFlagArgs([]string{"__complete", "--pkg-version", "mypkg@1.2.0", "infra", ""}, flags)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mypkg@1.2.0"}, leading.PackageVersions)
+	assert.Equal(t, []string{"__complete", "infra", ""}, args)
+
+	leading, args, err = leadingFlagArgs([]string{"--help", "package", "list"}, flags)
+	assert.Nil(t, err)
+	assert.Empty(t, leading.PackageVersions)
+	assert.Equal(t, []string{"--help", "package", "list"}, args)
+
+	for _, invalid := range [][]string{{"--pkg-version"}, {"--pkg-version", "mypkg"}, {"--pkg-version=@1.0.0"}, {"--pkg-version=mypkg@"}} {
+		_, _, err = leadingFlagArgs(invalid, flags)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestLeadingFlagArgs(t *testing.T) {
+	flags := createLeadingFlagsTestCmd().PersistentFlags()
+	leading, args, err := leadingFlagArgs([]string{"--set-config", "log_level=debug", "--pkg-version=mypkg@1.0.0", "--set-config=ci_enabled=true", "infra", "--set-config", "arg"}, flags)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"log_level=debug", "ci_enabled=true"}, leading.ConfigValues)
+	assert.Equal(t, []string{"mypkg@1.0.0"}, leading.PackageVersions)
+	assert.Equal(t, []string{"infra", "--set-config", "arg"}, args)
+
+	_, _, err = leadingFlagArgs([]string{"--set-config"}, flags)
+	assert.NotNil(t, err)
+	_, _, err = leadingFlagArgs([]string{"--profile"}, flags)
+	assert.NotNil(t, err)
+}
+
+func TestLeadingFlagArgsMixedOrder(t *testing.T) {
+	flags := createLeadingFlagsTestCmd().PersistentFlags()
+
+	// the flags are extracted whatever their order, and after the other flags with a value
+	for _, cmdLine := range [][]string{
+		{"--profile", "sandbox", "--pkg-version", "mypkg@1.0.0", "--set-config", "log_level=debug", "infra", "deploy"},
+		{"--set-config", "log_level=debug", "--profile", "sandbox", "--pkg-version", "mypkg@1.0.0", "infra", "deploy"},
+		{"--pkg-version", "mypkg@1.0.0", "--set-config=log_level=debug", "--profile=sandbox", "infra", "deploy"},
+		{"-v", "--from", "dropin:mypkg", "--profile", "sandbox", "--set-config", "log_level=debug", "--pkg-version", "mypkg@1.0.0", "infra", "deploy"},
+	} {
+		leading, args, err := leadingFlagArgs(cmdLine, flags)
+		assert.Nil(t, err, cmdLine)
+		assert.Equal(t, leadingFlags{PackageVersions: []string{"mypkg@1.0.0"}, ConfigValues: []string{"log_level=debug"}, Profile: "sandbox"}, leading, cmdLine)
+		if cmdLine[0] == "-v" {
+			assert.Equal(t, []string{"-v", "--from", "dropin:mypkg", "infra", "deploy"}, args)
+		} else {
+			assert.Equal(t, []string{"infra", "deploy"}, args)
+		}
+	}
+
+	// the last profile is used
+	leading, args, err := leadingFlagArgs([]string{"__complete", "--profile", "a", "--from=dropin:mypkg", "--profile", "b", "in"}, flags)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", leading.Profile)
+	assert.Equal(t, []string{"__complete", "--from=dropin:mypkg", "in"}, args)
+}
@@ -79,6 +79,7 @@ Check the update of %s and its commands.
 					PackageLockFile:      packageLockFile,
 					SyncPolicy:           "always", // TODO: use constant instead of string
 					IgnoreUpdatePause:    true,     // Disable pause to force update during 'update' command
+					PackageFilter:        defaultRemotePackageFilter(),
 				}
 				cmdUpdater.CheckUpdateAsync()
 				err := cmdUpdater.Update()
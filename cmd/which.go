@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/console"
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/spf13/cobra"
+)
+
+type WhichFlags struct {
+	json bool
+}
+
+var (
+	whichFlags = WhichFlags{}
+)
+
+// the resolution report, its JSON format is part of the public interface
+type commandResolutionReport struct {
+	Command    string              `json:"command"`
+	FullName   string              `json:"fullName"`
+	Type       string              `json:"type"`
+	Repository string              `json:"repository"`
+	Package    string              `json:"package"`
+	Version    string              `json:"version"`
+	PackageDir string              `json:"packageDir"`
+	Executable string              `json:"executable"`
+	Arguments  []string            `json:"arguments"`
+	Alias      *aliasReport        `json:"alias,omitempty"`
+	Conflict   *conflictReport     `json:"conflict,omitempty"`
+	Shadowed   []shadowedCmdReport `json:"shadowed"`
+}
+
+type aliasReport struct {
+	Group string `json:"group,omitempty"`
+	Name  string `json:"name,omitempty"`
+}
+
+type conflictReport struct {
+	Name      string `json:"name"`
+	RenamedTo string `json:"renamedTo"`
+	Winner    string `json:"winner"`
+}
+
+type shadowedCmdReport struct {
+	Command    string `json:"command"`
+	FullName   string `json:"fullName"`
+	Repository string `json:"repository"`
+	Package    string `json:"package"`
+	PackageDir string `json:"packageDir"`
+}
+
+func AddWhichCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back backend.Backend) {
+	whichCmd := &cobra.Command{
+		Use:   "which [group] [name]",
+		Short: "Show how a command is resolved",
+		Long: fmt.Sprintf(`
+Show how a command is resolved: its repository, package, version, package
+directory, the executable launched, the aliases and the conflict renames
+applied, and the other commands with the same name that are shadowed.
+
+For a root level command or a group:
+%s which [name]
+
+For a command in a group:
+%s which [group] [name]`,
+			appCtx.AppName(), appCtx.AppName()),
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			group, name := "", args[0]
+			if len(args) == 2 {
+				group, name = args[0], args[1]
+			}
+
+			resolution, err := back.ResolveCommand(group, name)
+			if err != nil {
+				return err
+			}
+
+			report := newResolutionReport(resolution)
+			if whichFlags.json {
+				payload, err := json.MarshalIndent(report, "", "    ")
+				if err != nil {
+					return fmt.Errorf("cannot encode the resolution report: %v", err)
+				}
+				fmt.Println(string(payload))
+			} else {
+				printResolutionReport(report)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			names := []string{}
+			switch len(args) {
+			case 0:
+				for _, c := range back.GroupCommands() {
+					names = append(names, c.RuntimeName())
+				}
+				for _, c := range back.ExecutableCommands() {
+					if c.RuntimeGroup() == "" {
+						names = append(names, c.RuntimeName())
+					}
+				}
+			case 1:
+				for _, c := range back.ExecutableCommands() {
+					if c.RuntimeGroup() == args[0] {
+						names = append(names, c.RuntimeName())
+					}
+				}
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	whichCmd.Flags().BoolVarP(&whichFlags.json, "json", "", false, "output in JSON format")
+	rootCmd.AddCommand(whichCmd)
+}
+
+func newResolutionReport(resolution *backend.CommandResolution) commandResolutionReport {
+	cmd := resolution.Command
+	report := commandResolutionReport{
+		Command:    runtimeCommandLine(cmd),
+		FullName:   cmd.FullName(),
+		Type:       cmd.Type(),
+		Repository: cmd.RepositoryID(),
+		Package:    cmd.PackageName(),
+		Version:    resolution.Version,
+		PackageDir: cmd.PackageDir(),
+		Arguments:  []string{},
+		Shadowed:   []shadowedCmdReport{},
+	}
+	if cmd.Type() == "executable" {
+		report.Executable = cmd.InterpolatedExecutable()
+		report.Arguments = cmd.InterpolatedArguments()
+	}
+
+	if resolution.GroupAlias != "" || resolution.NameAlias != "" {
+		report.Alias = &aliasReport{
+			Group: resolution.GroupAlias,
+			Name:  resolution.NameAlias,
+		}
+	}
+
+	if c := resolution.Conflict; c != nil {
+		winner := "built-in command"
+		if c.Winner != nil {
+			winner = c.Winner.FullName()
+		}
+		report.Conflict = &conflictReport{
+			Name:      c.Name,
+			RenamedTo: c.RenamedTo,
+			Winner:    winner,
+		}
+	}
+
+	for _, s := range resolution.Shadowed {
+		report.Shadowed = append(report.Shadowed, shadowedCmdReport{
+			Command:    runtimeCommandLine(s),
+			FullName:   s.FullName(),
+			Repository: s.RepositoryID(),
+			Package:    s.PackageName(),
+			PackageDir: s.PackageDir(),
+		})
+	}
+	return report
+}
+
+func printResolutionReport(report commandResolutionReport) {
+	console.Highlight("Command: %s\n", report.Command)
+	fmt.Printf("  Full Name:   %s\n", report.FullName)
+	fmt.Printf("  Type:        %s\n", report.Type)
+	fmt.Printf("  Repository:  %s\n", report.Repository)
+	fmt.Printf("  Package:     %s\n", report.Package)
+	fmt.Printf("  Version:     %s\n", report.Version)
+	fmt.Printf("  Package Dir: %s\n", report.PackageDir)
+	if report.Type == "executable" {
+		fmt.Printf("  Executable:  %s\n", report.Executable)
+		if len(report.Arguments) > 0 {
+			fmt.Printf("  Arguments:   %s\n", strings.Join(report.Arguments, " "))
+		}
+	}
+	if report.Alias != nil {
+		if report.Alias.Group != "" {
+			fmt.Printf("  Group Alias: %s\n", report.Alias.Group)
+		}
+		if report.Alias.Name != "" {
+			fmt.Printf("  Alias:       %s\n", report.Alias.Name)
+		}
+	}
+	if report.Conflict != nil {
+		fmt.Printf("  Conflict:    '%s' is kept by %s, renamed to '%s'\n",
+			report.Conflict.Name, report.Conflict.Winner, report.Conflict.RenamedTo)
+	}
+	if len(report.Shadowed) > 0 {
+		fmt.Println("  Shadowed:")
+		for _, s := range report.Shadowed {
+			fmt.Printf("    - %s (%s/%s, run as '%s')\n", s.FullName, s.Repository, s.Package, s.Command)
+		}
+	}
+}
+
+// runtimeCommandLine returns the group and the name used to run a command
+func runtimeCommandLine(cmd command.Command) string {
+	if cmd.Type() == "group" {
+		return cmd.RuntimeName()
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", cmd.RuntimeGroup(), cmd.RuntimeName()))
+}
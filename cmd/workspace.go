@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/criteo/command-launcher/cmd/consent"
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/console"
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AddWorkspaceCmd(rootCmd *cobra.Command, appCtx context.LauncherContext, back backend.Backend) {
+	workspaceCmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Manage the workspaces and their trust",
+		Long: fmt.Sprintf(`
+Manage the workspaces providing packages with a %s file, and their trust.
+
+A workspace command runs only when you trust the workspace with its current
+content, you are asked on its first use, and each time its content changes.`,
+			backend.WorkspacePackagesFileName(appCtx.AppName())),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+			return nil
+		},
+	}
+
+	workspaceListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the workspaces with their trust state",
+		Long:  "List the workspaces discovered from the current directory, and the other trusted workspaces, with their trust state and its expiration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			discovered := discoveredWorkspaceDirs(appCtx)
+			dirs := append([]string{}, discovered...)
+			for _, dir := range consent.TrustedWorkspaceDirs() {
+				if !containsString(dirs, dir) {
+					dirs = append(dirs, dir)
+				}
+			}
+			if len(dirs) == 0 {
+				fmt.Println("No workspace found")
+				return nil
+			}
+
+			for _, dir := range dirs {
+				state := workspaceTrustState(dir, appCtx, back)
+				if !containsString(discovered, dir) {
+					state = state + ", not in the current directory"
+				}
+				fmt.Printf("%-50s : %s\n", dir, state)
+			}
+			return nil
+		},
+	}
+
+	workspaceTrustCmd := &cobra.Command{
+		Use:   "trust [workspace dir]",
+		Short: "Trust a workspace with its current content",
+		Long:  "Trust a workspace with its current content, the closest workspace of the current directory by default. Its registry packages are installed first, and a previous denial is replaced.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := workspaceDirArg(args, appCtx)
+			if err != nil {
+				return err
+			}
+			// trusting a workspace installs its registry packages
+			src, err := workspaceSource(dir, appCtx, back, true)
+			if err != nil {
+				return err
+			}
+			snapshot, err := backend.NewWorkspaceSnapshot(src, appCtx.AppName())
+			if err != nil {
+				return err
+			}
+			if err := consent.SaveWorkspaceConsent(dir, snapshot); err != nil {
+				return fmt.Errorf("cannot save the workspace consent: %v", err)
+			}
+			console.Success("Workspace %s trusted, with %d command(s)\n", dir, len(snapshot.Commands))
+			return nil
+		},
+		ValidArgsFunction: workspaceDirCompletion(appCtx),
+	}
+
+	workspaceUntrustCmd := &cobra.Command{
+		Use:   "untrust [workspace dir]",
+		Short: "Forget the trust or the denial of a workspace",
+		Long:  "Forget the trust or the denial of a workspace, the closest workspace of the current directory by default. You will be asked again on the next workspace command.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := workspaceDirArg(args, appCtx)
+			if err != nil {
+				return err
+			}
+			if err := consent.RemoveWorkspaceConsent(dir); err != nil {
+				return fmt.Errorf("cannot remove the workspace consent: %v", err)
+			}
+			console.Success("Workspace %s is not trusted anymore\n", dir)
+			return nil
+		},
+		ValidArgsFunction: workspaceDirCompletion(appCtx),
+	}
+
+	workspaceShowCmd := &cobra.Command{
+		Use:   "show [workspace dir]",
+		Short: "Show the packages and the commands of a workspace",
+		Long:  "Show the packages and the commands of a workspace, the closest workspace of the current directory by default, and the commands they shadow",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := workspaceDirArg(args, appCtx)
+			if err != nil {
+				return err
+			}
+			src, err := workspaceSource(dir, appCtx, back, false)
+			if err != nil {
+				return err
+			}
+			printWorkspace(dir, src, appCtx, back)
+			return nil
+		},
+		ValidArgsFunction: workspaceDirCompletion(appCtx),
+	}
+
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceTrustCmd)
+	workspaceCmd.AddCommand(workspaceUntrustCmd)
+	workspaceCmd.AddCommand(workspaceShowCmd)
+	rootCmd.AddCommand(workspaceCmd)
+}
+
+// discoveredWorkspaceDirs returns the workspaces of the current directory, deepest-first
+func discoveredWorkspaceDirs(appCtx context.LauncherContext) []string {
+	dirs := []string{}
+	wd, err := os.Getwd()
+	if err != nil {
+		return dirs
+	}
+	for _, ws := range backend.DiscoverWorkspaces(wd, appCtx.AppName()) {
+		dirs = append(dirs, ws.Dir)
+	}
+	return dirs
+}
+
+// workspaceDirArg returns the absolute workspace directory of the args, or
+// the closest workspace of the current directory
+func workspaceDirArg(args []string, appCtx context.LauncherContext) (string, error) {
+	if len(args) == 0 {
+		dirs := discoveredWorkspaceDirs(appCtx)
+		if len(dirs) == 0 {
+			return "", fmt.Errorf("no workspace found from the current directory")
+		}
+		return dirs[0], nil
+	}
+
+	dir, err := filepath.Abs(args[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid workspace directory %s: %v", args[0], err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, backend.WorkspacePackagesFileName(appCtx.AppName()))); err != nil {
+		return "", fmt.Errorf("no %s file found in %s", backend.WorkspacePackagesFileName(appCtx.AppName()), dir)
+	}
+	return dir, nil
+}
+
+// workspaceSource returns the package source of a workspace: the one of the
+// backend when it is loaded, otherwise it is loaded from the workspace directory.
+// With download, the missing registry packages are installed, and the source
+// is always loaded from the workspace directory.
+func workspaceSource(dir string, appCtx context.LauncherContext, back backend.Backend, download bool) (*backend.PackageSource, error) {
+	if !download {
+		for _, src := range back.AllPackageSources() {
+			if src.Name == backend.WorkspaceSourcePrefix+dir && src.Repo != nil {
+				return src, nil
+			}
+		}
+	}
+
+	ws, err := backend.LoadWorkspace(dir, appCtx.AppName())
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the workspace %s: %v", dir, err)
+	}
+	remotes := map[string]*backend.PackageSource{}
+	for _, src := range back.AllPackageSources() {
+		if src.IsManaged {
+			remotes[src.Name] = src
+		}
+	}
+	ws.InstallRegistryPackages(config.ProfileHome(), remotes, &rootCtxt.user,
+		viper.GetBool(config.VERIFY_PACKAGE_CHECKSUM_KEY),
+		viper.GetBool(config.VERIFY_PACKAGE_SIGNATURE_KEY),
+		download,
+	)
+	return backend.LoadWorkspaceSource(ws)
+}
+
+func workspaceTrustState(dir string, appCtx context.LauncherContext, back backend.Backend) string {
+	if _, err := os.Stat(filepath.Join(dir, backend.WorkspacePackagesFileName(appCtx.AppName()))); err != nil {
+		return "missing"
+	}
+
+	// the registry packages of a trusted workspace are cached when it is trusted
+	var snapshot *backend.WorkspaceSnapshot
+	if src, err := workspaceSource(dir, appCtx, back, false); err == nil {
+		snapshot, _ = backend.NewWorkspaceSnapshot(src, appCtx.AppName())
+	}
+	trust := consent.GetWorkspaceTrust(dir, snapshot)
+	switch trust.State {
+	case consent.WORKSPACE_TRUSTED:
+		return fmt.Sprintf("trusted, expires %s", trust.ExpiresAt.Format(time.RFC3339))
+	case consent.WORKSPACE_CHANGED:
+		return fmt.Sprintf("changed since trusted, expires %s", trust.ExpiresAt.Format(time.RFC3339))
+	case consent.WORKSPACE_DENIED:
+		return fmt.Sprintf("denied, expires %s", trust.ExpiresAt.Format(time.RFC3339))
+	}
+	return "not trusted"
+}
+
+func printWorkspace(dir string, src *backend.PackageSource, appCtx context.LauncherContext, back backend.Backend) {
+	console.Highlight("Workspace: %s\n", dir)
+	fmt.Printf("  Trust:    %s\n", workspaceTrustState(dir, appCtx, back))
+
+	fmt.Println("  Packages:")
+	pkgs := src.Repo.InstalledPackages()
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name() < pkgs[j].Name() })
+	cmds := src.Repo.InstalledCommands()
+	for _, pkg := range pkgs {
+		pkgDir := ""
+		for _, cmd := range cmds {
+			if cmd.PackageName() == pkg.Name() {
+				pkgDir = cmd.PackageDir()
+				break
+			}
+		}
+		fmt.Printf("    - %s (%s) %s\n", pkg.Name(), pkg.Version(), pkgDir)
+	}
+	pkgNames := []string{}
+	for _, pkg := range pkgs {
+		pkgNames = append(pkgNames, pkg.Name())
+	}
+	if ws, err := backend.LoadWorkspace(dir, appCtx.AppName()); err == nil {
+		for _, p := range ws.RegistryPackages {
+			if !containsString(pkgNames, p.Name) {
+				fmt.Printf("    - %s not installed, trust the workspace to install it\n", p)
+			}
+		}
+	}
+
+	// the conflicts are only known when the workspace is loaded by the backend
+	conflicts := []backend.CommandConflict{}
+	for _, c := range back.Conflicts() {
+		if c.Loser.RepositoryID() == src.Name || (c.Winner != nil && c.Winner.RepositoryID() == src.Name) {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	fmt.Println("  Commands:")
+	sort.Slice(cmds, func(i, j int) bool { return workspaceCmdName(cmds[i]) < workspaceCmdName(cmds[j]) })
+	for _, cmd := range cmds {
+		line := fmt.Sprintf("    - %-25s (%s)", workspaceCmdName(cmd), cmd.PackageName())
+		for _, c := range conflicts {
+			if c.Loser == cmd {
+				winner := "a built-in command"
+				if c.Winner != nil {
+					winner = fmt.Sprintf("%s/%s", c.Winner.RepositoryID(), c.Winner.PackageName())
+				}
+				line = fmt.Sprintf("%s shadowed by %s, run as '%s'", line, winner, c.RenamedTo)
+			}
+		}
+		fmt.Println(line)
+	}
+
+	shadowed := []string{}
+	for _, c := range conflicts {
+		if c.Winner != nil && c.Winner.RepositoryID() == src.Name {
+			shadowed = append(shadowed, fmt.Sprintf("    - %s from %s/%s, renamed to '%s'",
+				c.Name, c.Loser.RepositoryID(), c.Loser.PackageName(), c.RenamedTo))
+		}
+	}
+	if len(shadowed) > 0 {
+		fmt.Println("  Shadowed commands:")
+		fmt.Println(strings.Join(shadowed, "\n"))
+	}
+}
+
+// workspaceCmdName returns the group and the name of a command in its manifest
+func workspaceCmdName(cmd command.Command) string {
+	if cmd.Type() == "group" {
+		return cmd.Name()
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", cmd.Group(), cmd.Name()))
+}
+
+func workspaceDirCompletion(appCtx context.LauncherContext) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 1 {
+			return []string{}, cobra.ShellCompDirectiveNoFileComp
+		}
+		dirs := discoveredWorkspaceDirs(appCtx)
+		for _, dir := range consent.TrustedWorkspaceDirs() {
+			if !containsString(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+		return dirs, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/backend"
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeWorkspaceTestPackage(t *testing.T, pkgDir string, name string, cmds string) {
+	t.Helper()
+	assert.Nil(t, os.MkdirAll(pkgDir, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(pkgDir, "manifest.mf"), []byte(`{
+  "pkgName": "`+name+`",
+  "version": "1.0.0",
+  "cmds": [`+cmds+`]
+}`), 0644))
+}
+
+// createWorkspaceTest creates a workspace with a local package and a registry
+// package of the default remote, and a backend loading it; the returned
+// counter is the number of requests to the remote
+func createWorkspaceTest(t *testing.T) (context.LauncherContext, string, backend.Backend, *int32) {
+	t.Helper()
+	appCtx := context.InitContext("test-workspace", "1.0.0", "1")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CDT_VAULT_SECRET", "test-secret")
+	t.Setenv(appCtx.AppHomeEnvVar(), t.TempDir())
+
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	workspaceDir := t.TempDir()
+	writeWorkspaceTestPackage(t, filepath.Join(workspaceDir, "tools"), "tools",
+		`{"name": "hello", "type": "executable", "executable": "echo"}, {"name": "build", "type": "executable", "executable": "echo"}`)
+	assert.Nil(t, os.WriteFile(filepath.Join(workspaceDir, backend.WorkspacePackagesFileName(appCtx.AppName())),
+		[]byte("tools\nremote:default/ls@^0.0.1\n"), 0644))
+
+	dropinDir := t.TempDir()
+	writeWorkspaceTestPackage(t, filepath.Join(dropinDir, "other"), "other",
+		`{"name": "hello", "type": "executable", "executable": "echo"}`)
+
+	back, err := backend.NewDefaultBackend(t.TempDir(),
+		backend.DiscoverWorkspaceSources(workspaceDir, appCtx.AppName()),
+		backend.NewDropinSource(dropinDir),
+		backend.NewManagedSource("default", t.TempDir(), server.URL, backend.SYNC_POLICY_NEVER),
+	)
+	assert.Nil(t, err)
+	atomic.StoreInt32(&requests, 0)
+	return appCtx, workspaceDir, back, &requests
+}
+
+func captureWorkspaceOutput(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	out, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	return string(out)
+}
+
+func TestWorkspaceDirArg(t *testing.T) {
+	appCtx, workspaceDir, _, _ := createWorkspaceTest(t)
+
+	dir, err := workspaceDirArg([]string{workspaceDir}, appCtx)
+	assert.Nil(t, err)
+	assert.Equal(t, workspaceDir, dir)
+
+	_, err = workspaceDirArg([]string{t.TempDir()}, appCtx)
+	assert.NotNil(t, err)
+
+	// the closest workspace of the current directory
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	defer os.Chdir(wd)
+	assert.Nil(t, os.Chdir(filepath.Join(workspaceDir, "tools")))
+	dir, err = workspaceDirArg([]string{}, appCtx)
+	assert.Nil(t, err)
+	assert.Equal(t, workspaceDir, dir)
+
+	assert.Nil(t, os.Chdir(t.TempDir()))
+	_, err = workspaceDirArg([]string{}, appCtx)
+	assert.NotNil(t, err)
+}
+
+func TestWorkspaceTrustStateWithoutInstall(t *testing.T) {
+	appCtx, workspaceDir, back, requests := createWorkspaceTest(t)
+
+	assert.Equal(t, "not trusted", workspaceTrustState(workspaceDir, appCtx, back))
+	assert.Equal(t, "missing", workspaceTrustState(t.TempDir(), appCtx, back))
+
+	// the workspace not loaded by the backend is read without installing its registry packages
+	other := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(other, backend.WorkspacePackagesFileName(appCtx.AppName())), []byte("remote:default/ls\n"), 0644))
+	assert.Equal(t, "not trusted", workspaceTrustState(other, appCtx, back))
+
+	assert.Equal(t, int32(0), *requests)
+	_, err := os.Stat(backend.WorkspaceCacheDir(os.Getenv(appCtx.AppHomeEnvVar()), other))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPrintWorkspace(t *testing.T) {
+	appCtx, workspaceDir, back, requests := createWorkspaceTest(t)
+
+	src, err := workspaceSource(workspaceDir, appCtx, back, false)
+	assert.Nil(t, err)
+	out := captureWorkspaceOutput(t, func() { printWorkspace(workspaceDir, src, appCtx, back) })
+
+	assert.Contains(t, out, "Trust:    not trusted")
+	assert.Contains(t, out, "- tools (1.0.0) "+filepath.Join(workspaceDir, "tools"))
+	assert.Contains(t, out, "- remote:default/ls@^0.0.1 not installed, trust the workspace to install it")
+	assert.Contains(t, out, "- build                     (tools)\n")
+	// the dropin command shadowed by the workspace
+	assert.Contains(t, out, "Shadowed commands:\n    - hello from dropin/other, renamed to 'hello@@other@dropin'")
+	assert.Equal(t, int32(0), *requests)
+}
@@ -15,6 +15,9 @@ var RESERVED_CMD_SEARCH_KEY map[string]bool = map[string]bool{
 	"#version":    true,
 	"#help":       true,
 	"#completion": true,
+	"#which":      true,
+	"#workspace":  true,
+	"#consent":    true,
 }
 
 type Backend interface {
@@ -32,6 +35,44 @@ type Backend interface {
 
 	FindCommandByFullName(fullName string) (command.Command, error)
 
+	// Explain how a group and a name are resolved to a command: the package
+	// source and version, the aliases and the conflict renames applied, and
+	// the other commands with the same group and name
+	ResolveCommand(group string, name string) (*CommandResolution, error)
+
+	// Use a specific version of a package for the current session, instead
+	// of its installed version. The version is either the installed one or a
+	// version installed side by side in the same repository. Once used, the
+	// commands of the package are resolved by FindCommand to this version.
+	UsePackageVersion(name string, version string) error
+
+	// Change the priority of the package sources, and the preferred source
+	// of some top level commands. The priority is a list of source names,
+	// "workspace" and "extras" match all workspace sources and all extra
+	// remotes. The sources not in the list keep their default order after
+	// the listed ones. The prefer rules map a top level command name (a group
+	// or a root executable) to the source that wins its conflicts.
+	SetConflictRules(priority []string, preferRules map[string]string) error
+
+	// Get the commands renamed during the last command extraction because
+	// of a conflict
+	Conflicts() []CommandConflict
+
+	// Disable a package of a package source: it stays installed, but its
+	// commands are hidden. The package is referenced as [source:]package,
+	// the source is optional when only one source provides the package.
+	// The state is kept across sessions.
+	DisablePackage(ref string) error
+
+	// Enable a disabled package, referenced as [source:]package
+	EnablePackage(ref string) error
+
+	// Check if a package is disabled in a package source
+	IsPackageDisabled(source string, name string) bool
+
+	// Get the disabled packages, in form of source:package
+	DisabledPackages() []string
+
 	// Get all group commands
 	GroupCommands() []command.Command
 
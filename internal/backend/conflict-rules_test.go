@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeExtraSource(t *testing.T, name string, dir string, pkgName string, cmds string) *PackageSource {
+	t.Helper()
+	createTestPackageDir(t, dir, pkgName, cmds)
+	return &PackageSource{
+		Name:       name,
+		RepoDir:    dir,
+		SyncPolicy: SYNC_POLICY_NEVER,
+		IsManaged:  true,
+	}
+}
+
+func TestConflictsAreRecorded(t *testing.T) {
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("hotfix")+","+execCmd("config"))
+
+	be, err := NewDefaultBackend(t.TempDir(), []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+
+	conflicts := map[string]CommandConflict{}
+	for _, c := range be.Conflicts() {
+		conflicts[c.Name] = c
+	}
+	assert.Len(t, conflicts, 2)
+
+	assert.Equal(t, "dropin", conflicts["hotfix"].Winner.RepositoryID())
+	assert.Equal(t, "default", conflicts["hotfix"].Loser.RepositoryID())
+	assert.Equal(t, "hotfix@@default-pkg@default", conflicts["hotfix"].RenamedTo)
+
+	// conflict with a built-in command
+	assert.Nil(t, conflicts["config"].Winner)
+	assert.Equal(t, "config@@default-pkg@default", conflicts["config"].RenamedTo)
+}
+
+func TestSourcePriority(t *testing.T) {
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("hotfix"))
+	teamSrc := makeExtraSource(t, "team", t.TempDir(), "team-pkg", execCmd("hotfix"))
+
+	be, err := NewDefaultBackend(t.TempDir(), []*PackageSource{}, dropinSrc, defaultSrc, teamSrc)
+	assert.Nil(t, err)
+
+	cmd, err := be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "dropin", cmd.RepositoryID())
+
+	assert.Nil(t, be.SetConflictRules([]string{"extras", "default"}, map[string]string{}))
+	cmd, err = be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "team", cmd.RepositoryID())
+
+	// the unlisted sources keep their order after the listed ones
+	names := []string{}
+	for _, src := range be.AllPackageSources() {
+		names = append(names, src.Name)
+	}
+	assert.Equal(t, []string{"team", "default", "dropin"}, names)
+
+	renamed := []string{}
+	for _, c := range be.Conflicts() {
+		renamed = append(renamed, c.RenamedTo)
+	}
+	assert.Equal(t, []string{"hotfix@@default-pkg@default", "hotfix@@dropin-pkg@dropin"}, renamed)
+	cmd, err = be.FindCommand("", "hotfix@@dropin-pkg@dropin")
+	assert.Nil(t, err)
+	assert.Equal(t, "dropin", cmd.RepositoryID())
+
+	err = be.SetConflictRules([]string{"unknown"}, map[string]string{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown package sources: unknown")
+}
+
+func TestConflictRulesKeepLoadedRepos(t *testing.T) {
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("hotfix"))
+
+	be, err := NewDefaultBackend(t.TempDir(), []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+	dropinRepo := dropinSrc.Repo
+
+	assert.Nil(t, be.SetConflictRules([]string{"default"}, map[string]string{}))
+	assert.Same(t, dropinRepo, dropinSrc.Repo)
+	_, err = be.FindCommand("", "hotfix@@dropin-pkg@dropin")
+	assert.Nil(t, err)
+
+	// the renames of the previous rules are discarded
+	assert.Nil(t, be.SetConflictRules([]string{"dropin"}, map[string]string{}))
+	cmd, err := be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "dropin", cmd.RepositoryID())
+	_, err = be.FindCommand("", "hotfix@@dropin-pkg@dropin")
+	assert.NotNil(t, err)
+	assert.Len(t, be.ExecutableCommands(), 2)
+}
+
+func TestPreferRules(t *testing.T) {
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("hotfix")+","+groupCmd("infra")+","+execCmdInGroup("deploy", "infra"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("lint")+","+groupCmd("infra")+","+execCmdInGroup("deploy", "infra"))
+	teamSrc := makeExtraSource(t, "team", t.TempDir(), "team-pkg", execCmd("hotfix")+","+execCmd("lint"))
+
+	be, err := NewDefaultBackend(t.TempDir(), []*PackageSource{}, dropinSrc, defaultSrc, teamSrc)
+	assert.Nil(t, err)
+
+	assert.Nil(t, be.SetConflictRules([]string{}, map[string]string{
+		"hotfix": "team",
+		"infra":  "default",
+	}))
+
+	cmd, err := be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "team", cmd.RepositoryID())
+
+	// the whole group comes from the preferred source
+	cmd, err = be.FindCommand("", "infra")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", cmd.RepositoryID())
+	cmd, err = be.FindCommand("infra", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", cmd.RepositoryID())
+
+	// the other commands follow the source priority
+	cmd, err = be.FindCommand("", "lint")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", cmd.RepositoryID())
+}
+
+func TestPreferRulesIgnoreCase(t *testing.T) {
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("HotFix"))
+	teamSrc := makeExtraSource(t, "team", t.TempDir(), "team-pkg", execCmd("HotFix"))
+
+	be, err := NewDefaultBackend(t.TempDir(), []*PackageSource{}, dropinSrc, teamSrc)
+	assert.Nil(t, err)
+
+	// the prefer rules are read from the configuration with lowercase names
+	assert.Nil(t, be.SetConflictRules([]string{}, map[string]string{"hotfix": "team"}))
+	cmd, err := be.FindCommand("", "HotFix")
+	assert.Nil(t, err)
+	assert.Equal(t, "team", cmd.RepositoryID())
+}
+
+func TestPreferRuleOnReservedName(t *testing.T) {
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("consent")+","+execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("lint"))
+
+	be, err := NewDefaultBackend(t.TempDir(), []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+
+	// the preferred command is renamed on conflict with the built-in command,
+	// it must be extracted only once
+	assert.Nil(t, be.SetConflictRules([]string{}, map[string]string{"consent": "dropin"}))
+	assert.Len(t, be.ExecutableCommands(), 3)
+	assert.Len(t, be.Conflicts(), 1)
+	assert.Nil(t, be.Conflicts()[0].Winner)
+	assert.Equal(t, "consent@@dropin-pkg@dropin", be.Conflicts()[0].RenamedTo)
+}
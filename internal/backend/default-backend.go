@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/console"
 	"github.com/criteo/command-launcher/internal/repository"
 	"gopkg.in/yaml.v3"
 )
@@ -31,11 +32,31 @@ type DefaultBackend struct {
 
 	userAlias map[string]string
 	tmpAlias  map[string]string
+
+	pinnedVersions   map[string]string // key is the package name, value is the version to use
+	disabledPackages map[string]bool   // key is source:package
+	preferRules      map[string]string // key is the top level command name, value is the preferred source
+	conflicts        []CommandConflict
+}
+
+// CommandConflict is a command that has been renamed because another
+// command with the same name has a higher priority
+type CommandConflict struct {
+	Name      string          // the conflicting name, ex: "hotfix" or "infra deploy"
+	Winner    command.Command // the command that keeps the name, nil for a built-in command
+	Loser     command.Command // the renamed command
+	RenamedTo string          // the new name of the loser
 }
 
 const DEFAULT_REPO_ID = "default"
 const DROPIN_REPO_ID = "dropin"
 
+// special entries of the source priority
+const (
+	SOURCE_PRIORITY_WORKSPACE = "workspace" // all workspace sources
+	SOURCE_PRIORITY_EXTRAS    = "extras"    // all extra remotes
+)
+
 const RENAME_FILE_NAME = "rename.json"
 
 // Create a new default backend with multiple local repository directories
@@ -53,11 +74,15 @@ func NewDefaultBackend(homeDir string, workspaceSources []*PackageSource, dropin
 		sources: sources,
 
 		// data need to be reset during reload
-		cmdsCache:      map[string]command.Command{},
-		groupCmds:      []command.Command{},
-		executableCmds: []command.Command{},
-		userAlias:      map[string]string{},
-		tmpAlias:       map[string]string{},
+		cmdsCache:        map[string]command.Command{},
+		groupCmds:        []command.Command{},
+		executableCmds:   []command.Command{},
+		userAlias:        map[string]string{},
+		tmpAlias:         map[string]string{},
+		pinnedVersions:   map[string]string{},
+		preferRules:      map[string]string{},
+		conflicts:        []CommandConflict{},
+		disabledPackages: map[string]bool{},
 	}
 	err := backend.Reload()
 	return backend, err
@@ -75,10 +100,23 @@ func (backend *DefaultBackend) Reload() error {
 
 	err := backend.loadRepos()
 	backend.loadAlias()
+	if disabledErr := backend.loadDisabledPackages(); disabledErr != nil {
+		console.Warn("%v, all packages are enabled\n", disabledErr)
+	}
 	backend.extractCmds()
 	return err
 }
 
+// refreshCmds extracts the commands of the loaded repositories again, without
+// loading them, once the pinned versions or the conflict rules have changed
+func (backend *DefaultBackend) refreshCmds() {
+	backend.cmdsCache = make(map[string]command.Command)
+	backend.groupCmds = []command.Command{}
+	backend.executableCmds = []command.Command{}
+	backend.tmpAlias = make(map[string]string)
+	backend.extractCmds()
+}
+
 func (backend *DefaultBackend) loadRepos() error {
 	failures := []string{}
 	for _, src := range backend.sources {
@@ -141,50 +179,164 @@ func (backend *DefaultBackend) setRuntimeByAlias(cmd command.Command) {
 }
 
 func (backend *DefaultBackend) extractCmds() {
+	backend.conflicts = []CommandConflict{}
+
+	type sourceCmds struct {
+		groupCmds      []command.Command
+		executableCmds []command.Command
+	}
+	// the commands from the preferred sources are extracted first, so that
+	// they win the conflicts, then the others following the source priority.
+	// The preference is decided once before the extraction, which renames
+	// the commands in conflict
+	preferred, others := []sourceCmds{}, []sourceCmds{}
 	for _, src := range backend.sources {
-		repo := src.Repo
-		if repo == nil {
+		if src.Repo == nil {
 			continue
 		}
-		// first extract group commands
-		cmds := repo.InstalledGroupCommands()
-		for _, cmd := range cmds {
+		groupCmds, executableCmds := backend.sourceCommands(src)
+		p, o := sourceCmds{}, sourceCmds{}
+		for _, cmd := range groupCmds {
+			// the renames of a previous extraction are discarded, and
+			// the prefer rules apply to the names renamed by the user
+			resetRuntimeName(cmd)
 			backend.setRuntimeByAlias(cmd)
-
-			key := getCmdSearchKey(cmd)
-			if _, exist := backend.cmdsCache[key]; exist || isReservedCmd(key) {
-				// conflict
-				cmd.SetRuntimeName(cmd.FullName())
-				backend.tmpAlias[cmd.FullName()] = cmd.FullName()
-				key = getCmdSearchKey(cmd)
+			if backend.isPreferred(src, cmd) {
+				p.groupCmds = append(p.groupCmds, cmd)
+			} else {
+				o.groupCmds = append(o.groupCmds, cmd)
+			}
+		}
+		for _, cmd := range executableCmds {
+			resetRuntimeName(cmd)
+			backend.setRuntimeByAlias(cmd)
+			if backend.isPreferred(src, cmd) {
+				p.executableCmds = append(p.executableCmds, cmd)
+			} else {
+				o.executableCmds = append(o.executableCmds, cmd)
 			}
-
-			backend.cmdsCache[key] = cmd
-			backend.groupCmds = append(backend.groupCmds, cmd)
 		}
+		preferred = append(preferred, p)
+		others = append(others, o)
+	}
 
+	for _, s := range append(preferred, others...) {
+		// first extract group commands
+		for _, cmd := range s.groupCmds {
+			backend.addGroupCmd(cmd)
+		}
 		// now extract executable commands
-		cmds = repo.InstalledExecutableCommands()
-		for _, cmd := range cmds {
-			backend.setRuntimeByAlias(cmd)
+		for _, cmd := range s.executableCmds {
+			backend.addExecutableCmd(cmd)
+		}
+	}
+}
 
-			key := getCmdSearchKey(cmd)
-			if _, exist := backend.cmdsCache[key]; exist || isReservedCmd(key) {
-				// conflict
-				if cmd.Group() == "" {
-					cmd.SetRuntimeName(cmd.FullName())
-					backend.tmpAlias[cmd.FullName()] = cmd.FullName()
-				} else {
-					cmd.SetRuntimeGroup(cmd.FullGroup())
-					backend.tmpAlias[cmd.FullGroup()] = cmd.FullGroup()
-				}
+// resetRuntimeName restores the group and the name of the manifest
+func resetRuntimeName(cmd command.Command) {
+	cmd.SetRuntimeGroup("")
+	cmd.SetRuntimeName("")
+}
+
+func (backend *DefaultBackend) addGroupCmd(cmd command.Command) {
+	backend.setRuntimeByAlias(cmd)
+
+	key := getCmdSearchKey(cmd)
+	if winner, exist := backend.cmdsCache[key]; exist || isReservedCmd(key) {
+		// conflict
+		name := cmd.RuntimeName()
+		cmd.SetRuntimeName(cmd.FullName())
+		backend.tmpAlias[cmd.FullName()] = cmd.FullName()
+		key = getCmdSearchKey(cmd)
+		backend.conflicts = append(backend.conflicts, CommandConflict{
+			Name: name, Winner: winner, Loser: cmd, RenamedTo: cmd.RuntimeName(),
+		})
+	}
+
+	backend.cmdsCache[key] = cmd
+	backend.groupCmds = append(backend.groupCmds, cmd)
+}
+
+func (backend *DefaultBackend) addExecutableCmd(cmd command.Command) {
+	backend.setRuntimeByAlias(cmd)
+
+	key := getCmdSearchKey(cmd)
+	if winner, exist := backend.cmdsCache[key]; exist || isReservedCmd(key) {
+		// conflict
+		name := runtimePath(cmd)
+		if cmd.Group() == "" {
+			cmd.SetRuntimeName(cmd.FullName())
+			backend.tmpAlias[cmd.FullName()] = cmd.FullName()
+		} else {
+			cmd.SetRuntimeGroup(cmd.FullGroup())
+			backend.tmpAlias[cmd.FullGroup()] = cmd.FullGroup()
+		}
+		backend.conflicts = append(backend.conflicts, CommandConflict{
+			Name: name, Winner: winner, Loser: cmd, RenamedTo: runtimePath(cmd),
+		})
+	}
+
+	key = getCmdSearchKey(cmd)
+	backend.cmdsCache[key] = cmd
+	backend.executableCmds = append(backend.executableCmds, cmd)
+}
+
+// sourceCommands returns the group and the executable commands of a source,
+// the commands of the packages with a pinned version are replaced by the ones
+// of the pinned version, the commands of the disabled packages are excluded
+func (backend *DefaultBackend) sourceCommands(src *PackageSource) ([]command.Command, []command.Command) {
+	groupCmds := backend.enabledCommands(src, src.Repo.InstalledGroupCommands())
+	executableCmds := backend.enabledCommands(src, src.Repo.InstalledExecutableCommands())
+
+	pinned := map[string]command.PackageManifest{}
+	for name, version := range backend.pinnedVersions {
+		if backend.IsPackageDisabled(src.Name, name) || backend.pinnedVersionSource(name, version) != src {
+			continue
+		}
+		if mf, err := src.Repo.PackageVersion(name, version); err == nil {
+			pinned[name] = mf
+		}
+	}
+	if len(pinned) == 0 {
+		return groupCmds, executableCmds
+	}
+
+	filter := func(cmds []command.Command) []command.Command {
+		filtered := []command.Command{}
+		for _, cmd := range cmds {
+			if _, exists := pinned[cmd.PackageName()]; !exists {
+				filtered = append(filtered, cmd)
+			}
+		}
+		return filtered
+	}
+	groupCmds = filter(groupCmds)
+	executableCmds = filter(executableCmds)
+
+	for _, mf := range pinned {
+		for _, cmd := range mf.Commands() {
+			switch cmd.Type() {
+			case "group":
+				groupCmds = append(groupCmds, cmd)
+			case "executable":
+				executableCmds = append(executableCmds, cmd)
 			}
+		}
+	}
+	return groupCmds, executableCmds
+}
 
-			key = getCmdSearchKey(cmd)
-			backend.cmdsCache[key] = cmd
-			backend.executableCmds = append(backend.executableCmds, cmd)
+// pinnedVersionSource returns the first source that provides the version of the package
+func (backend *DefaultBackend) pinnedVersionSource(name string, version string) *PackageSource {
+	for _, src := range backend.sources {
+		if src.Repo == nil {
+			continue
+		}
+		if _, err := src.Repo.PackageVersion(name, version); err == nil {
+			return src
 		}
 	}
+	return nil
 }
 
 func getCmdSearchKey(cmd command.Command) string {
@@ -215,6 +367,122 @@ func (backend *DefaultBackend) FindCommand(group string, name string) (command.C
 	return cmd, nil
 }
 
+func (backend *DefaultBackend) UsePackageVersion(name string, version string) error {
+	if backend.pinnedVersionSource(name, version) == nil {
+		if _, err := backend.findPackage(name); err != nil {
+			return fmt.Errorf("no package named %s found", name)
+		}
+		return fmt.Errorf("version %s of the package %s is not installed", version, name)
+	}
+
+	backend.pinnedVersions[name] = version
+	backend.refreshCmds()
+	return nil
+}
+
+func (backend *DefaultBackend) SetConflictRules(priority []string, preferRules map[string]string) error {
+	unknown := []string{}
+
+	ordered := []*PackageSource{}
+	added := map[*PackageSource]bool{}
+	for _, entry := range priority {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		matched := false
+		for _, src := range backend.sources {
+			if matchSourcePriority(src, entry) {
+				matched = true
+				if !added[src] {
+					ordered = append(ordered, src)
+					added[src] = true
+				}
+			}
+		}
+		if !matched {
+			unknown = append(unknown, entry)
+		}
+	}
+	// the sources not in the priority list keep their default order
+	for _, src := range backend.sources {
+		if !added[src] {
+			ordered = append(ordered, src)
+		}
+	}
+	backend.sources = ordered
+
+	backend.preferRules = map[string]string{}
+	for name, source := range preferRules {
+		found := false
+		for _, src := range backend.sources {
+			if src.Name == source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, source)
+		}
+		// the configuration keys are case insensitive
+		backend.preferRules[strings.ToLower(name)] = source
+	}
+
+	backend.refreshCmds()
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown package sources: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// matchSourcePriority checks if a source matches an entry of the source priority:
+// a source name, "workspace" for all workspace sources, or "extras" for all extra remotes
+func matchSourcePriority(src *PackageSource, entry string) bool {
+	switch entry {
+	case SOURCE_PRIORITY_WORKSPACE:
+		return strings.HasPrefix(src.Name, WorkspaceSourcePrefix)
+	case SOURCE_PRIORITY_EXTRAS:
+		return src.IsManaged && src.Name != DEFAULT_REPO_ID
+	}
+	return src.Name == entry
+}
+
+// isPreferred checks if a prefer rule selects the source for the command
+func (backend *DefaultBackend) isPreferred(src *PackageSource, cmd command.Command) bool {
+	preferred, exists := backend.preferRules[strings.ToLower(topLevelName(cmd))]
+	return exists && preferred == src.Name
+}
+
+func (backend *DefaultBackend) Conflicts() []CommandConflict {
+	return backend.conflicts
+}
+
+// topLevelName returns the name of the command at the root level: the group
+// name for a command in a group
+func topLevelName(cmd command.Command) string {
+	if cmd.Type() == "executable" && cmd.RuntimeGroup() != "" {
+		return cmd.RuntimeGroup()
+	}
+	return cmd.RuntimeName()
+}
+
+// runtimePath returns the group and the name to run a command, ex: "infra deploy"
+func runtimePath(cmd command.Command) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", cmd.RuntimeGroup(), cmd.RuntimeName()))
+}
+
+func (backend *DefaultBackend) findPackage(name string) (command.PackageManifest, error) {
+	for _, src := range backend.sources {
+		if src.Repo == nil {
+			continue
+		}
+		if mf, err := src.Repo.Package(name); err == nil {
+			return mf, nil
+		}
+	}
+	return nil, fmt.Errorf("no package named %s found", name)
+}
+
 func (backend DefaultBackend) FindCommandByFullName(fullName string) (command.Command, error) {
 	for _, c := range backend.cmdsCache {
 		if c.FullName() == fullName {
@@ -261,6 +529,9 @@ func (backend *DefaultBackend) RenameCommand(cmd command.Command, new_name strin
 		return fmt.Errorf("can't encode rename in json: %v", err)
 	}
 
+	if err := os.MkdirAll(backend.homeDir, 0755); err != nil {
+		return fmt.Errorf("can't write rename filen: %v", err)
+	}
 	err = os.WriteFile(filepath.Join(backend.homeDir, RENAME_FILE_NAME), payload, 0755)
 	if err != nil {
 		return fmt.Errorf("can't write rename filen: %v", err)
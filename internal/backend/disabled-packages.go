@@ -0,0 +1,169 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/command"
+)
+
+// the disabled packages are kept installed, but their commands are hidden
+const DISABLED_PACKAGES_FILE_NAME = "disabled-packages.json"
+
+// a package is disabled in a package source, the packages with the same
+// name in other sources are not affected. It is referenced in the same way
+// as a command namespace, ex: dropin:my-package
+func disabledPackageKey(source string, name string) string {
+	return fmt.Sprintf("%s:%s", source, name)
+}
+
+func (backend *DefaultBackend) loadDisabledPackages() error {
+	backend.disabledPackages = map[string]bool{}
+	payload, err := os.ReadFile(filepath.Join(backend.homeDir, DISABLED_PACKAGES_FILE_NAME))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot read the disabled packages: %v", err)
+	}
+
+	refs := []string{}
+	if err := json.Unmarshal(payload, &refs); err != nil {
+		return fmt.Errorf("cannot read the disabled packages: %v", err)
+	}
+	for _, ref := range refs {
+		backend.disabledPackages[ref] = true
+	}
+	return nil
+}
+
+func (backend *DefaultBackend) writeDisabledPackages() error {
+	payload, err := json.Marshal(backend.DisabledPackages())
+	if err != nil {
+		return fmt.Errorf("cannot encode the disabled packages: %v", err)
+	}
+	if err := os.MkdirAll(backend.homeDir, 0755); err != nil {
+		return fmt.Errorf("cannot write the disabled packages: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(backend.homeDir, DISABLED_PACKAGES_FILE_NAME), payload, 0644); err != nil {
+		return fmt.Errorf("cannot write the disabled packages: %v", err)
+	}
+	return nil
+}
+
+// enabledCommands excludes the commands of the packages disabled in the source
+func (backend *DefaultBackend) enabledCommands(src *PackageSource, cmds []command.Command) []command.Command {
+	if len(backend.disabledPackages) == 0 {
+		return cmds
+	}
+	enabled := []command.Command{}
+	for _, cmd := range cmds {
+		if !backend.IsPackageDisabled(src.Name, cmd.PackageName()) {
+			enabled = append(enabled, cmd)
+		}
+	}
+	return enabled
+}
+
+// resolvePackageRef finds the source of a package reference in form of
+// [source:]package, the source is optional when only one source provides
+// the package
+func (backend *DefaultBackend) resolvePackageRef(ref string) (string, string, error) {
+	if i := strings.LastIndex(ref, ":"); i >= 0 {
+		source, name := ref[:i], ref[i+1:]
+		for _, src := range backend.sources {
+			if src.Name != source {
+				continue
+			}
+			if src.Repo == nil {
+				return "", "", fmt.Errorf("package source %s is not loaded", source)
+			}
+			if _, err := src.Repo.Package(name); err != nil {
+				return "", "", err
+			}
+			return source, name, nil
+		}
+		return "", "", fmt.Errorf("unknown package source %s", source)
+	}
+
+	sources := []string{}
+	for _, src := range backend.sources {
+		if src.Repo == nil {
+			continue
+		}
+		if _, err := src.Repo.Package(ref); err == nil {
+			sources = append(sources, src.Name)
+		}
+	}
+	switch len(sources) {
+	case 0:
+		return "", "", fmt.Errorf("cannot find the package %s", ref)
+	case 1:
+		return sources[0], ref, nil
+	}
+	refs := []string{}
+	for _, source := range sources {
+		refs = append(refs, disabledPackageKey(source, ref))
+	}
+	return "", "", fmt.Errorf("package %s is installed in several sources, use one of: %s", ref, strings.Join(refs, ", "))
+}
+
+func (backend *DefaultBackend) DisablePackage(ref string) error {
+	source, name, err := backend.resolvePackageRef(ref)
+	if err != nil {
+		return err
+	}
+	key := disabledPackageKey(source, name)
+	if backend.disabledPackages[key] {
+		return fmt.Errorf("package %s is already disabled", key)
+	}
+	backend.disabledPackages[key] = true
+	if err := backend.writeDisabledPackages(); err != nil {
+		return err
+	}
+	return backend.Reload()
+}
+
+func (backend *DefaultBackend) EnablePackage(ref string) error {
+	key := ref
+	if !strings.Contains(ref, ":") {
+		// find the source of the disabled package by its name
+		matches := []string{}
+		for _, disabled := range backend.DisabledPackages() {
+			if strings.HasSuffix(disabled, ":"+ref) {
+				matches = append(matches, disabled)
+			}
+		}
+		if len(matches) > 1 {
+			return fmt.Errorf("package %s is disabled in several sources, use one of: %s", ref, strings.Join(matches, ", "))
+		}
+		if len(matches) == 1 {
+			key = matches[0]
+		}
+	}
+	if !backend.disabledPackages[key] {
+		return fmt.Errorf("package %s is not disabled", ref)
+	}
+	delete(backend.disabledPackages, key)
+	if err := backend.writeDisabledPackages(); err != nil {
+		return err
+	}
+	return backend.Reload()
+}
+
+func (backend *DefaultBackend) IsPackageDisabled(source string, name string) bool {
+	return backend.disabledPackages[disabledPackageKey(source, name)]
+}
+
+func (backend *DefaultBackend) DisabledPackages() []string {
+	refs := []string{}
+	for ref := range backend.disabledPackages {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
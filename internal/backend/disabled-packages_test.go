@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisablePackage(t *testing.T) {
+	homeDir := t.TempDir()
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("hotfix")+","+execCmd("lint"))
+
+	be, err := NewDefaultBackend(homeDir, []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+
+	assert.Nil(t, be.DisablePackage("dropin-pkg"))
+	assert.True(t, be.IsPackageDisabled("dropin", "dropin-pkg"))
+	assert.Equal(t, []string{"dropin:dropin-pkg"}, be.DisabledPackages())
+
+	// the command of the disabled package does not conflict anymore
+	cmd, err := be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", cmd.RepositoryID())
+	assert.Len(t, be.ExecutableCommands(), 2)
+	assert.Empty(t, be.Conflicts())
+
+	// the package is still installed
+	_, err = be.DropinRepository().Package("dropin-pkg")
+	assert.Nil(t, err)
+
+	// the state is kept across sessions
+	be, err = NewDefaultBackend(homeDir, []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+	assert.True(t, be.IsPackageDisabled("dropin", "dropin-pkg"))
+
+	assert.Nil(t, be.EnablePackage("dropin-pkg"))
+	cmd, err = be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "dropin", cmd.RepositoryID())
+
+	assert.NotNil(t, be.EnablePackage("dropin-pkg"))
+	assert.NotNil(t, be.DisablePackage("unknown"))
+}
+
+func TestDisablePackageInOneSource(t *testing.T) {
+	homeDir := t.TempDir()
+	dropinSrc := makeDropinSource(t, t.TempDir(), "shared-pkg", execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "shared-pkg", execCmd("hotfix"))
+
+	be, err := NewDefaultBackend(homeDir, []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+
+	// the source is required when several sources provide the package
+	err = be.DisablePackage("shared-pkg")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "dropin:shared-pkg, default:shared-pkg")
+	assert.NotNil(t, be.DisablePackage("unknown:shared-pkg"))
+
+	assert.Nil(t, be.DisablePackage("dropin:shared-pkg"))
+	assert.True(t, be.IsPackageDisabled("dropin", "shared-pkg"))
+	assert.False(t, be.IsPackageDisabled("default", "shared-pkg"))
+
+	// the package of the other source is still enabled
+	cmd, err := be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", cmd.RepositoryID())
+	assert.Len(t, be.ExecutableCommands(), 1)
+
+	assert.Nil(t, be.EnablePackage("shared-pkg"))
+	assert.Len(t, be.ExecutableCommands(), 2)
+}
+
+func TestCorruptedDisabledPackages(t *testing.T) {
+	homeDir := t.TempDir()
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("lint"))
+	assert.Nil(t, os.WriteFile(filepath.Join(homeDir, DISABLED_PACKAGES_FILE_NAME), []byte("{not json"), 0644))
+
+	be, err := NewDefaultBackend(homeDir, []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+
+	// the error is reported, and the packages are loaded
+	assert.NotNil(t, be.(*DefaultBackend).loadDisabledPackages())
+	assert.Len(t, be.ExecutableCommands(), 2)
+}
@@ -0,0 +1,26 @@
+package backend
+
+import (
+	"time"
+
+	"github.com/criteo/command-launcher/internal/repository"
+)
+
+// NewExternalSource creates a PackageSource for the packages returned by an
+// external package source provider, the provider is called in workDir, and
+// its output is cached when a cache is provided.
+func NewExternalSource(name string, workDir string, provider []string, timeout time.Duration, cache *repository.ExternalProviderCache) (*PackageSource, error) {
+	repoIndex, err := repository.NewExternalRepoIndex(name, provider, timeout, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PackageSource{
+		Name:            name,
+		RepoDir:         workDir,
+		RemoteBaseURL:   "",
+		SyncPolicy:      SYNC_POLICY_NEVER,
+		IsManaged:       false,
+		CustomRepoIndex: repoIndex,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test provider is a shell script")
+	}
+	workDir := t.TempDir()
+	pkgDir := createTestPackageDir(t, t.TempDir(), "bazel-pkg", execCmd("build-tool")+","+execCmd("hotfix"))
+	provider := filepath.Join(t.TempDir(), "provider.sh")
+	script := "#!/bin/sh\necho '{\"packages\": [{\"dir\": \"" + pkgDir + "\"}]}'\n"
+	assert.Nil(t, os.WriteFile(provider, []byte(script), 0755))
+
+	externalSrc, err := NewExternalSource("bazel", workDir, []string{provider}, 5*time.Second, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, workDir, externalSrc.RepoDir)
+
+	dropinSrc := makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("hotfix"))
+	defaultSrc := makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("lint"))
+	be, err := NewDefaultBackend(t.TempDir(), []*PackageSource{}, dropinSrc, defaultSrc, externalSrc)
+	assert.Nil(t, err)
+
+	cmd, err := be.FindCommand("", "build-tool")
+	assert.Nil(t, err)
+	assert.Equal(t, "bazel", cmd.RepositoryID())
+	assert.Equal(t, pkgDir, cmd.PackageDir())
+
+	// the external sources have the lowest priority by default
+	cmd, err = be.FindCommand("", "hotfix")
+	assert.Nil(t, err)
+	assert.Equal(t, "dropin", cmd.RepositoryID())
+}
@@ -5,8 +5,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/criteo/command-launcher/internal/command"
 	"github.com/criteo/command-launcher/internal/remote"
 	"github.com/criteo/command-launcher/internal/repository"
+	"github.com/criteo/command-launcher/internal/syncPolicy"
 	"github.com/criteo/command-launcher/internal/updater"
 	"github.com/criteo/command-launcher/internal/user"
 
@@ -14,12 +16,12 @@ import (
 )
 
 const (
-	SYNC_POLICY_NEVER   = "never"
-	SYNC_POLICY_ALWAYS  = "always"
-	SYNC_POLICY_HOURLY  = "hourly"
-	SYNC_POLICY_DAILY   = "daily"
-	SYNC_POLICY_WEEKLY  = "weekly"
-	SYNC_POLICY_MONTHLY = "monthly"
+	SYNC_POLICY_NEVER   = syncPolicy.NEVER
+	SYNC_POLICY_ALWAYS  = syncPolicy.ALWAYS
+	SYNC_POLICY_HOURLY  = syncPolicy.HOURLY
+	SYNC_POLICY_DAILY   = syncPolicy.DAILY
+	SYNC_POLICY_WEEKLY  = syncPolicy.WEEKLY
+	SYNC_POLICY_MONTHLY = syncPolicy.MONTHLY
 )
 
 type PackageSource struct {
@@ -29,13 +31,15 @@ type PackageSource struct {
 	RemoteRegistryURL string
 	SyncPolicy        string
 	IsManaged         bool
+	// the packages of the remote to install, all packages when empty
+	PackageFilter remote.PackageFilter
 
 	Repo    repository.PackageRepository
 	Failure error
 
 	Updater *updater.CmdUpdater
 
-	CustomRepoIndex repository.RepoIndex // nil for standard sources, set for workspace sources
+	CustomRepoIndex repository.RepoIndex // nil for standard sources, set for workspace and external sources
 }
 
 func NewDropinSource(repoDir string) *PackageSource {
@@ -74,6 +78,7 @@ func (src *PackageSource) InitUpdater(user *user.User, timeout time.Duration, en
 		VerifyChecksum:       verifyChecksum,
 		VerifySignature:      verifySignature,
 		SyncPolicy:           src.SyncPolicy,
+		PackageFilter:        src.PackageFilter,
 	}
 	return src.Updater
 }
@@ -101,6 +106,10 @@ func (src *PackageSource) InitialInstallCommands(user *user.User, enableCI bool,
 
 	if pkgs, err := remote.PackageNames(); err == nil {
 		for _, pkgName := range pkgs {
+			if !src.PackageFilter.Match(pkgName) {
+				log.Infof("Skip installing package %s, excluded by the package filter of the remote %s\n", pkgName, src.Name)
+				continue
+			}
 			pkgVersion := "unspecified"
 			if lockedVersion, ok := lockedPackages[pkgName]; ok {
 				pkgVersion = lockedVersion
@@ -148,3 +157,76 @@ func (src *PackageSource) InitialInstallCommands(user *user.User, enableCI bool,
 
 	return nil
 }
+
+// ResolveVersion returns the highest version of a package in the remote of
+// the package source, satisfying the constraint and available for the user's partition
+func (src *PackageSource) ResolveVersion(user *user.User, name string, constraint remote.VersionConstraint) (string, error) {
+	if src.RemoteBaseURL == "" {
+		return "", fmt.Errorf("no remote configured for the repository %s", src.Name)
+	}
+	remoteRepo := remote.CreateRemoteRepository(src.RemoteBaseURL)
+	pkgInfos, err := remoteRepo.All()
+	if err != nil {
+		return "", fmt.Errorf("cannot get the packages of the remote %s: %v", src.Name, err)
+	}
+
+	versions := []string{}
+	for _, info := range pkgInfos {
+		if info.Name == name && user.InPartition(info.StartPartition, info.EndPartition) {
+			versions = append(versions, info.Version)
+		}
+	}
+	version := constraint.Latest(versions)
+	if version == "" {
+		return "", fmt.Errorf("no version of the package %s matching %s found in the remote %s", name, constraint, src.Name)
+	}
+	return version, nil
+}
+
+// FetchPackage downloads a package from the remote of the package source and
+// verifies it. When the version is empty, the latest version available for
+// the user's partition is fetched.
+func (src *PackageSource) FetchPackage(user *user.User, name string, version string, verifyChecksum bool, verifySignature bool) (command.Package, error) {
+	if !src.PackageFilter.Match(name) {
+		return nil, fmt.Errorf("the package %s is excluded by the package filter of the remote %s", name, src.Name)
+	}
+	return src.fetchPackage(user, name, version, verifyChecksum, verifySignature)
+}
+
+// FetchInstalledPackage downloads again the exact version of an installed
+// package, to repair it. The package filter is not applied: it selects the
+// packages to install from the remote, not the ones already installed.
+func (src *PackageSource) FetchInstalledPackage(name string, version string, verifyChecksum bool, verifySignature bool) (command.Package, error) {
+	if version == "" {
+		return nil, fmt.Errorf("no installed version of the package %s to fetch", name)
+	}
+	return src.fetchPackage(nil, name, version, verifyChecksum, verifySignature)
+}
+
+func (src *PackageSource) fetchPackage(user *user.User, name string, version string, verifyChecksum bool, verifySignature bool) (command.Package, error) {
+	if src.RemoteBaseURL == "" {
+		return nil, fmt.Errorf("no remote configured for the repository %s", src.Name)
+	}
+	remoteRepo := remote.CreateRemoteRepository(src.RemoteBaseURL)
+
+	if version == "" {
+		latest, err := remoteRepo.QueryLatestPackageInfo(name, func(pkgInfo *remote.PackageInfo) bool {
+			return user.InPartition(pkgInfo.StartPartition, pkgInfo.EndPartition)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot find the package %s in the remote %s: %v", name, src.Name, err)
+		}
+		version = latest.Version
+	} else if _, err := remoteRepo.PackageInfo(name, version); err != nil {
+		return nil, fmt.Errorf("cannot find the package %s in the remote %s: %v", name, src.Name, err)
+	}
+
+	pkg, err := remoteRepo.Package(name, version)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the package %s@%s: %v", name, version, err)
+	}
+	if ok, err := remoteRepo.Verify(pkg, verifyChecksum, verifySignature); !ok || err != nil {
+		return nil, fmt.Errorf("failed to verify the package %s@%s: %v", name, version, err)
+	}
+	return pkg, nil
+}
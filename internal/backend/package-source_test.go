@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/helper"
+	"github.com/criteo/command-launcher/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestRemote(t *testing.T) string {
+	t.Helper()
+	basePath := filepath.Join(t.TempDir(), "remote-test")
+	assert.Nil(t, os.Mkdir(basePath, 0755))
+
+	err := helper.CopyLocalFile("../remote/assets/remote/basic-index.json", filepath.Join(basePath, "index.json"), false)
+	assert.Nil(t, err)
+	for _, version := range []string{"0.0.2", "0.0.3"} {
+		pkgFile := fmt.Sprintf("ls-%s.pkg", version)
+		err = helper.CopyLocalFile(filepath.Join("../remote/assets", pkgFile), filepath.Join(basePath, pkgFile), false)
+		assert.Nil(t, err)
+	}
+	return fmt.Sprintf("file://%s", basePath)
+}
+
+func TestFetchPackage(t *testing.T) {
+	src := PackageSource{Name: "default", RemoteBaseURL: createTestRemote(t)}
+	u := user.User{Partition: 1}
+
+	// latest version
+	pkg, err := src.FetchPackage(&u, "ls", "", false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "ls", pkg.Name())
+
+	// specific version
+	pkg, err = src.FetchPackage(&u, "ls", "0.0.2", false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "ls", pkg.Name())
+	assert.Equal(t, "0.0.2", pkg.Version())
+}
+
+func TestFetchPackageNotFound(t *testing.T) {
+	src := PackageSource{Name: "default", RemoteBaseURL: createTestRemote(t)}
+	u := user.User{Partition: 1}
+
+	_, err := src.FetchPackage(&u, "unknown", "", false, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cannot find the package unknown in the remote default")
+
+	_, err = src.FetchPackage(&u, "ls", "9.9.9", false, false)
+	assert.NotNil(t, err)
+
+	_, err = (&PackageSource{Name: "dropin"}).FetchPackage(&u, "ls", "", false, false)
+	assert.NotNil(t, err)
+}
+
+func TestFetchInstalledPackageIgnoresFilter(t *testing.T) {
+	src := PackageSource{Name: "default", RemoteBaseURL: createTestRemote(t)}
+	src.PackageFilter.Exclude = []string{"ls"}
+	u := user.User{Partition: 1}
+
+	_, err := src.FetchPackage(&u, "ls", "0.0.2", false, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "excluded by the package filter")
+
+	pkg, err := src.FetchInstalledPackage("ls", "0.0.2", false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0.2", pkg.Version())
+
+	_, err = src.FetchInstalledPackage("ls", "", false, false)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsePackageVersion(t *testing.T) {
+	homeDir := t.TempDir()
+	dropinDir := t.TempDir()
+	defaultDir := t.TempDir()
+
+	dropinSrc := makeDropinSource(t, dropinDir, "dropin-pkg", execCmd("other"))
+	defaultSrc := makeDefaultSource(t, defaultDir, "mypkg", groupCmd("infra")+","+execCmdInGroup("deploy", "infra"))
+
+	// a previous version installed side by side, with an extra command
+	versionDir := filepath.Join(defaultDir, repository.PACKAGE_VERSIONS_DIR, "mypkg", "0.9.0")
+	assert.Nil(t, os.MkdirAll(versionDir, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(versionDir, "manifest.mf"), []byte(`{
+  "pkgName": "mypkg",
+  "version": "0.9.0",
+  "cmds": [`+groupCmd("infra")+","+execCmdInGroup("deploy", "infra")+","+execCmdInGroup("migrate", "infra")+`]
+}`), 0644))
+
+	be, err := NewDefaultBackend(homeDir, []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+
+	cmd, err := be.FindCommand("infra", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(defaultDir, "mypkg"), cmd.PackageDir())
+	_, err = be.FindCommand("infra", "migrate")
+	assert.NotNil(t, err)
+
+	defaultRepo := defaultSrc.Repo
+	assert.Nil(t, be.UsePackageVersion("mypkg", "0.9.0"))
+	// the repositories are not loaded again
+	assert.Same(t, defaultRepo, defaultSrc.Repo)
+
+	cmd, err = be.FindCommand("infra", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, versionDir, cmd.PackageDir())
+	assert.Equal(t, "default", cmd.RepositoryID())
+	cmd, err = be.FindCommand("infra", "migrate")
+	assert.Nil(t, err)
+	assert.Equal(t, versionDir, cmd.PackageDir())
+	assert.Len(t, be.GroupCommands(), 1)
+	assert.Len(t, be.ExecutableCommands(), 3)
+
+	// the other packages are not changed
+	cmd, err = be.FindCommand("", "other")
+	assert.Nil(t, err)
+	assert.Equal(t, "dropin", cmd.RepositoryID())
+
+	// the pinned version is kept on reload
+	assert.Nil(t, be.Reload())
+	cmd, err = be.FindCommand("infra", "migrate")
+	assert.Nil(t, err)
+	assert.Equal(t, versionDir, cmd.PackageDir())
+
+	// the installed version can be used as well
+	assert.Nil(t, be.UsePackageVersion("mypkg", "1.0.0"))
+	cmd, err = be.FindCommand("infra", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(defaultDir, "mypkg"), cmd.PackageDir())
+
+	err = be.UsePackageVersion("mypkg", "2.0.0")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "version 2.0.0 of the package mypkg is not installed")
+
+	err = be.UsePackageVersion("unknown", "1.0.0")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no package named unknown found")
+}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/command"
+)
+
+// CommandResolution explains how a group and a name are resolved to a command
+type CommandResolution struct {
+	Command command.Command
+	Source  *PackageSource
+	// the version of the package providing the command
+	Version string
+	// the user aliases applied to the group and to the name of the command,
+	// empty when the command is not renamed by the user
+	GroupAlias string
+	NameAlias  string
+	// the conflict that renamed the command or its group, nil if none
+	Conflict *CommandConflict
+	// the other commands with the same group and name, which are renamed
+	// because of the resolved command or of each other
+	Shadowed []command.Command
+}
+
+func (backend *DefaultBackend) ResolveCommand(group string, name string) (*CommandResolution, error) {
+	cmd, err := backend.FindCommand(group, name)
+	if err != nil {
+		return nil, fmt.Errorf("no command %s found", strings.TrimSpace(group+" "+name))
+	}
+
+	resolution := &CommandResolution{
+		Command:  cmd,
+		Shadowed: []command.Command{},
+	}
+
+	for _, src := range backend.sources {
+		if src.Name == cmd.RepositoryID() {
+			resolution.Source = src
+			break
+		}
+	}
+	resolution.Version = backend.commandVersion(resolution.Source, cmd)
+
+	if alias, exists := backend.userAlias[cmd.FullName()]; exists {
+		resolution.NameAlias = alias
+	}
+	if cmd.Type() == "executable" && cmd.Group() != "" {
+		if alias, exists := backend.userAlias[cmd.FullGroup()]; exists {
+			resolution.GroupAlias = alias
+		}
+	}
+
+	for i, c := range backend.conflicts {
+		// an executable in a group is renamed with its group
+		if c.Loser == cmd || (cmd.Type() == "executable" && c.Loser.Type() == "group" && c.Loser.FullName() == cmd.FullGroup()) {
+			resolution.Conflict = &backend.conflicts[i]
+			break
+		}
+	}
+
+	candidates := backend.groupCmds
+	if cmd.Type() == "executable" {
+		candidates = backend.executableCmds
+	}
+	for _, c := range candidates {
+		if c != cmd && c.Group() == cmd.Group() && c.Name() == cmd.Name() {
+			resolution.Shadowed = append(resolution.Shadowed, c)
+		}
+	}
+
+	return resolution, nil
+}
+
+// commandVersion returns the version of the package providing the command,
+// taking into account the package version used in this session
+func (backend *DefaultBackend) commandVersion(src *PackageSource, cmd command.Command) string {
+	if src == nil || src.Repo == nil {
+		return ""
+	}
+	if version, pinned := backend.pinnedVersions[cmd.PackageName()]; pinned && backend.pinnedVersionSource(cmd.PackageName(), version) == src {
+		return version
+	}
+	if mf, err := src.Repo.Package(cmd.PackageName()); err == nil {
+		return mf.Version()
+	}
+	return ""
+}
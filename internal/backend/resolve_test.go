@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveCommand(t *testing.T) {
+	homeDir := t.TempDir()
+	dropinDir := t.TempDir()
+	defaultDir := t.TempDir()
+
+	dropinSrc := makeDropinSource(t, dropinDir, "dropin-pkg", groupCmd("infra")+","+execCmdInGroup("deploy", "infra"))
+	defaultSrc := makeDefaultSource(t, defaultDir, "default-pkg", groupCmd("infra")+","+execCmdInGroup("deploy", "infra"))
+
+	be, err := NewDefaultBackend(homeDir, []*PackageSource{}, dropinSrc, defaultSrc)
+	assert.Nil(t, err)
+
+	resolution, err := be.ResolveCommand("infra", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, "dropin", resolution.Source.Name)
+	assert.Equal(t, "1.0.0", resolution.Version)
+	assert.Equal(t, filepath.Join(dropinDir, "dropin-pkg"), resolution.Command.PackageDir())
+	assert.Nil(t, resolution.Conflict)
+	assert.Len(t, resolution.Shadowed, 1)
+	assert.Equal(t, "default", resolution.Shadowed[0].RepositoryID())
+	assert.Equal(t, "infra@@default-pkg@default", resolution.Shadowed[0].RuntimeGroup())
+
+	// the command in the renamed group reports the conflict of its group
+	resolution, err = be.ResolveCommand("infra@@default-pkg@default", "deploy")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", resolution.Source.Name)
+	assert.NotNil(t, resolution.Conflict)
+	assert.Equal(t, "infra", resolution.Conflict.Name)
+	assert.Equal(t, "infra@@default-pkg@default", resolution.Conflict.RenamedTo)
+	assert.Equal(t, "dropin", resolution.Conflict.Winner.RepositoryID())
+
+	// user alias
+	cmd, err := be.FindCommand("infra", "deploy")
+	assert.Nil(t, err)
+	assert.Nil(t, be.RenameCommand(cmd, "ship"))
+	assert.Nil(t, be.Reload())
+	resolution, err = be.ResolveCommand("infra", "ship")
+	assert.Nil(t, err)
+	assert.Equal(t, "ship", resolution.NameAlias)
+	assert.Equal(t, "", resolution.GroupAlias)
+
+	_, err = be.ResolveCommand("infra", "unknown")
+	assert.Equal(t, "no command infra unknown found", err.Error())
+	_, err = be.ResolveCommand("", "unknown")
+	assert.Equal(t, "no command unknown found", err.Error())
+}
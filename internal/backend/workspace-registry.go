@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/criteo/command-launcher/internal/remote"
+	"github.com/criteo/command-launcher/internal/user"
+)
+
+const (
+	// the prefix of the registry packages in a .cdt-packages file
+	WorkspaceRegistryPrefix = "remote:"
+	// the folder of the registry packages installed for the workspaces, in the home folder of the profile
+	WORKSPACE_CACHE_DIR = "workspace-cache"
+)
+
+var errWorkspacePackageNotCached = errors.New("no cached version satisfies the constraint")
+
+// WorkspaceRegistryPackage is a package of a remote registry required by a
+// workspace, in form of remote:[remote name]/[package name]@[version constraint]
+type WorkspaceRegistryPackage struct {
+	Remote     string
+	Name       string
+	Constraint remote.VersionConstraint
+}
+
+func ParseWorkspaceRegistryPackage(entry string) (WorkspaceRegistryPackage, error) {
+	ref := strings.TrimPrefix(entry, WorkspaceRegistryPrefix)
+	ref, constraint, _ := strings.Cut(ref, "@")
+	remoteName, name, ok := strings.Cut(ref, "/")
+	if !ok || remoteName == "" || name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return WorkspaceRegistryPackage{}, fmt.Errorf("invalid registry package, must be in form of %s[remote]/[package]@[version constraint]", WorkspaceRegistryPrefix)
+	}
+	vc, err := remote.ParseVersionConstraint(constraint)
+	if err != nil {
+		return WorkspaceRegistryPackage{}, err
+	}
+	return WorkspaceRegistryPackage{
+		Remote:     remoteName,
+		Name:       name,
+		Constraint: vc,
+	}, nil
+}
+
+func (p WorkspaceRegistryPackage) String() string {
+	return fmt.Sprintf("%s%s/%s@%s", WorkspaceRegistryPrefix, p.Remote, p.Name, p.Constraint)
+}
+
+// WorkspaceCacheDir returns the folder of the registry packages installed for a workspace
+func WorkspaceCacheDir(homeDir string, workspaceDir string) string {
+	return filepath.Join(homeDir, WORKSPACE_CACHE_DIR, fmt.Sprintf("%x", sha256.Sum256([]byte(workspaceDir)))[:16])
+}
+
+// InstallRegistryPackages installs the registry packages of the workspace
+// into its cache, and adds their directories to the package paths of the
+// workspace, before the local packages. The remotes are the managed package
+// sources by name. A package that cannot be installed is skipped with a warning.
+// When download is false, only the cached versions are used, and a package
+// without any cached version is skipped.
+func (ws *Workspace) InstallRegistryPackages(homeDir string, remotes map[string]*PackageSource, user *user.User, verifyChecksum bool, verifySignature bool, download bool) {
+	if len(ws.RegistryPackages) == 0 {
+		return
+	}
+
+	cacheDir := WorkspaceCacheDir(homeDir, ws.Dir)
+	pkgPaths := []string{}
+	for _, p := range ws.RegistryPackages {
+		src, exists := remotes[p.Remote]
+		if !exists {
+			log.Warnf("workspace: skipping %s in %s: unknown or disabled remote %s", p, ws.Dir, p.Remote)
+			continue
+		}
+		pkgDir, err := installWorkspacePackage(cacheDir, p, src, user, verifyChecksum, verifySignature, download)
+		if err == errWorkspacePackageNotCached {
+			log.Infof("workspace: skipping %s in %s: %v", p, ws.Dir, err)
+			continue
+		}
+		if err != nil {
+			log.Warnf("workspace: skipping %s in %s: %v", p, ws.Dir, err)
+			continue
+		}
+		pkgPaths = append(pkgPaths, pkgDir)
+	}
+	ws.PackagePaths = append(pkgPaths, ws.PackagePaths...)
+}
+
+// installWorkspacePackage returns the directory of a registry package in the
+// workspace cache. The highest cached version satisfying the constraint is
+// used without any network access, otherwise the highest version of the
+// remote satisfying the constraint is installed, when download is true.
+func installWorkspacePackage(cacheDir string, p WorkspaceRegistryPackage, src *PackageSource, user *user.User, verifyChecksum bool, verifySignature bool, download bool) (string, error) {
+	versionsDir := filepath.Join(cacheDir, p.Remote, p.Name)
+	if version := p.Constraint.Latest(cachedWorkspacePackageVersions(versionsDir)); version != "" {
+		return filepath.Join(versionsDir, version), nil
+	}
+	if !download {
+		return "", errWorkspacePackageNotCached
+	}
+
+	version, err := src.ResolveVersion(user, p.Name, p.Constraint)
+	if err != nil {
+		return "", err
+	}
+	pkg, err := src.FetchPackage(user, p.Name, version, verifyChecksum, verifySignature)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(versionsDir, 0755); err != nil {
+		return "", fmt.Errorf("cannot create the workspace cache folder: %v", err)
+	}
+	tmpDir, err := os.MkdirTemp(versionsDir, ".install-")
+	if err != nil {
+		return "", fmt.Errorf("cannot create the workspace cache folder: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, err := pkg.InstallTo(filepath.Join(tmpDir, p.Name)); err != nil {
+		return "", fmt.Errorf("cannot install the package %s@%s: %v", p.Name, version, err)
+	}
+	pkgDir := filepath.Join(versionsDir, version)
+	if err := os.Rename(filepath.Join(tmpDir, p.Name), pkgDir); err != nil {
+		return "", fmt.Errorf("cannot install the package %s@%s: %v", p.Name, version, err)
+	}
+	log.Infof("workspace: package %s@%s installed in %s", p.Name, version, pkgDir)
+	return pkgDir, nil
+}
+
+// cachedWorkspacePackageVersions returns the installed versions of a package in the workspace cache
+func cachedWorkspacePackageVersions(versionsDir string) []string {
+	versions := []string{}
+	entries, err := os.ReadDir(versionsDir)
+	if err != nil {
+		return versions
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(versionsDir, entry.Name(), "manifest.mf")); err == nil {
+			versions = append(versions, entry.Name())
+		}
+	}
+	return versions
+}
@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkspaceRegistryPackage(t *testing.T) {
+	p, err := ParseWorkspaceRegistryPackage("remote:default/infra-tools@^2.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", p.Remote)
+	assert.Equal(t, "infra-tools", p.Name)
+	assert.True(t, p.Constraint.Check("2.3.0"))
+	assert.False(t, p.Constraint.Check("3.0.0"))
+	assert.Equal(t, "remote:default/infra-tools@^2.1", p.String())
+
+	p, err = ParseWorkspaceRegistryPackage("remote:team/linter")
+	assert.Nil(t, err)
+	assert.Equal(t, "remote:team/linter@*", p.String())
+
+	for _, entry := range []string{"remote:default", "remote:/pkg", "remote:default/", "remote:default/a/b", "remote:default/..", "remote:default/pkg@^x"} {
+		_, err := ParseWorkspaceRegistryPackage(entry)
+		assert.NotNil(t, err, entry)
+	}
+}
+
+func TestParseWorkspaceEntries_RegistryPackages(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestManifest(t, tmpDir, "local-pkg")
+
+	content := `local-pkg
+remote:default/infra-tools@^2.1
+remote:invalid
+`
+	dotFile := filepath.Join(tmpDir, WorkspacePackagesFileName(testAppName))
+	assert.Nil(t, os.WriteFile(dotFile, []byte(content), 0644))
+
+	paths, registryPkgs, err := ParseWorkspaceEntries(dotFile)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(tmpDir, "local-pkg")}, paths)
+	assert.Len(t, registryPkgs, 1)
+	assert.Equal(t, "infra-tools", registryPkgs[0].Name)
+
+	// only the local packages are returned by ParseWorkspaceFile
+	paths, err = ParseWorkspaceFile(dotFile)
+	assert.Nil(t, err)
+	assert.Len(t, paths, 1)
+}
+
+func TestInstallWorkspaceRegistryPackages(t *testing.T) {
+	homeDir := t.TempDir()
+	workspaceDir := t.TempDir()
+	createTestManifest(t, workspaceDir, "local-pkg")
+
+	content := `local-pkg
+remote:default/ls@^0.0.1
+remote:default/unknown-pkg
+remote:unknown/ls
+`
+	dotFile := filepath.Join(workspaceDir, WorkspacePackagesFileName(testAppName))
+	assert.Nil(t, os.WriteFile(dotFile, []byte(content), 0644))
+
+	workspaces := DiscoverWorkspaces(workspaceDir, testAppName)
+	assert.Len(t, workspaces, 1)
+	ws := workspaces[0]
+	assert.Len(t, ws.RegistryPackages, 3)
+
+	src := &PackageSource{Name: "default", RemoteBaseURL: createTestRemote(t), IsManaged: true}
+	u := user.User{Partition: 1}
+
+	// nothing is downloaded when the download is not allowed
+	ws.InstallRegistryPackages(homeDir, map[string]*PackageSource{"default": src}, &u, false, false, false)
+	assert.Equal(t, []string{filepath.Join(workspaceDir, "local-pkg")}, ws.PackagePaths)
+	_, err := os.Stat(WorkspaceCacheDir(homeDir, workspaceDir))
+	assert.True(t, os.IsNotExist(err))
+
+	ws = DiscoverWorkspaces(workspaceDir, testAppName)[0]
+	ws.InstallRegistryPackages(homeDir, map[string]*PackageSource{"default": src}, &u, false, false, true)
+
+	cachedDir := filepath.Join(WorkspaceCacheDir(homeDir, workspaceDir), "default", "ls", "0.0.2")
+	assert.Equal(t, []string{cachedDir, filepath.Join(workspaceDir, "local-pkg")}, ws.PackagePaths)
+	_, err = os.Stat(filepath.Join(cachedDir, "manifest.mf"))
+	assert.Nil(t, err)
+
+	// the cached version is used without the remote
+	ws = DiscoverWorkspaces(workspaceDir, testAppName)[0]
+	offline := &PackageSource{Name: "default", IsManaged: true}
+	ws.InstallRegistryPackages(homeDir, map[string]*PackageSource{"default": offline}, &u, false, false, false)
+	assert.Equal(t, cachedDir, ws.PackagePaths[0])
+
+	// the registry packages are resolved with the workspace priority
+	wsSrc, err := NewWorkspaceSource(ws.Dir, ws.PackagePaths)
+	assert.Nil(t, err)
+	be, err := NewDefaultBackend(homeDir, []*PackageSource{wsSrc}, makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("other")), makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("lint")))
+	assert.Nil(t, err)
+	mf, err := wsSrc.Repo.Package("ls")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0.2", mf.Version())
+	assert.NotNil(t, be)
+}
@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/command"
+)
+
+// WorkspaceSnapshot describes the content of a workspace that the user
+// trusts: its packages file, the manifests of its packages, and the
+// executables of its commands. Any change of them changes the digest.
+type WorkspaceSnapshot struct {
+	Digest string `json:"digest"`
+	// the digest of each command, the key is the group and the name of the
+	// command in the manifest, ex: "infra deploy"
+	Commands map[string]string `json:"commands"`
+}
+
+// NewWorkspaceSnapshot computes the snapshot of a workspace package source
+func NewWorkspaceSnapshot(src *PackageSource, appName string) (*WorkspaceSnapshot, error) {
+	if src.Repo == nil {
+		return nil, fmt.Errorf("the workspace %s is not loaded", src.RepoDir)
+	}
+
+	snapshot := &WorkspaceSnapshot{Commands: map[string]string{}}
+	digest := sha256.New()
+
+	packagesFile := filepath.Join(src.RepoDir, WorkspacePackagesFileName(appName))
+	fileDigest, err := fileSha256(packagesFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the workspace packages file: %v", err)
+	}
+	fmt.Fprintf(digest, "%s\n", fileDigest)
+
+	pkgDirs := map[string]string{}
+	cmds := src.Repo.InstalledCommands()
+	for _, cmd := range cmds {
+		pkgDirs[cmd.PackageDir()] = cmd.PackageName()
+	}
+	for _, pkgDir := range sortedKeys(pkgDirs) {
+		// a package without manifest file is ignored by the workspace
+		manifestDigest, _ := fileSha256(filepath.Join(pkgDir, "manifest.mf"))
+		fmt.Fprintf(digest, "%s %s\n", pkgDir, manifestDigest)
+	}
+
+	for _, cmd := range cmds {
+		cmdDigest := commandSha256(cmd)
+		key := strings.TrimSpace(fmt.Sprintf("%s %s", cmd.Group(), cmd.Name()))
+		if cmd.Type() == "group" {
+			key = cmd.Name()
+		}
+		if existing, exists := snapshot.Commands[key]; exists {
+			// commands with the same name in different packages
+			cmdDigest = fmt.Sprintf("%x", sha256.Sum256([]byte(existing+cmdDigest)))
+		}
+		snapshot.Commands[key] = cmdDigest
+	}
+	for _, key := range sortedKeys(snapshot.Commands) {
+		fmt.Fprintf(digest, "%s %s\n", key, snapshot.Commands[key])
+	}
+
+	snapshot.Digest = fmt.Sprintf("%x", digest.Sum(nil))
+	return snapshot, nil
+}
+
+// commandSha256 digests what a command runs: its definition, and the
+// content of its executable and of the files passed as arguments
+func commandSha256(cmd command.Command) string {
+	digest := sha256.New()
+	fmt.Fprintf(digest, "%s\n%s\n%s\n%s\n", cmd.PackageName(), cmd.Type(), cmd.Group(), cmd.Name())
+	fmt.Fprintf(digest, "%s\n%q\n%q\n%q\n%q\n", cmd.Executable(), cmd.Arguments(), cmd.ValidArgsCmd(), cmd.FlagValuesCmd(), cmd.RequestedResources())
+	if cmd.Type() == "executable" {
+		for _, file := range append([]string{cmd.InterpolatedExecutable()}, cmd.InterpolatedArguments()...) {
+			if !filepath.IsAbs(file) {
+				continue
+			}
+			if fileDigest, err := fileSha256(file); err == nil {
+				fmt.Fprintf(digest, "%s %s\n", file, fileDigest)
+			}
+		}
+	}
+	return fmt.Sprintf("%x", digest.Sum(nil))
+}
+
+func fileSha256(path string) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !stat.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", digest.Sum(nil)), nil
+}
+
+// WorkspaceChanges lists the commands added, changed, and removed between two snapshots
+type WorkspaceChanges struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+func (c WorkspaceChanges) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// Changes compares the snapshot with a previous one
+func (snapshot *WorkspaceSnapshot) Changes(previous *WorkspaceSnapshot) WorkspaceChanges {
+	changes := WorkspaceChanges{Added: []string{}, Changed: []string{}, Removed: []string{}}
+	for _, key := range sortedKeys(snapshot.Commands) {
+		previousDigest, exists := previous.Commands[key]
+		if !exists {
+			changes.Added = append(changes.Added, key)
+		} else if previousDigest != snapshot.Commands[key] {
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+	for _, key := range sortedKeys(previous.Commands) {
+		if _, exists := snapshot.Commands[key]; !exists {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+	return changes
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
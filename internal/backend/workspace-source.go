@@ -3,7 +3,9 @@ package backend
 import (
 	"bufio"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -20,11 +22,20 @@ func WorkspacePackagesFileName(appName string) string {
 	return fmt.Sprintf(".%s-packages", appName)
 }
 
-// DiscoverWorkspaceSources walks up from startDir to the filesystem root,
-// looking for .<appName>-packages files. Returns workspace PackageSources ordered
-// deepest-first (closest to startDir has highest priority).
-func DiscoverWorkspaceSources(startDir string, appName string) []*PackageSource {
-	sources := []*PackageSource{}
+// Workspace is a directory containing a .cdt-packages file
+type Workspace struct {
+	Dir string
+	// the absolute paths of the local package directories
+	PackagePaths []string
+	// the packages to install from the remote registries
+	RegistryPackages []WorkspaceRegistryPackage
+}
+
+// DiscoverWorkspaces walks up from startDir to the filesystem root, looking
+// for .<appName>-packages files. Returns the workspaces ordered deepest-first
+// (closest to startDir has highest priority).
+func DiscoverWorkspaces(startDir string, appName string) []Workspace {
+	workspaces := []Workspace{}
 	dir := startDir
 	checked := ""
 	fileName := WorkspacePackagesFileName(appName)
@@ -32,38 +43,102 @@ func DiscoverWorkspaceSources(startDir string, appName string) []*PackageSource
 	for dir != checked {
 		candidate := filepath.Join(dir, fileName)
 		if _, err := os.Stat(candidate); err == nil {
-			pkgPaths, err := ParseWorkspaceFile(candidate)
+			pkgPaths, registryPkgs, err := ParseWorkspaceEntries(candidate)
 			if err != nil {
 				log.Warnf("workspace: failed to parse %s: %v", candidate, err)
-			} else if len(pkgPaths) > 0 {
-				src, err := NewWorkspaceSource(dir, pkgPaths)
-				if err != nil {
-					log.Warnf("workspace: failed to create source from %s: %v", candidate, err)
-				} else {
-					sources = append(sources, src)
-				}
+			} else if len(pkgPaths) > 0 || len(registryPkgs) > 0 {
+				workspaces = append(workspaces, Workspace{
+					Dir:              dir,
+					PackagePaths:     pkgPaths,
+					RegistryPackages: registryPkgs,
+				})
 			}
 		}
 		checked = dir
 		dir = filepath.Dir(dir)
 	}
 
+	return workspaces
+}
+
+// LoadWorkspace reads the packages file of a workspace directory
+func LoadWorkspace(dir string, appName string) (*Workspace, error) {
+	pkgPaths, registryPkgs, err := ParseWorkspaceEntries(filepath.Join(dir, WorkspacePackagesFileName(appName)))
+	if err != nil {
+		return nil, err
+	}
+	return &Workspace{
+		Dir:              dir,
+		PackagePaths:     pkgPaths,
+		RegistryPackages: registryPkgs,
+	}, nil
+}
+
+// LoadWorkspaceSource creates the package source of a workspace, and loads
+// its packages outside of a backend
+func LoadWorkspaceSource(ws *Workspace) (*PackageSource, error) {
+	src, err := NewWorkspaceSource(ws.Dir, ws.PackagePaths)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := repository.CreateLocalRepository(src.Name, src.RepoDir, src.CustomRepoIndex)
+	if err != nil {
+		return nil, err
+	}
+	src.Repo = repo
+	return src, nil
+}
+
+// DiscoverWorkspaceSources returns the sources of the local packages of the
+// workspaces, ordered deepest-first, see DiscoverWorkspaces.
+func DiscoverWorkspaceSources(startDir string, appName string) []*PackageSource {
+	sources := []*PackageSource{}
+	for _, ws := range DiscoverWorkspaces(startDir, appName) {
+		if len(ws.PackagePaths) == 0 {
+			continue
+		}
+		src, err := NewWorkspaceSource(ws.Dir, ws.PackagePaths)
+		if err != nil {
+			log.Warnf("workspace: failed to create source from %s: %v", ws.Dir, err)
+		} else {
+			sources = append(sources, src)
+		}
+	}
+
 	return sources
 }
 
 // ParseWorkspaceFile reads a .cdt-packages file and returns absolute paths
 // to valid package directories. Lines starting with # are comments.
+//
+// Each line is a package path relative to the workspace directory, a glob
+// pattern (ex: tools/*/), or a root followed by /** to find every manifest.mf
+// under it. Lines starting with ! are ignore rules: the packages matching
+// them, or under a directory matching them, are skipped.
+//
 // Absolute paths and paths containing ".." are rejected for security
 // (packages must be under the workspace directory).
 func ParseWorkspaceFile(filePath string) ([]string, error) {
+	paths, _, err := ParseWorkspaceEntries(filePath)
+	return paths, err
+}
+
+// ParseWorkspaceEntries reads a .cdt-packages file and returns the absolute
+// paths of the local package directories, and the registry packages, see
+// ParseWorkspaceFile. A registry package is declared in form of
+// remote:[remote name]/[package name]@[version constraint], ex:
+// remote:default/infra-tools@^2.1
+func ParseWorkspaceEntries(filePath string) ([]string, []WorkspaceRegistryPackage, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
 
 	baseDir := filepath.Dir(filePath)
-	var paths []string
+	entries := []string{}
+	ignores := []string{}
+	registryPkgs := []WorkspaceRegistryPackage{}
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
@@ -74,33 +149,143 @@ func ParseWorkspaceFile(filePath string) ([]string, error) {
 			continue
 		}
 
+		if strings.HasPrefix(line, WorkspaceRegistryPrefix) {
+			registryPkg, err := ParseWorkspaceRegistryPackage(line)
+			if err != nil {
+				log.Warnf("workspace: rejecting %q in %s: %v", line, filePath, err)
+			} else {
+				registryPkgs = append(registryPkgs, registryPkg)
+			}
+			continue
+		}
+
+		ignore := strings.HasPrefix(line, "!")
+		pattern := strings.TrimPrefix(line, "!")
+
 		// reject absolute paths and paths containing ".." for security
-		if filepath.IsAbs(line) {
+		if filepath.IsAbs(pattern) {
 			log.Warnf("workspace: rejecting path %q in %s: absolute paths are not allowed", line, filePath)
 			continue
 		}
-		if containsParentTraversal(line) {
+		if containsParentTraversal(pattern) {
 			log.Warnf("workspace: rejecting path %q in %s: parent directory traversal (..) is not allowed", line, filePath)
 			continue
 		}
 
-		absPath := filepath.Join(baseDir, line)
+		pattern = path.Clean(filepath.ToSlash(pattern))
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Warnf("workspace: rejecting path %q in %s: %v", line, filePath, err)
+			continue
+		}
+		if ignore {
+			ignores = append(ignores, pattern)
+		} else {
+			entries = append(entries, pattern)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var paths []string
+	found := map[string]bool{}
+	for _, entry := range entries {
+		for _, relPath := range workspaceEntryPackages(baseDir, entry, filePath) {
+			if found[relPath] || isIgnoredWorkspacePath(relPath, ignores) {
+				continue
+			}
+			found[relPath] = true
+			paths = append(paths, filepath.Join(baseDir, filepath.FromSlash(relPath)))
+		}
+	}
+
+	return paths, registryPkgs, nil
+}
+
+// workspaceEntryPackages returns the relative paths of the package
+// directories selected by an entry of the packages file
+func workspaceEntryPackages(baseDir string, entry string, filePath string) []string {
+	recursive := false
+	if entry == "**" {
+		entry, recursive = ".", true
+	} else if root, ok := strings.CutSuffix(entry, "/**"); ok {
+		entry, recursive = root, true
+	}
+	if strings.Contains(entry, "**") {
+		log.Warnf("workspace: rejecting path %q in %s: ** is only supported at the end of a path", entry, filePath)
+		return []string{}
+	}
+
+	roots := []string{entry}
+	isPattern := strings.ContainsAny(entry, "*?[")
+	if isPattern {
+		matches, _ := filepath.Glob(filepath.Join(baseDir, filepath.FromSlash(entry)))
+		roots = []string{}
+		for _, match := range matches {
+			if rel, err := filepath.Rel(baseDir, match); err == nil && !containsParentTraversal(rel) {
+				roots = append(roots, filepath.ToSlash(rel))
+			}
+		}
+	}
 
+	pkgPaths := []string{}
+	for _, root := range roots {
+		absRoot := filepath.Join(baseDir, filepath.FromSlash(root))
+		if recursive {
+			pkgPaths = append(pkgPaths, findWorkspacePackages(baseDir, absRoot)...)
+			continue
+		}
 		// validate that the path exists and contains a manifest.mf
-		manifestPath := filepath.Join(absPath, "manifest.mf")
+		manifestPath := filepath.Join(absRoot, "manifest.mf")
 		if _, err := os.Stat(manifestPath); err != nil {
-			log.Warnf("workspace: skipping %q in %s: manifest.mf not found at %s", line, filePath, manifestPath)
+			if !isPattern {
+				log.Warnf("workspace: skipping %q in %s: manifest.mf not found at %s", entry, filePath, manifestPath)
+			}
 			continue
 		}
-
-		paths = append(paths, absPath)
+		pkgPaths = append(pkgPaths, root)
 	}
+	return pkgPaths
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
+// findWorkspacePackages walks a directory to find the package directories,
+// the hidden directories and the content of the packages are not searched
+func findWorkspacePackages(baseDir string, root string) []string {
+	pkgPaths := []string{}
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Warnf("workspace: cannot search packages in %s: %v", p, err)
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(p, "manifest.mf")); err == nil {
+			if rel, err := filepath.Rel(baseDir, p); err == nil {
+				pkgPaths = append(pkgPaths, filepath.ToSlash(rel))
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return pkgPaths
+}
 
-	return paths, nil
+// isIgnoredWorkspacePath checks if the package path, or one of its parent
+// directories, matches an ignore rule
+func isIgnoredWorkspacePath(relPath string, ignores []string) bool {
+	for p := relPath; p != "." && p != "/"; p = path.Dir(p) {
+		for _, pattern := range ignores {
+			if matched, _ := path.Match(pattern, p); matched {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // containsParentTraversal checks if a path contains ".." components.
@@ -219,6 +219,81 @@ func TestParseWorkspaceFile_AllowDotPaths(t *testing.T) {
 	assert.Len(t, paths, 2)
 }
 
+func TestParseWorkspaceFile_GlobPatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	createTestManifest(t, filepath.Join(tmpDir, "tools"), "build-tool")
+	createTestManifest(t, filepath.Join(tmpDir, "tools"), "deploy-tool")
+	createTestManifest(t, filepath.Join(tmpDir, "scripts"), "dev-helpers")
+	// a directory without manifest is skipped
+	assert.Nil(t, os.MkdirAll(filepath.Join(tmpDir, "tools", "docs"), 0755))
+
+	content := `tools/*/
+scripts/dev-*
+tools/build-tool
+`
+	dotFile := filepath.Join(tmpDir, WorkspacePackagesFileName(testAppName))
+	err := os.WriteFile(dotFile, []byte(content), 0644)
+	assert.Nil(t, err)
+
+	paths, err := ParseWorkspaceFile(dotFile)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpDir, "tools", "build-tool"),
+		filepath.Join(tmpDir, "tools", "deploy-tool"),
+		filepath.Join(tmpDir, "scripts", "dev-helpers"),
+	}, paths)
+}
+
+func TestParseWorkspaceFile_Recursive(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	createTestManifest(t, filepath.Join(tmpDir, "tools"), "build-tool")
+	createTestManifest(t, filepath.Join(tmpDir, "tools", "infra", "k8s"), "kube-tool")
+	createTestManifest(t, filepath.Join(tmpDir, "tools", "legacy"), "old-tool")
+	createTestManifest(t, filepath.Join(tmpDir, "tools", "experimental"), "new-tool")
+	// the hidden directories and the packages content are not searched
+	createTestManifest(t, filepath.Join(tmpDir, "tools", ".cache"), "cached-tool")
+	createTestManifest(t, filepath.Join(tmpDir, "tools", "build-tool"), "nested-tool")
+
+	content := `tools/**
+!tools/legacy
+!*/experimental/*
+`
+	dotFile := filepath.Join(tmpDir, WorkspacePackagesFileName(testAppName))
+	err := os.WriteFile(dotFile, []byte(content), 0644)
+	assert.Nil(t, err)
+
+	paths, err := ParseWorkspaceFile(dotFile)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpDir, "tools", "build-tool"),
+		filepath.Join(tmpDir, "tools", "infra", "k8s", "kube-tool"),
+	}, paths)
+}
+
+func TestParseWorkspaceFile_RejectTraversalInPatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	createTestManifest(t, filepath.Join(tmpDir, "outside"), "evil-pkg")
+	workspaceDir := filepath.Join(tmpDir, "workspace")
+	createTestManifest(t, filepath.Join(workspaceDir, "tools"), "my-tool")
+
+	content := `../outside/*
+../**
+` + filepath.Join(tmpDir, "outside") + `/**
+tools/**/deep
+tools/*
+`
+	dotFile := filepath.Join(workspaceDir, WorkspacePackagesFileName(testAppName))
+	err := os.WriteFile(dotFile, []byte(content), 0644)
+	assert.Nil(t, err)
+
+	paths, err := ParseWorkspaceFile(dotFile)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(workspaceDir, "tools", "my-tool")}, paths)
+}
+
 func TestContainsParentTraversal(t *testing.T) {
 	assert.True(t, containsParentTraversal("../foo"))
 	assert.True(t, containsParentTraversal("foo/../../bar"))
@@ -1,5 +1,7 @@
 package command
 
+import "time"
+
 type CommandInfo interface {
 	Name() string
 
@@ -68,9 +70,16 @@ type Command interface {
 	RuntimeName() string
 	// the package directory
 	PackageDir() string
+	// the executable after interpolation, ex: the package dir replaces #CACHE#
+	InterpolatedExecutable() string
+	// the arguments after interpolation
+	InterpolatedArguments() []string
 
 	Execute(envVars []string, args ...string) (int, error)
 
+	// execute the command, and kill it once the timeout is reached
+	ExecuteWithTimeout(envVars []string, timeout time.Duration, args ...string) (int, error)
+
 	ExecuteWithOutput(envVars []string, args ...string) (int, string, error)
 
 	ExecuteValidArgsCmd(envVars []string, args ...string) (int, string, error)
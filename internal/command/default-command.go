@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/criteo/command-launcher/internal/helper"
 	log "github.com/sirupsen/logrus"
@@ -120,13 +122,34 @@ func CmdReverseID(repo, pkg, group, name string) string {
 }
 
 func (cmd *DefaultCommand) Execute(envVars []string, args ...string) (int, error) {
+	return cmd.execute(context.Background(), envVars, args...)
+}
+
+// ExecuteWithTimeout executes the command like Execute, but kills it once the
+// timeout is reached. A timeout less or equal to zero means no time limit.
+func (cmd *DefaultCommand) ExecuteWithTimeout(envVars []string, timeout time.Duration, args ...string) (int, error) {
+	if timeout <= 0 {
+		return cmd.Execute(envVars, args...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	exitCode, err := cmd.execute(ctx, envVars, args...)
+	if ctx.Err() == context.DeadlineExceeded {
+		return exitCode, fmt.Errorf("command %s timed out after %s", cmd.CmdName, timeout)
+	}
+	return exitCode, err
+}
+
+func (cmd *DefaultCommand) execute(ctx context.Context, envVars []string, args ...string) (int, error) {
 	arguments := append(cmd.CmdArguments, args...)
 	cmd.interpolateArray(&arguments)
 	command := cmd.interpolateCmd()
 
 	log.Debug("Command line: ", command, " ", arguments)
 
-	proc := exec.Command(command, arguments...)
+	proc := exec.CommandContext(ctx, command, arguments...)
 	// inject additional environments
 	env := append(os.Environ(), envVars...)
 	proc.Env = env
@@ -321,6 +344,16 @@ func (cmd *DefaultCommand) Arguments() []string {
 	return cmd.CmdArguments
 }
 
+func (cmd *DefaultCommand) InterpolatedExecutable() string {
+	return cmd.interpolateCmd()
+}
+
+func (cmd *DefaultCommand) InterpolatedArguments() []string {
+	arguments := append([]string{}, cmd.Arguments()...)
+	cmd.interpolateArray(&arguments)
+	return arguments
+}
+
 func (cmd *DefaultCommand) DocFile() string {
 	return cmd.interpolate(cmd.CmdDocFile)
 }
@@ -225,6 +225,17 @@ func TestInterpolate(t *testing.T) {
 	assert.Equal(t, "/tmp/test/root/windows/x64/test.exe", cmd.doInterpolate("windows", "x64", "#CACHE#/#OS#/#ARCH#/test#EXT#"))
 }
 
+func TestInterpolatedExecutable(t *testing.T) {
+	cmd := getDefaultCommand()
+	cmd.CmdExecutable = "#CACHE#/bin/test"
+	cmd.CmdArguments = []string{"-c", "#CACHE#/config"}
+
+	assert.Equal(t, "/tmp/test/root/bin/test", cmd.InterpolatedExecutable())
+	assert.Equal(t, []string{"-c", "/tmp/test/root/config"}, cmd.InterpolatedArguments())
+	// the manifest is not changed
+	assert.Equal(t, "#CACHE#/config", cmd.Arguments()[1])
+}
+
 func TestRuntimeNameAndGroup(t *testing.T) {
 	cmd := getDefaultCommand()
 
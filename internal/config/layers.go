@@ -0,0 +1,234 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/console"
+	"github.com/spf13/viper"
+)
+
+// the configuration layers, from the lowest priority
+const (
+	DEFAULT_LAYER = "default"
+	SYSTEM_LAYER  = "system"
+	USER_LAYER    = "user"
+	PROFILE_LAYER = "profile"
+	PROJECT_LAYER = "project"
+	ENV_LAYER     = "env"
+	FLAG_LAYER    = "flag"
+)
+
+// ConfigLayer is a configuration file merged into the configuration
+type ConfigLayer struct {
+	Name string
+	File string
+	// the top level keys defined in the file, in lower case
+	Keys []string
+}
+
+// the values changed since the configuration is loaded, they are the only
+// ones written by WriteConfig, the other layers stay unchanged
+var changedValues = map[string]interface{}{}
+
+// the keys removed from the configuration file by WriteConfig
+var unsetKeys = map[string]bool{}
+
+// the keys set from the command line flags
+var flagKeys = map[string]bool{}
+
+// Metadata returns the configuration files loaded and the file where the
+// configuration changes are written
+func Metadata() ConfigMetadata {
+	return configMetadata
+}
+
+// Origin returns where the effective value of a configuration comes from:
+// a command line flag, an environment variable, a configuration file, or
+// the default value
+func Origin(key string) string {
+	lowerKey := strings.ToLower(key)
+	if flagKeys[lowerKey] {
+		return fmt.Sprintf("%s: --%s", FLAG_LAYER, SET_CONFIG_FLAG)
+	}
+	if os.Getenv(strings.ToUpper(key)) != "" {
+		return fmt.Sprintf("%s: %s", ENV_LAYER, strings.ToUpper(key))
+	}
+	for i := len(configMetadata.Layers) - 1; i >= 0; i-- {
+		layer := configMetadata.Layers[i]
+		for _, k := range layer.Keys {
+			if k == lowerKey {
+				return fmt.Sprintf("%s: %s", layer.Name, layer.File)
+			}
+		}
+	}
+	return DEFAULT_LAYER
+}
+
+// SET_CONFIG_FLAG overrides a configuration for one run, ex: --set-config log_level=debug
+const SET_CONFIG_FLAG = "set-config"
+
+// SetFlagValues overrides the configurations from the command line flags,
+// the values are in form of key=value, and are never written
+func SetFlagValues(values []string) error {
+	for _, value := range values {
+		key, v, found := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return fmt.Errorf("invalid config %s, must be in form of key=value", value)
+		}
+		if err := SetSettingValue(key, v); err != nil {
+			return fmt.Errorf("invalid config %s: %v", key, err)
+		}
+		delete(changedValues, strings.ToLower(key))
+		flagKeys[strings.ToLower(key)] = true
+	}
+	return nil
+}
+
+// WriteConfig writes the configuration changes to the configuration file,
+// the one of the active profile, or the user one by default
+func WriteConfig() error {
+	values, err := readTargetValues()
+	if err != nil {
+		return err
+	}
+
+	for key := range unsetKeys {
+		for k := range values {
+			if strings.EqualFold(k, key) {
+				delete(values, k)
+			}
+		}
+	}
+	for key, value := range changedValues {
+		for k := range values {
+			if strings.EqualFold(k, key) {
+				delete(values, k)
+			}
+		}
+		values[key] = value
+	}
+
+	payload, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configMetadata.File, payload, 0644); err != nil {
+		return err
+	}
+
+	changedValues = map[string]interface{}{}
+	unsetKeys = map[string]bool{}
+	return nil
+}
+
+// UnsetValue removes a configuration from the configuration file written
+// by WriteConfig, its value is inherited from the other layers
+func UnsetValue(key string) error {
+	if setting, found := FindSetting(key); found && setting.ManagedBy != "" {
+		return fmt.Errorf("config %s cannot be changed, it is managed by %s", strings.ToLower(setting.Key), setting.ManagedBy)
+	}
+	values, err := readTargetValues()
+	if err != nil {
+		return err
+	}
+	for k := range values {
+		if strings.EqualFold(k, key) {
+			unsetKeys[strings.ToLower(key)] = true
+			delete(changedValues, strings.ToLower(key))
+			return nil
+		}
+	}
+	return fmt.Errorf("config %s is not set in %s", strings.ToLower(key), configMetadata.File)
+}
+
+// ResetConfig removes the configurations from the configuration file written
+// by WriteConfig, except the ones managed by other commands, and the
+// repository of the active profile. Returns the removed keys.
+func ResetConfig() ([]string, error) {
+	values, err := readTargetValues()
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for k := range values {
+		if setting, found := FindSetting(k); found && setting.ManagedBy != "" {
+			continue
+		}
+		if activeProfile != "" && configMetadata.File == ProfileFile(activeProfile) && strings.EqualFold(k, LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY) {
+			continue
+		}
+		unsetKeys[strings.ToLower(k)] = true
+		delete(changedValues, strings.ToLower(k))
+		removed = append(removed, strings.ToLower(k))
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
+// readTargetValues reads the configuration file written by WriteConfig
+func readTargetValues() (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	payload, err := os.ReadFile(configMetadata.File)
+	if os.IsNotExist(err) {
+		return values, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(payload, &values); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", configMetadata.File, err)
+	}
+	return values, nil
+}
+
+func setValue(key string, value interface{}) {
+	viper.Set(key, value)
+	changedValues[strings.ToLower(key)] = value
+	delete(unsetKeys, strings.ToLower(key))
+	delete(flagKeys, strings.ToLower(key))
+}
+
+// systemConfigFile returns the configuration file shared by all users
+func systemConfigFile(appName string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), appName, "config.json")
+	}
+	return filepath.Join("/etc", appName, "config.json")
+}
+
+// readConfigLayer merges a configuration file, and records its keys
+func readConfigLayer(name string, file string) (ConfigLayer, error) {
+	layer := ConfigLayer{Name: name, File: file, Keys: []string{}}
+	payload, err := os.ReadFile(file)
+	if err != nil {
+		return layer, err
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &values); err != nil {
+		return layer, fmt.Errorf("invalid configuration file %s: %v", file, err)
+	}
+	if name == PROJECT_LAYER {
+		for k := range values {
+			if setting, found := FindSetting(k); found && setting.NotInProject {
+				console.Warn("config %s is ignored in the project configuration file %s\n", strings.ToLower(k), file)
+				delete(values, k)
+			}
+		}
+	}
+	for k := range values {
+		layer.Keys = append(layer.Keys, strings.ToLower(k))
+	}
+	sort.Strings(layer.Keys)
+
+	if err := viper.MergeConfigMap(values); err != nil {
+		return layer, err
+	}
+	return layer, nil
+}
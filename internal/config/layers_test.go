@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigLayers(t *testing.T) {
+	viper.Reset()
+	defer func() {
+		viper.Reset()
+		flagKeys = map[string]bool{}
+		changedValues = map[string]interface{}{}
+	}()
+	tmpDir := t.TempDir()
+	userFile := filepath.Join(tmpDir, "config.json")
+	projectFile := filepath.Join(tmpDir, "cdt.json")
+	assert.Nil(t, os.WriteFile(userFile, []byte(`{"log_level": "warn", "ci_enabled": true}`), 0644))
+	assert.Nil(t, os.WriteFile(projectFile, []byte(`{"LOG_LEVEL": "debug"}`), 0644))
+
+	viper.SetDefault(LOG_LEVEL_KEY, "fatal")
+	viper.SetDefault(LOG_ENABLED_KEY, false)
+	configMetadata = ConfigMetadata{File: userFile, Layers: []ConfigLayer{}}
+	for _, layer := range []ConfigLayer{{Name: USER_LAYER, File: userFile}, {Name: PROJECT_LAYER, File: projectFile}} {
+		loaded, err := readConfigLayer(layer.Name, layer.File)
+		assert.Nil(t, err)
+		configMetadata.Layers = append(configMetadata.Layers, loaded)
+	}
+
+	// the project file overrides the user file key by key
+	assert.Equal(t, "debug", viper.GetString(LOG_LEVEL_KEY))
+	assert.True(t, viper.GetBool(CI_ENABLED_KEY))
+	assert.Equal(t, "project: "+projectFile, Origin(LOG_LEVEL_KEY))
+	assert.Equal(t, "user: "+userFile, Origin(CI_ENABLED_KEY))
+	assert.Equal(t, DEFAULT_LAYER, Origin(LOG_ENABLED_KEY))
+
+	// the flags override all layers, and are never written
+	assert.Nil(t, SetFlagValues([]string{"log_level=info"}))
+	assert.Equal(t, "info", viper.GetString(LOG_LEVEL_KEY))
+	assert.Equal(t, "flag: --set-config", Origin(LOG_LEVEL_KEY))
+	assert.NotNil(t, SetFlagValues([]string{"log_level"}))
+	assert.NotNil(t, SetFlagValues([]string{"log_level=unknown"}))
+
+	// only the changed values are written to the user file
+	assert.Nil(t, SetSettingValue(LOG_ENABLED_KEY, "true"))
+	assert.Nil(t, WriteConfig())
+	written := viper.New()
+	written.SetConfigFile(userFile)
+	assert.Nil(t, written.ReadInConfig())
+	assert.Equal(t, "warn", written.GetString(LOG_LEVEL_KEY))
+	assert.True(t, written.GetBool(LOG_ENABLED_KEY))
+	assert.True(t, written.GetBool(CI_ENABLED_KEY))
+	assert.Equal(t, 3, len(written.AllKeys()))
+}
+
+func TestProjectLayerIgnoresProviders(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	tmpDir := t.TempDir()
+	userFile := filepath.Join(tmpDir, "config.json")
+	projectFile := filepath.Join(tmpDir, "cdt.json")
+	assert.Nil(t, os.WriteFile(userFile, []byte(`{"package_source_providers": {"bazel": "/opt/bazel-provider"}}`), 0644))
+	assert.Nil(t, os.WriteFile(projectFile, []byte(`{"PACKAGE_SOURCE_PROVIDERS": {"x": "./evil.sh"}, "log_level": "debug"}`), 0644))
+
+	_, err := readConfigLayer(USER_LAYER, userFile)
+	assert.Nil(t, err)
+	project, err := readConfigLayer(PROJECT_LAYER, projectFile)
+	assert.Nil(t, err)
+
+	// a repository cannot configure an executable to run
+	assert.Equal(t, map[string]string{"bazel": "/opt/bazel-provider"}, viper.GetStringMapString(PACKAGE_SOURCE_PROVIDERS_KEY))
+	assert.Equal(t, []string{"log_level"}, project.Keys)
+	assert.Equal(t, "debug", viper.GetString(LOG_LEVEL_KEY))
+}
+
+func TestInvalidConfigLayer(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	file := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(file, []byte(`{"log_level": `), 0644))
+
+	_, err := readConfigLayer(USER_LAYER, file)
+	assert.NotNil(t, err)
+}
+
+func TestUnsetAndResetConfig(t *testing.T) {
+	viper.Reset()
+	defer func() {
+		viper.Reset()
+		changedValues = map[string]interface{}{}
+		unsetKeys = map[string]bool{}
+	}()
+	userFile := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(userFile, []byte(`{"log_level": "warn", "ci_enabled": true, "extra_remotes": [], "unknown": 1}`), 0644))
+	configMetadata = ConfigMetadata{File: userFile, Layers: []ConfigLayer{}}
+
+	assert.Nil(t, UnsetValue("LOG_LEVEL"))
+	assert.NotNil(t, UnsetValue(LOG_ENABLED_KEY))
+	assert.NotNil(t, UnsetValue(EXTRA_REMOTES_KEY))
+	assert.Nil(t, WriteConfig())
+	values, err := readTargetValues()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(values))
+	assert.NotContains(t, values, "log_level")
+
+	removed, err := ResetConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ci_enabled", "unknown"}, removed)
+	assert.Nil(t, WriteConfig())
+	values, err = readTargetValues()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(values))
+	assert.Contains(t, values, "extra_remotes")
+}
+
+func TestRemotesWrittenToTargetFile(t *testing.T) {
+	viper.Reset()
+	defer func() {
+		viper.Reset()
+		changedValues = map[string]interface{}{}
+		unsetKeys = map[string]bool{}
+	}()
+	tmpDir := t.TempDir()
+	userFile := filepath.Join(tmpDir, "config.json")
+	projectFile := filepath.Join(tmpDir, "cdt.json")
+	assert.Nil(t, os.WriteFile(userFile, []byte(`{"extra_remotes": [{"name": "team", "remote_base_url": "https://team.example.com", "repository_dir": "/repo/team", "sync_policy": "always"}]}`), 0644))
+	assert.Nil(t, os.WriteFile(projectFile, []byte(`{"extra_remotes": [{"name": "project", "remote_base_url": "https://project.example.com", "repository_dir": "/repo/project", "sync_policy": "always"}]}`), 0644))
+	configMetadata = ConfigMetadata{File: userFile, Layers: []ConfigLayer{}}
+	for _, layer := range []ConfigLayer{{Name: USER_LAYER, File: userFile}, {Name: PROJECT_LAYER, File: projectFile}} {
+		loaded, err := readConfigLayer(layer.Name, layer.File)
+		assert.Nil(t, err)
+		configMetadata.Layers = append(configMetadata.Layers, loaded)
+	}
+
+	// the remotes of the project are not written to the user file
+	assert.Nil(t, AddRemote("other", "/repo/other", "https://other.example.com", "daily"))
+	assert.Nil(t, SetRemotePackageFilter("other", []string{"infra-*"}, []string{}))
+	assert.Nil(t, UpdateRemote("team", "weekly"))
+	assert.Nil(t, SetRemoteDisabled("team", true))
+	assert.NotNil(t, UpdateRemote("project", "weekly"))
+	assert.NotNil(t, SetRemoteDisabled("project", true))
+	assert.Nil(t, WriteConfig())
+
+	remotes, err := targetRemotes()
+	assert.Nil(t, err)
+	assert.Len(t, remotes, 2)
+	assert.Equal(t, ExtraRemote{Name: "team", RemoteBaseUrl: "https://team.example.com", RepositoryDir: "/repo/team", SyncPolicy: "weekly", Disabled: true}, remotes[0])
+	assert.Equal(t, "other", remotes[1].Name)
+	assert.Equal(t, []string{"infra-*"}, remotes[1].Include)
+
+	assert.Nil(t, RemoveRemote("team"))
+	assert.Nil(t, WriteConfig())
+	remotes, err = targetRemotes()
+	assert.Nil(t, err)
+	assert.Len(t, remotes, 1)
+	assert.Equal(t, "other", remotes[0].Name)
+}
@@ -1,10 +1,11 @@
 package config
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/criteo/command-launcher/internal/context"
@@ -19,112 +20,109 @@ const REMOTE_CONFIG_CHECK_CYCLE_KEY = "REMOTE_CONFIG_CHECK_CYCLE"
 
 // store some metadata about the configuration settings
 type ConfigMetadata struct {
+	// the file where the configuration changes are written
 	File   string
 	Reason string
+	// the configuration files merged, from the lowest priority
+	Layers []ConfigLayer
+	// the profile overlaying the base configuration, and why it is selected
+	Profile       string
+	ProfileReason string
 }
 
 var (
 	configMetadata = ConfigMetadata{}
 )
 
-func LoadConfig(appCtx context.LauncherContext) {
-	// NOTE: we don't put default value for the DEBUG_FLAGS configuration, it will not show in a newly created config file
-	// Please keep it as a hidden config, better not to let developer directly see this option
+// LoadConfig merges the configuration layers, from the lowest priority:
+// the default values, the system file, the user file in the app home, the
+// file of the profile, the nearest project file in the working dir or its
+// parents, the file of the configuration file environment variable, and the
+// environment variables. The command line flags are applied later by
+// SetFlagValues.
+//
+// The profile is the one of the --profile flag, of the profile environment
+// variable, or the current one.
+func LoadConfig(appCtx context.LauncherContext, profile string) {
+	profile, profileReason, err := selectProfile(profile, appCtx.ProfileEnvVar())
+	if err != nil {
+		log.Fatal("Cannot select the configuration profile: ", err)
+	}
+	activeProfile = profile
+	configMetadata.Profile = profile
+	configMetadata.ProfileReason = profileReason
+
 	setDefaultConfig()
+
 	wd, _ := os.Getwd()
 	cfgFile := os.Getenv(appCtx.ConfigurationFileEnvVar())
+	systemCfgFile := os.Getenv(appCtx.SystemConfigurationFileEnvVar())
+	if systemCfgFile == "" {
+		systemCfgFile = systemConfigFile(appCtx.AppName())
+	}
 	localCftFileName := fmt.Sprintf("%s.json", appCtx.AppName())
 	appDir := AppDir()
+	userCfgFile := filepath.Join(appDir, "config.json")
+
+	layers := []ConfigLayer{
+		{Name: SYSTEM_LAYER, File: systemCfgFile},
+		{Name: USER_LAYER, File: userCfgFile},
+	}
+	if profile != "" {
+		layers = append(layers, ConfigLayer{Name: PROFILE_LAYER, File: ProfileFile(profile)})
+	}
+	if localCfgFile, found := findLocalConfig(wd, localCftFileName); found {
+		layers = append(layers, ConfigLayer{Name: PROJECT_LAYER, File: localCfgFile})
+	}
+
 	if cfgFile != "" {
 		configMetadata.Reason = fmt.Sprintf("from environment variable: %s", appCtx.ConfigurationFileEnvVar())
 		configMetadata.File = cfgFile
-
-		viper.SetConfigFile(cfgFile)
-	} else if localCfgFile, found := findLocalConfig(wd, localCftFileName); found {
-		configMetadata.Reason = fmt.Sprintf("found config file from working dir or its parents: %s", localCfgFile)
-		configMetadata.File = localCfgFile
-
-		viper.SetConfigFile(localCfgFile)
+		layers = append(layers, ConfigLayer{Name: ENV_LAYER, File: cfgFile})
+	} else if profile != "" {
+		configMetadata.Reason = fmt.Sprintf("use the file of profile %s", profile)
+		configMetadata.File = ProfileFile(profile)
 	} else {
 		configMetadata.Reason = fmt.Sprintf("use default config file from app home %s", appDir)
-		configMetadata.File = fmt.Sprintf("%s/config.json", appDir)
-
-		viper.AddConfigPath(appDir)
-		viper.SetConfigType("json")
-		viper.SetConfigName("config")
+		configMetadata.File = userCfgFile
 	}
 
-	viper.AutomaticEnv()
+	if _, err := os.Stat(configMetadata.File); os.IsNotExist(err) {
+		initDefaultConfigFile()
+	}
 
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			initDefaultConfigFile()
-		} else {
+	viper.SetConfigFile(configMetadata.File)
+	viper.SetConfigType("json")
+	configMetadata.Layers = []ConfigLayer{}
+	for _, layer := range layers {
+		if _, err := os.Stat(layer.File); os.IsNotExist(err) {
+			continue
+		}
+		loaded, err := readConfigLayer(layer.Name, layer.File)
+		if err != nil {
 			log.Fatal("Cannot read configuration file: ", err)
 		}
+		configMetadata.Layers = append(configMetadata.Layers, loaded)
 	}
+
+	viper.AutomaticEnv()
+
 	// load remote config first
 	remoteConfigLoaded := loadRemoteConfig(appCtx)
 
 	if remoteConfigLoaded {
 		log.Info("Remote Configuration loaded...")
-		viper.WriteConfig()
+		WriteConfig()
 	}
 
 }
 
-func setDefaultConfig() {
-	appDir := AppDir()
-	viper.SetDefault(LOG_ENABLED_KEY, false)
-	viper.SetDefault(LOG_LEVEL_KEY, "fatal") // trace, debug, info, warn, error, fatal, panic
-
-	viper.SetDefault(SELF_UPDATE_ENABLED_KEY, false)
-	viper.SetDefault(SELF_UPDATE_TIMEOUT_KEY, 2*time.Second) // In seconds
-	viper.SetDefault(SELF_UPDATE_LATEST_VERSION_URL_KEY, "")
-	viper.SetDefault(SELF_UPDATE_BASE_URL_KEY, "")
-
-	viper.SetDefault(COMMAND_UPDATE_ENABLED_KEY, false)
-	viper.SetDefault(COMMAND_REPOSITORY_BASE_URL_KEY, "")
-
-	viper.SetDefault(DROPIN_FOLDER_KEY, filepath.Join(appDir, "dropins"))
-	viper.SetDefault(LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY, filepath.Join(appDir, "current"))
-
-	viper.SetDefault(USAGE_METRICS_ENABLED_KEY, false)
-	viper.SetDefault(METRIC_GRAPHITE_HOST_KEY, "dummy")
-
-	viper.SetDefault(INTERNAL_COMMAND_ENABLED_KEY, false)
-	viper.SetDefault(EXPERIMENTAL_COMMAND_ENABLED_KEY, false)
-	// set default remote config time to now, so that the first run it will always check
-	viper.SetDefault(REMOTE_CONFIG_CHECK_TIME_KEY, time.Now())
-	// set default remote config check cycle to 24 hours
-	viper.SetDefault(REMOTE_CONFIG_CHECK_CYCLE_KEY, 24)
-
-	viper.SetDefault(CI_ENABLED_KEY, false)
-	viper.SetDefault(PACKAGE_LOCK_FILE_KEY, filepath.Join(appDir, "lock.json"))
-
-	viper.SetDefault(ENABLE_USER_CONSENT_KEY, false)
-	viper.SetDefault(USER_CONSENT_LIFE_KEY, 7*24*time.Hour)
-
-	viper.SetDefault(SYSTEM_PACKAGE_KEY, "")
-	viper.SetDefault(SYSTEM_PACKAGE_PUBLIC_KEY_KEY, "")
-	viper.SetDefault(SYSTEM_PACKAGE_PUBLIC_KEY_FILE_KEY, "")
-
-	viper.SetDefault(VERIFY_PACKAGE_CHECKSUM_KEY, false)
-	viper.SetDefault(VERIFY_PACKAGE_SIGNATURE_KEY, false)
-
-	viper.SetDefault(ENABLE_WORKSPACE_PACKAGES_KEY, false)
-
-	viper.SetDefault(EXTRA_REMOTES_KEY, []map[string]string{})
-	viper.SetDefault(ENABLE_PACKAGE_SETUP_HOOK_KEY, false)
-
-	// by default, group the top level command by registry in the help message
-	viper.SetDefault(GROUP_HELP_BY_REGISTRY_KEY, true)
-}
-
+// initDefaultConfigFile creates an empty configuration file, the default
+// values are not written to let the system configuration apply
 func initDefaultConfigFile() {
 	log.Info("Create default config file")
 	createAppDir()
-	if err := viper.SafeWriteConfig(); err != nil {
+	if err := os.WriteFile(configMetadata.File, []byte("{}\n"), 0644); err != nil {
 		log.Error("cannot write the default configuration: ", err)
 	}
 }
@@ -167,15 +165,25 @@ func loadRemoteConfig(appCtx context.LauncherContext) bool {
 			return false
 		}
 
-		viper.Set(REMOTE_CONFIG_CHECK_TIME_KEY, time.Now().Add(time.Duration(checkCycle)*time.Hour))
-		viper.Set(REMOTE_CONFIG_CHECK_CYCLE_KEY, checkCycle)
+		setValue(REMOTE_CONFIG_CHECK_TIME_KEY, time.Now().Add(time.Duration(checkCycle)*time.Hour))
+		setValue(REMOTE_CONFIG_CHECK_CYCLE_KEY, checkCycle)
 		data, err := helper.LoadFile(urlCfg)
 		if err != nil {
 			return false
 		}
 
-		err = viper.MergeConfig(bytes.NewReader(data))
-		return err == nil
+		// the remote config is written to the configuration file
+		values := map[string]interface{}{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return false
+		}
+		if err := viper.MergeConfigMap(values); err != nil {
+			return false
+		}
+		for k, v := range values {
+			changedValues[strings.ToLower(k)] = v
+		}
+		return true
 	}
 
 	return false
@@ -41,6 +41,12 @@ func InitLog(prefix string) {
 		}
 	}
 	log.Debugf("Config file is loaded from %s, reason: %s", configMetadata.File, configMetadata.Reason)
+	if configMetadata.Profile != "" {
+		log.Debugf("Config profile %s, reason: %s", configMetadata.Profile, configMetadata.ProfileReason)
+	}
+	for _, layer := range configMetadata.Layers {
+		log.Debugf("Config layer %s merged from %s", layer.Name, layer.File)
+	}
 }
 
 func logFilename(prefix string) string {
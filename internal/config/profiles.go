@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// the folder of the profile files, and of their repositories
+	PROFILES_DIR = "profiles"
+	// the file storing the profile used by default
+	CURRENT_PROFILE_FILE = "current-profile"
+	// the name of the base configuration, without profile
+	DEFAULT_PROFILE = "default"
+	// PROFILE_FLAG selects the profile of one run, ex: --profile sandbox
+	PROFILE_FLAG = "profile"
+)
+
+var profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// the profile of the configuration, empty for the base configuration
+var activeProfile = ""
+
+// ActiveProfile returns the profile overlaying the base configuration,
+// empty when no profile is used
+func ActiveProfile() string {
+	return activeProfile
+}
+
+// ProfileHome returns the folder of the repositories of the active profile,
+// the app home without profile
+func ProfileHome() string {
+	if activeProfile == "" {
+		return AppDir()
+	}
+	return filepath.Join(AppDir(), PROFILES_DIR, activeProfile)
+}
+
+// ProfileFile returns the configuration file of a profile
+func ProfileFile(name string) string {
+	return filepath.Join(AppDir(), PROFILES_DIR, name+".json")
+}
+
+// Profiles returns the names of the profiles in alphabet order
+func Profiles() []string {
+	names := []string{}
+	entries, err := os.ReadDir(filepath.Join(AppDir(), PROFILES_DIR))
+	if err != nil {
+		return names
+	}
+	for _, entry := range entries {
+		if name, found := strings.CutSuffix(entry.Name(), ".json"); found && !entry.IsDir() && IsValidProfileName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsValidProfileName checks if a name can be used as profile name
+func IsValidProfileName(name string) bool {
+	return name != DEFAULT_PROFILE && profileNamePattern.MatchString(name)
+}
+
+// ProfileExists checks if a profile has been created
+func ProfileExists(name string) bool {
+	_, err := os.Stat(ProfileFile(name))
+	return err == nil
+}
+
+// CreateProfile creates a profile overlaying the base configuration, or
+// copying another profile. The profile has its own repository folder.
+func CreateProfile(name string, from string) error {
+	if !IsValidProfileName(name) {
+		return fmt.Errorf("invalid profile name %s, it must only contain letters, digits, '-' and '_', and cannot be '%s'", name, DEFAULT_PROFILE)
+	}
+	if ProfileExists(name) {
+		return fmt.Errorf("profile '%s' already exists", name)
+	}
+
+	values := map[string]interface{}{}
+	if from != "" {
+		payload, err := os.ReadFile(ProfileFile(from))
+		if err != nil {
+			return fmt.Errorf("profile '%s' not found", from)
+		}
+		if err := json.Unmarshal(payload, &values); err != nil {
+			return fmt.Errorf("invalid profile '%s': %v", from, err)
+		}
+	}
+	values[strings.ToLower(LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY)] = filepath.Join(AppDir(), PROFILES_DIR, name, "current")
+
+	payload, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(AppDir(), PROFILES_DIR), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(ProfileFile(name), payload, 0644)
+}
+
+// UseProfile selects the profile used by default, the default profile
+// selects the base configuration
+func UseProfile(name string) error {
+	file := filepath.Join(AppDir(), CURRENT_PROFILE_FILE)
+	if name == DEFAULT_PROFILE {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	return os.WriteFile(file, []byte(name+"\n"), 0644)
+}
+
+// CurrentProfile returns the profile used by default, empty for the base
+// configuration
+func CurrentProfile() string {
+	payload, err := os.ReadFile(filepath.Join(AppDir(), CURRENT_PROFILE_FILE))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(payload))
+}
+
+// DeleteProfile removes a profile and its repositories, the base
+// configuration is used by default when it was the current profile
+func DeleteProfile(name string) error {
+	if !IsValidProfileName(name) || !ProfileExists(name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	if err := os.Remove(ProfileFile(name)); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(AppDir(), PROFILES_DIR, name)); err != nil {
+		return err
+	}
+	if CurrentProfile() == name {
+		return UseProfile(DEFAULT_PROFILE)
+	}
+	return nil
+}
+
+// selectProfile returns the profile of the flag, of the environment
+// variable, or the current one, and the reason of the selection
+func selectProfile(flagProfile string, envVar string) (string, string, error) {
+	name, reason := flagProfile, fmt.Sprintf("from flag: --%s", PROFILE_FLAG)
+	if name == "" {
+		name, reason = os.Getenv(envVar), fmt.Sprintf("from environment variable: %s", envVar)
+	}
+	if name == "" {
+		if name = CurrentProfile(); name == "" || !ProfileExists(name) {
+			return "", "", nil
+		}
+		return name, "current profile", nil
+	}
+	if name == DEFAULT_PROFILE {
+		return "", "", nil
+	}
+	if !ProfileExists(name) {
+		return "", "", fmt.Errorf("profile '%s' not found", name)
+	}
+	return name, reason, nil
+}
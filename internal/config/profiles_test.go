@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfiles(t *testing.T) {
+	appCtx := context.InitContext("test-profile", "1.0.0", "1")
+	appDir := t.TempDir()
+	t.Setenv(appCtx.AppHomeEnvVar(), appDir)
+
+	assert.Nil(t, CreateProfile("sandbox", ""))
+	assert.NotNil(t, CreateProfile("sandbox", ""))
+	assert.NotNil(t, CreateProfile(DEFAULT_PROFILE, ""))
+	assert.NotNil(t, CreateProfile("../prod", ""))
+	assert.NotNil(t, CreateProfile("prod", "unknown"))
+	assert.Nil(t, CreateProfile("prod", "sandbox"))
+	assert.Equal(t, []string{"prod", "sandbox"}, Profiles())
+
+	// each profile has its own repository
+	layer, err := readConfigLayer(PROFILE_LAYER, ProfileFile("prod"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"local_command_repository_dirname"}, layer.Keys)
+
+	profile, _, err := selectProfile("", appCtx.ProfileEnvVar())
+	assert.Nil(t, err)
+	assert.Equal(t, "", profile)
+
+	assert.Nil(t, UseProfile("sandbox"))
+	assert.NotNil(t, UseProfile("unknown"))
+	profile, reason, err := selectProfile("", appCtx.ProfileEnvVar())
+	assert.Nil(t, err)
+	assert.Equal(t, "sandbox", profile)
+	assert.Equal(t, "current profile", reason)
+
+	// the environment variable and the flag override the current profile
+	t.Setenv(appCtx.ProfileEnvVar(), "prod")
+	profile, _, err = selectProfile("", appCtx.ProfileEnvVar())
+	assert.Nil(t, err)
+	assert.Equal(t, "prod", profile)
+	profile, _, err = selectProfile(DEFAULT_PROFILE, appCtx.ProfileEnvVar())
+	assert.Nil(t, err)
+	assert.Equal(t, "", profile)
+	_, _, err = selectProfile("unknown", appCtx.ProfileEnvVar())
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(appDir, PROFILES_DIR, "sandbox", "current"), 0755))
+	assert.Nil(t, DeleteProfile("sandbox"))
+	assert.NotNil(t, DeleteProfile("sandbox"))
+	assert.Equal(t, []string{"prod"}, Profiles())
+	assert.Equal(t, "", CurrentProfile())
+	_, err = os.Stat(filepath.Join(appDir, PROFILES_DIR, "sandbox"))
+	assert.True(t, os.IsNotExist(err))
+}
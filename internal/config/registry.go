@@ -0,0 +1,392 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/criteo/command-launcher/internal/helper"
+	"github.com/spf13/viper"
+)
+
+// the types of the settings
+const (
+	BOOL_SETTING      = "bool"
+	STRING_SETTING    = "string"
+	INT_SETTING       = "int"
+	DURATION_SETTING  = "duration"
+	SIZE_SETTING      = "size"
+	ENUM_SETTING      = "enum"
+	LIST_SETTING      = "list"      // comma separated values, ex: "USERNAME,LOG_LEVEL"
+	GLOB_LIST_SETTING = "glob-list" // comma separated glob patterns, ex: "infra-*,hotfix"
+	MAP_SETTING       = "map"       // comma separated key=value pairs, ex: "hotfix=team-remote,deploy=default"
+	TIME_SETTING      = "time"
+	REMOTES_SETTING   = "remotes"
+)
+
+// Setting describes a configuration entry
+type Setting struct {
+	Key  string
+	Type string
+	// the default value, no default value when nil
+	Default interface{}
+	// the default value depending on the app home, replaces Default
+	DefaultFunc func() interface{}
+	Description string
+	// the values of an enum setting
+	Values []string
+	// additional validation of the value, after the type validation
+	Validate func(value string) error
+	// the value is masked when printed
+	Secret bool
+	// the setting is changed by command launcher or by another command,
+	// not by the config command
+	ManagedBy string
+	// the setting is ignored in the project configuration files, which come
+	// with the repositories, ex: a setting running an executable
+	NotInProject bool
+}
+
+// Settings is the registry of the configuration entries, it drives the
+// config command, its completion, the default values and the documentation
+var Settings = []Setting{
+	{Key: LOG_ENABLED_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether log is enabled or not"},
+	{Key: LOG_LEVEL_KEY, Type: ENUM_SETTING, Default: "fatal",
+		Values:      []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"},
+		Description: "the log level of command launcher. Note, the managed command could also request access to this config"},
+	{Key: SELF_UPDATE_ENABLED_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether auto update command launcher itself"},
+	{Key: SELF_UPDATE_TIMEOUT_KEY, Type: DURATION_SETTING, Default: 2 * time.Second, Validate: positiveDuration,
+		Description: "timeout duration for self update"},
+	{Key: SELF_UPDATE_LATEST_VERSION_URL_KEY, Type: STRING_SETTING, Default: "", Validate: validUrl,
+		Description: "url to get the latest command launcher version information"},
+	{Key: SELF_UPDATE_BASE_URL_KEY, Type: STRING_SETTING, Default: "", Validate: validUrl,
+		Description: "base url to get command launcher binaries"},
+	{Key: COMMAND_UPDATE_ENABLED_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether auto update managed commands or not"},
+	{Key: COMMAND_REPOSITORY_BASE_URL_KEY, Type: STRING_SETTING, Default: "", Validate: validUrlOrPath,
+		Description: "the base url of the remote repository, or the absolute path of a local folder, it must contain a `/index.json` endpoint to list the available packages"},
+	{Key: LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY, Type: STRING_SETTING,
+		DefaultFunc: func() interface{} { return filepath.Join(ProfileHome(), "current") },
+		Description: "the absolute path of the local repository folder, default: the `current` folder of the app home, or of the profile"},
+	{Key: USAGE_METRICS_ENABLED_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether enable metrics"},
+	{Key: METRIC_GRAPHITE_HOST_KEY, Type: STRING_SETTING, Default: "dummy",
+		Description: "graphite url for metrics"},
+	// NOTE: we don't put default value for the DEBUG_FLAGS configuration, it will not show in a newly created config file
+	// Please keep it as a hidden config, better not to let developer directly see this option
+	{Key: DEBUG_FLAGS_KEY, Type: STRING_SETTING,
+		Description: "the debug flags passed to the managed commands"},
+	{Key: DROPIN_FOLDER_KEY, Type: STRING_SETTING,
+		DefaultFunc: func() interface{} { return filepath.Join(AppDir(), "dropins") },
+		Description: "the absolute path of the dropin folder, default: the `dropins` folder of the app home"},
+	{Key: CI_ENABLED_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether the CI mode is enabled or not"},
+	{Key: PACKAGE_LOCK_FILE_KEY, Type: STRING_SETTING,
+		DefaultFunc: func() interface{} { return filepath.Join(AppDir(), "lock.json") },
+		Description: "only available for CI mode (ci_enabled = true). Lock the package version for CI purpose"},
+	{Key: INTERNAL_COMMAND_ENABLED_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether enable internal command or not"},
+	{Key: EXPERIMENTAL_COMMAND_ENABLED_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether enable experimental command or not"},
+	{Key: ENABLE_USER_CONSENT_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether enable the user consent. Be caution, when set to false, all resources are allowed to pass to the managed commands."},
+	{Key: USER_CONSENT_LIFE_KEY, Type: DURATION_SETTING, Default: 7 * 24 * time.Hour, Validate: positiveDuration,
+		Description: "the life of user consent"},
+	{Key: USER_CONSENT_POLICY_KEY, Type: ENUM_SETTING, Default: CONSENT_POLICY_DENY, Values: ValidConsentPolicies(),
+		Description: "how the resources without consent are handled in a non-interactive session"},
+	{Key: USER_CONSENT_ALLOWED_RESOURCES_KEY, Type: LIST_SETTING, Default: "",
+		Description: "comma separated resources granted in a non-interactive session with the `allow-list` policy, ex: `USERNAME,LOG_LEVEL`"},
+	{Key: SYSTEM_PACKAGE_KEY, Type: STRING_SETTING, Default: "",
+		Description: "the system package name"},
+	{Key: SYSTEM_PACKAGE_PUBLIC_KEY_KEY, Type: STRING_SETTING, Default: "",
+		Description: "the public key to verify the system package signature"},
+	{Key: SYSTEM_PACKAGE_PUBLIC_KEY_FILE_KEY, Type: STRING_SETTING, Default: "",
+		Description: "the public key file to verify the system package signature"},
+	{Key: VERIFY_PACKAGE_CHECKSUM_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether to verify the package checksum during package installation"},
+	{Key: VERIFY_PACKAGE_SIGNATURE_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "whether to verify the package signature during package installation (will be available in 1.8)"},
+	{Key: EXTRA_REMOTES_KEY, Type: REMOTES_SETTING, Default: []map[string]string{}, ManagedBy: "the remote command",
+		Description: "extra remote registry configurations, see extra remote configuration (available 1.8+)"},
+	{Key: ENABLE_PACKAGE_SETUP_HOOK_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "call setup hook after a new version of package is installed (available 1.9+)"},
+	{Key: ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "call the package lifecycle hooks during update and uninstall (available 1.16+)"},
+	{Key: PACKAGE_HOOK_TIMEOUT_KEY, Type: DURATION_SETTING, Default: 5 * time.Minute, Validate: positiveDuration,
+		Description: "timeout of a package hook execution (available 1.16+)"},
+	// by default, group the top level command by registry in the help message
+	{Key: GROUP_HELP_BY_REGISTRY_KEY, Type: BOOL_SETTING, Default: true,
+		Description: "group help by registry (available 1.13+)"},
+	{Key: ENABLE_WORKSPACE_PACKAGES_KEY, Type: BOOL_SETTING, Default: false,
+		Description: "enable or disable workspace package discovery (available 1.15+)"},
+	// limits of the package archive extraction, 0 means no limit
+	{Key: PACKAGE_MAX_EXTRACT_SIZE_KEY, Type: SIZE_SETTING, Default: int64(1 << 30),
+		Description: "max size of the extracted content of a package archive, ex: 512MB, 0 for no limit"},
+	{Key: PACKAGE_MAX_FILE_COUNT_KEY, Type: INT_SETTING, Default: 10000,
+		Description: "max number of entries in a package archive, 0 for no limit"},
+	// by default: workspace > dropin > default > extra remotes
+	{Key: SOURCE_PRIORITY_KEY, Type: STRING_SETTING, Default: "",
+		Description: "comma separated package sources, from the highest priority, see command conflicts"},
+	{Key: COMMAND_PREFER_RULES_KEY, Type: MAP_SETTING, Default: map[string]string{},
+		Description: "preferred package source of top level commands, ex: `hotfix=remote1,infra=default`, see command conflicts"},
+	// by default, install all packages of the default remote
+	{Key: COMMAND_REPOSITORY_INCLUDE_KEY, Type: GLOB_LIST_SETTING, Default: "",
+		Description: "comma separated glob patterns of the packages to install from the default remote, ex: `infra-*,hello`, see package filters"},
+	{Key: COMMAND_REPOSITORY_EXCLUDE_KEY, Type: GLOB_LIST_SETTING, Default: "",
+		Description: "comma separated glob patterns of the packages not to install from the default remote, ex: `*-experimental`"},
+	// no external package source by default
+	{Key: PACKAGE_SOURCE_PROVIDERS_KEY, Type: MAP_SETTING, Default: map[string]string{}, NotInProject: true,
+		Description: "command line of the external package source providers, by source name, ex: `bazel=/opt/tools/bazel-cola-provider`, see [external package sources](../external-sources). It is ignored in a project configuration file"},
+	{Key: PACKAGE_SOURCE_PROVIDER_TIMEOUT_KEY, Type: DURATION_SETTING, Default: 10 * time.Second, Validate: positiveDuration,
+		Description: "timeout of an external package source provider call"},
+	{Key: PACKAGE_SOURCE_PROVIDER_CACHE_KEY, Type: DURATION_SETTING, Default: 10 * time.Minute, Validate: nonNegativeDuration,
+		Description: "how long the output of an external package source provider is reused before calling it again, 0 to call it on each run"},
+	// set default remote config check cycle to 24 hours
+	{Key: REMOTE_CONFIG_CHECK_CYCLE_KEY, Type: INT_SETTING, Default: 24,
+		Description: "interval in hours to check the remote config"},
+	// set default remote config time to now, so that the first run it will always check
+	{Key: REMOTE_CONFIG_CHECK_TIME_KEY, Type: TIME_SETTING, DefaultFunc: func() interface{} { return time.Now() }, ManagedBy: "command launcher",
+		Description: "next remote config check time. This configuration is set automatically by command launcher, you shouldn't change it manually."},
+}
+
+// FindSetting returns the setting of a configuration key, case insensitive
+func FindSetting(key string) (Setting, bool) {
+	for _, s := range Settings {
+		if strings.EqualFold(s.Key, key) {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// DefaultValue returns the default value of the setting, nil when it has none
+func (s Setting) DefaultValue() interface{} {
+	if s.DefaultFunc != nil {
+		return s.DefaultFunc()
+	}
+	return s.Default
+}
+
+// DisplayValue returns the printable value of the setting
+func (s Setting) DisplayValue(value interface{}) interface{} {
+	if s.Secret && value != nil && fmt.Sprintf("%v", value) != "" {
+		return "********"
+	}
+	return value
+}
+
+// TypeName returns the type of the setting, with its values for an enum
+func (s Setting) TypeName() string {
+	if s.Type == ENUM_SETTING {
+		return fmt.Sprintf("%s (%s)", s.Type, strings.Join(s.Values, ", "))
+	}
+	return s.Type
+}
+
+// Parse converts a string value to the value of the setting type, and
+// validates it
+func (s Setting) Parse(value string) (interface{}, error) {
+	if s.ManagedBy != "" {
+		return nil, fmt.Errorf("config %s cannot be changed, it is managed by %s", strings.ToLower(s.Key), s.ManagedBy)
+	}
+
+	var parsed interface{}
+	var err error
+	switch s.Type {
+	case BOOL_SETTING:
+		parsed, err = parseBoolean(value)
+	case DURATION_SETTING:
+		parsed, err = parseDuration(value)
+	case SIZE_SETTING:
+		parsed, err = helper.ParseSize(value)
+	case INT_SETTING:
+		parsed, err = parseInteger(value)
+	case ENUM_SETTING:
+		parsed, err = parseEnum(value, s.Values)
+	case GLOB_LIST_SETTING:
+		parsed, err = parseGlobList(value)
+	case MAP_SETTING:
+		parsed, err = parseStringMap(value)
+	case STRING_SETTING, LIST_SETTING:
+		parsed = value
+	default:
+		err = fmt.Errorf("unsupported type %s", s.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Validate != nil {
+		if err := s.Validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// MarkdownTable returns the documentation of the settings, the managed
+// ones included
+func MarkdownTable() string {
+	rows := [][]string{{"Config Name", "Type", "Default", "Description"}}
+	for _, s := range Settings {
+		if s.Key == DEBUG_FLAGS_KEY {
+			// hidden config
+			continue
+		}
+		def := "-"
+		if s.DefaultFunc == nil && s.Default != nil {
+			def = fmt.Sprintf("`%v`", s.Default)
+			switch v := s.Default.(type) {
+			case string:
+				if v == "" {
+					def = "-"
+				}
+			case map[string]string, []map[string]string:
+				def = "-"
+			}
+		}
+		rows = append(rows, []string{strings.ToLower(s.Key), s.TypeName(), def, s.Description})
+	}
+
+	widths := make([]int, 4)
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	lines := []string{}
+	for i, row := range rows {
+		cells := []string{}
+		for j, cell := range row {
+			cells = append(cells, fmt.Sprintf("%-*s", widths[j], cell))
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			separators := []string{}
+			for _, w := range widths {
+				separators = append(separators, strings.Repeat("-", w+2))
+			}
+			lines = append(lines, "|"+strings.Join(separators, "|")+"|")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func setDefaultConfig() {
+	for _, s := range Settings {
+		if value := s.DefaultValue(); value != nil {
+			viper.SetDefault(s.Key, value)
+		}
+	}
+}
+
+func parseBoolean(value string) (bool, error) {
+	if value == "true" {
+		return true, nil
+	} else if value == "false" {
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid format for boolean type")
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid format for duration type, ex: 30s, 5m, 24h")
+	}
+	return d, nil
+}
+
+func parseInteger(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid format for positive integer type")
+	}
+	return n, nil
+}
+
+func parseEnum(value string, values []string) (string, error) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	for _, v := range values {
+		if lower == v {
+			return lower, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value %s, expected one of: %s", value, strings.Join(values, ", "))
+}
+
+// parseGlobList validates comma separated glob patterns, ex: "infra-*,hotfix"
+func parseGlobList(value string) (string, error) {
+	for _, pattern := range strings.Split(value, ",") {
+		if _, err := path.Match(strings.TrimSpace(pattern), ""); err != nil {
+			return "", fmt.Errorf("invalid glob pattern %s: %v", pattern, err)
+		}
+	}
+	return value, nil
+}
+
+// parseStringMap parses a map from comma separated key=value pairs,
+// ex: "hotfix=team-remote,deploy=default", an empty value is an empty map
+func parseStringMap(value string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, found := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !found || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid format for map type, expected key1=value1,key2=value2")
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func positiveDuration(value string) error {
+	if d, _ := time.ParseDuration(value); d <= 0 {
+		return fmt.Errorf("invalid duration %s, it must be positive", value)
+	}
+	return nil
+}
+
+func nonNegativeDuration(value string) error {
+	if d, _ := time.ParseDuration(value); d < 0 {
+		return fmt.Errorf("invalid duration %s, it must not be negative", value)
+	}
+	return nil
+}
+
+// validUrl accepts an empty value, or an absolute url
+func validUrl(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return fmt.Errorf("invalid url %s", value)
+	}
+	return nil
+}
+
+// validUrlOrPath accepts an empty value, an absolute url, or the absolute
+// path of a local folder, like the remote repositories loaded from the disk
+func validUrlOrPath(value string) error {
+	if filepath.IsAbs(value) {
+		return nil
+	}
+	if err := validUrl(value); err != nil {
+		return fmt.Errorf("invalid url or absolute path %s", value)
+	}
+	return nil
+}
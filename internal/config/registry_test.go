@@ -0,0 +1,106 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsRegistry(t *testing.T) {
+	keys := map[string]bool{}
+	for _, s := range Settings {
+		assert.False(t, keys[s.Key], "duplicated setting %s", s.Key)
+		keys[s.Key] = true
+		assert.NotEmpty(t, s.Description, s.Key)
+		assert.NotEmpty(t, s.Type, s.Key)
+	}
+	for _, key := range []string{SYSTEM_PACKAGE_PUBLIC_KEY_KEY, VERIFY_PACKAGE_CHECKSUM_KEY, VERIFY_PACKAGE_SIGNATURE_KEY, REMOTE_CONFIG_CHECK_CYCLE_KEY} {
+		assert.Contains(t, SettingKeys, key)
+	}
+	assert.NotContains(t, SettingKeys, EXTRA_REMOTES_KEY)
+	assert.NotContains(t, SettingKeys, REMOTE_CONFIG_CHECK_TIME_KEY)
+
+	setting, found := FindSetting("log_level")
+	assert.True(t, found)
+	assert.Equal(t, LOG_LEVEL_KEY, setting.Key)
+	_, found = FindSetting("unknown")
+	assert.False(t, found)
+}
+
+func TestParseSetting(t *testing.T) {
+	valid := map[string]interface{}{
+		"LOG_ENABLED=true":                         true,
+		"LOG_LEVEL=DEBUG":                          "debug",
+		"USER_CONSENT_LIFE=24h":                    24 * time.Hour,
+		"PACKAGE_MAX_EXTRACT_SIZE=512MB":           int64(512 << 20),
+		"PACKAGE_MAX_FILE_COUNT=0":                 0,
+		"COMMAND_PREFER_RULES=hotfix=r1, infra=d":  map[string]string{"hotfix": "r1", "infra": "d"},
+		"COMMAND_REPOSITORY_INCLUDE=infra-*,hello": "infra-*,hello",
+		"COMMAND_REPOSITORY_BASE_URL=":             "",
+		"COMMAND_REPOSITORY_BASE_URL=file:///r":    "file:///r",
+		"SELF_UPDATE_BASE_URL=https://example.com": "https://example.com",
+	}
+	for entry, expected := range valid {
+		key, value, _ := strings.Cut(entry, "=")
+		setting, _ := FindSetting(key)
+		parsed, err := setting.Parse(value)
+		assert.Nil(t, err, entry)
+		assert.Equal(t, expected, parsed, entry)
+	}
+
+	// a local folder registry
+	localDir, err := filepath.Abs("cola-remote-repository")
+	assert.Nil(t, err)
+	setting, _ := FindSetting(COMMAND_REPOSITORY_BASE_URL_KEY)
+	parsed, err := setting.Parse(localDir)
+	assert.Nil(t, err)
+	assert.Equal(t, localDir, parsed)
+
+	invalid := []string{
+		"LOG_ENABLED=yes",
+		"LOG_LEVEL=verbose",
+		"USER_CONSENT_LIFE=forever",
+		"USER_CONSENT_LIFE=0s",
+		"PACKAGE_MAX_FILE_COUNT=-1",
+		"COMMAND_PREFER_RULES=hotfix",
+		"COMMAND_REPOSITORY_INCLUDE=[",
+		"COMMAND_REPOSITORY_BASE_URL=not-an-url",
+		"EXTRA_REMOTES=[]",
+		"REMOTE_CONFIG_CHECK_TIME=2024-01-01",
+	}
+	for _, entry := range invalid {
+		key, value, _ := strings.Cut(entry, "=")
+		setting, _ := FindSetting(key)
+		_, err := setting.Parse(value)
+		assert.NotNil(t, err, entry)
+	}
+}
+
+func TestSettingDisplay(t *testing.T) {
+	secret := Setting{Key: "TOKEN", Type: STRING_SETTING, Secret: true}
+	assert.Equal(t, "********", secret.DisplayValue("abc"))
+	assert.Equal(t, "", secret.DisplayValue(""))
+
+	table := MarkdownTable()
+	assert.Contains(t, table, "| log_level ")
+	assert.Contains(t, table, "`fatal`")
+	assert.NotContains(t, table, "debug_flags")
+}
+
+func TestDefaultSettings(t *testing.T) {
+	appCtx := context.InitContext("test-settings", "1.0.0", "1")
+	t.Setenv(appCtx.AppHomeEnvVar(), t.TempDir())
+	viper.Reset()
+	defer viper.Reset()
+	setDefaultConfig()
+
+	assert.Equal(t, "fatal", viper.GetString(LOG_LEVEL_KEY))
+	assert.Equal(t, 10*time.Second, viper.GetDuration(PACKAGE_SOURCE_PROVIDER_TIMEOUT_KEY))
+	assert.True(t, viper.GetBool(GROUP_HELP_BY_REGISTRY_KEY))
+	assert.False(t, viper.IsSet(DEBUG_FLAGS_KEY))
+}
@@ -1,11 +1,11 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/criteo/command-launcher/internal/syncPolicy"
 	"github.com/spf13/viper"
 )
 
@@ -27,6 +27,8 @@ const (
 	PACKAGE_LOCK_FILE_KEY                = "PACKAGE_LOCK_FILE"
 	ENABLE_USER_CONSENT_KEY              = "ENABLE_USER_CONSENT"
 	USER_CONSENT_LIFE_KEY                = "USER_CONSENT_LIFE"
+	USER_CONSENT_POLICY_KEY              = "USER_CONSENT_POLICY"            // the consent policy in non-interactive sessions: deny, allow-list, or fail
+	USER_CONSENT_ALLOWED_RESOURCES_KEY   = "USER_CONSENT_ALLOWED_RESOURCES" // comma separated resources granted by the allow-list policy
 	SYSTEM_PACKAGE_KEY                   = "SYSTEM_PACKAGE"                 // the system package name
 	SYSTEM_PACKAGE_PUBLIC_KEY_KEY        = "SYSTEM_PACKAGE_PUBLIC_KEY"      // the public key to verify system package
 	SYSTEM_PACKAGE_PUBLIC_KEY_FILE_KEY   = "SYSTEM_PACKAGE_PUBLIC_KEY_FILE" // the public key file to verify system package
@@ -37,8 +39,19 @@ const (
 	EXTRA_REMOTE_REPOSITORY_DIR_KEY      = "REPOSITORY_DIR"
 	EXTRA_REMOTE_SYNC_POLICY_KEY         = "SYNC_POLICY"
 	ENABLE_PACKAGE_SETUP_HOOK_KEY        = "ENABLE_PACKAGE_SETUP_HOOK"
+	ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY    = "ENABLE_PACKAGE_LIFECYCLE_HOOK"
+	PACKAGE_HOOK_TIMEOUT_KEY             = "PACKAGE_HOOK_TIMEOUT"
 	GROUP_HELP_BY_REGISTRY_KEY           = "GROUP_HELP_BY_REGISTRY"
 	ENABLE_WORKSPACE_PACKAGES_KEY        = "ENABLE_WORKSPACE_PACKAGES"
+	PACKAGE_MAX_EXTRACT_SIZE_KEY         = "PACKAGE_MAX_EXTRACT_SIZE"   // the max size in bytes of an extracted package archive
+	PACKAGE_MAX_FILE_COUNT_KEY           = "PACKAGE_MAX_FILE_COUNT"     // the max number of entries in a package archive
+	SOURCE_PRIORITY_KEY                  = "SOURCE_PRIORITY"            // comma separated package sources, from the highest priority
+	COMMAND_PREFER_RULES_KEY             = "COMMAND_PREFER_RULES"       // the preferred package source of top level commands
+	COMMAND_REPOSITORY_INCLUDE_KEY       = "COMMAND_REPOSITORY_INCLUDE" // comma separated glob patterns of the default remote packages to install
+	COMMAND_REPOSITORY_EXCLUDE_KEY       = "COMMAND_REPOSITORY_EXCLUDE" // comma separated glob patterns of the default remote packages to skip
+	PACKAGE_SOURCE_PROVIDERS_KEY         = "PACKAGE_SOURCE_PROVIDERS"   // the command lines of the external package source providers, by source name
+	PACKAGE_SOURCE_PROVIDER_TIMEOUT_KEY  = "PACKAGE_SOURCE_PROVIDER_TIMEOUT"
+	PACKAGE_SOURCE_PROVIDER_CACHE_KEY    = "PACKAGE_SOURCE_PROVIDER_CACHE" // how long the output of an external package source provider is reused
 
 	// internal commands are the commands with start partition number > INTERNAL_START_PARTITION
 	INTERNAL_COMMAND_ENABLED_KEY = "INTERNAL_COMMAND_ENABLED"
@@ -47,114 +60,81 @@ const (
 )
 
 type ExtraRemote struct {
-	Name          string `mapstructure:"name" json:"name"`
-	RemoteBaseUrl string `mapstructure:"remote_base_url" json:"remote_base_url"`
-	RepositoryDir string `mapstructure:"repository_dir" json:"repository_dir"`
-	SyncPolicy    string `mapstructure:"sync_policy" json:"sync_policy"`
+	Name          string   `mapstructure:"name" json:"name"`
+	RemoteBaseUrl string   `mapstructure:"remote_base_url" json:"remote_base_url"`
+	RepositoryDir string   `mapstructure:"repository_dir" json:"repository_dir"`
+	SyncPolicy    string   `mapstructure:"sync_policy" json:"sync_policy"`
+	Disabled      bool     `mapstructure:"disabled" json:"disabled"`
+	Include       []string `mapstructure:"include" json:"include,omitempty"`
+	Exclude       []string `mapstructure:"exclude" json:"exclude,omitempty"`
 }
 
+// SettingKeys are the keys of the settings changed by the config command
 var SettingKeys []string
 
 func init() {
-	SettingKeys = append([]string{},
-		LOG_ENABLED_KEY,
-		LOG_LEVEL_KEY,
-		SELF_UPDATE_ENABLED_KEY,
-		SELF_UPDATE_TIMEOUT_KEY,
-		SELF_UPDATE_LATEST_VERSION_URL_KEY,
-		SELF_UPDATE_BASE_URL_KEY,
-		COMMAND_UPDATE_ENABLED_KEY,
-		COMMAND_REPOSITORY_BASE_URL_KEY,
-		LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY,
-		USAGE_METRICS_ENABLED_KEY,
-		METRIC_GRAPHITE_HOST_KEY,
-		DEBUG_FLAGS_KEY,
-		DROPIN_FOLDER_KEY,
-		CI_ENABLED_KEY,
-		PACKAGE_LOCK_FILE_KEY,
-		INTERNAL_COMMAND_ENABLED_KEY,
-		EXPERIMENTAL_COMMAND_ENABLED_KEY,
-		ENABLE_USER_CONSENT_KEY,
-		USER_CONSENT_LIFE_KEY,
-		SYSTEM_PACKAGE_KEY,
-		SYSTEM_PACKAGE_PUBLIC_KEY_FILE_KEY,
-		ENABLE_PACKAGE_SETUP_HOOK_KEY,
-		GROUP_HELP_BY_REGISTRY_KEY,
-		ENABLE_WORKSPACE_PACKAGES_KEY,
-	)
+	SettingKeys = []string{}
+	for _, s := range Settings {
+		if s.ManagedBy == "" {
+			SettingKeys = append(SettingKeys, s.Key)
+		}
+	}
 }
 
 func SetSettingValue(key string, value string) error {
-	upperKey := strings.ToUpper(key)
-	switch upperKey {
-	case LOG_ENABLED_KEY:
-		return setBooleanConfig(upperKey, value)
-	case LOG_LEVEL_KEY:
-		return setLogLevelConfig(value)
-	case SELF_UPDATE_ENABLED_KEY:
-		return setBooleanConfig(upperKey, value)
-	case SELF_UPDATE_TIMEOUT_KEY:
-		return setDurationConfig(upperKey, value)
-	case SELF_UPDATE_BASE_URL_KEY:
-		return setStringConfig(upperKey, value)
-	case SELF_UPDATE_LATEST_VERSION_URL_KEY:
-		return setStringConfig(upperKey, value)
-	case COMMAND_UPDATE_ENABLED_KEY:
-		return setBooleanConfig(upperKey, value)
-	case COMMAND_REPOSITORY_BASE_URL_KEY:
-		return setStringConfig(upperKey, value)
-	case LOCAL_COMMAND_REPOSITORY_DIRNAME_KEY:
-		return setStringConfig(upperKey, value)
-	case USAGE_METRICS_ENABLED_KEY:
-		return setBooleanConfig(upperKey, value)
-	case METRIC_GRAPHITE_HOST_KEY:
-		return setStringConfig(upperKey, value)
-	case DEBUG_FLAGS_KEY:
-		return setStringConfig(upperKey, value)
-	case DROPIN_FOLDER_KEY:
-		return setStringConfig(upperKey, value)
-	case CI_ENABLED_KEY:
-		return setBooleanConfig(upperKey, value)
-	case PACKAGE_LOCK_FILE_KEY:
-		return setStringConfig(upperKey, value)
-	case EXPERIMENTAL_COMMAND_ENABLED_KEY:
-		return setBooleanConfig(upperKey, value)
-	case INTERNAL_COMMAND_ENABLED_KEY:
-		return setBooleanConfig(upperKey, value)
-	case ENABLE_USER_CONSENT_KEY:
-		return setBooleanConfig(upperKey, value)
-	case USER_CONSENT_LIFE_KEY:
-		return setDurationConfig(upperKey, value)
-	case SYSTEM_PACKAGE_KEY:
-		return setStringConfig(upperKey, value)
-	case SYSTEM_PACKAGE_PUBLIC_KEY_KEY:
-		return setStringConfig(upperKey, value)
-	case SYSTEM_PACKAGE_PUBLIC_KEY_FILE_KEY:
-		return setStringConfig(upperKey, value)
-	case VERIFY_PACKAGE_CHECKSUM_KEY:
-		return setBooleanConfig(upperKey, value)
-	case VERIFY_PACKAGE_SIGNATURE_KEY:
-		return setBooleanConfig(upperKey, value)
-	case ENABLE_PACKAGE_SETUP_HOOK_KEY:
-		return setBooleanConfig(upperKey, value)
-	case GROUP_HELP_BY_REGISTRY_KEY:
-		return setBooleanConfig(upperKey, value)
-	case ENABLE_WORKSPACE_PACKAGES_KEY:
-		return setBooleanConfig(upperKey, value)
+	setting, found := FindSetting(key)
+	if !found {
+		return fmt.Errorf("unsupported config %s", key)
 	}
-
-	return fmt.Errorf("unsupported config %s", key)
+	parsed, err := setting.Parse(value)
+	if err != nil {
+		return err
+	}
+	setValue(setting.Key, parsed)
+	return nil
 }
 
-func AddRemote(name, repoDir, remoteBaseUrl string, syncPolicy string) error {
+// targetRemotes returns the extra remotes of the configuration file written
+// by WriteConfig, with the changes not written yet. The remotes of the other
+// layers, for example the ones of a project, are never written to this file.
+func targetRemotes() ([]ExtraRemote, error) {
 	remotes := []ExtraRemote{}
-	err := viper.UnmarshalKey(EXTRA_REMOTES_KEY, &remotes)
+	var value interface{}
+	if changed, exists := changedValues[strings.ToLower(EXTRA_REMOTES_KEY)]; exists {
+		value = changed
+	} else if !unsetKeys[strings.ToLower(EXTRA_REMOTES_KEY)] {
+		values, err := readTargetValues()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			if strings.EqualFold(k, EXTRA_REMOTES_KEY) {
+				value = v
+			}
+		}
+	}
+	if value == nil {
+		return remotes, nil
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(payload, &remotes); err != nil {
+		return nil, fmt.Errorf("invalid %s in %s: %v", strings.ToLower(EXTRA_REMOTES_KEY), configMetadata.File, err)
+	}
+	return remotes, nil
+}
+
+func AddRemote(name, repoDir, remoteBaseUrl string, policy string) error {
+	remotes, err := targetRemotes()
 	if err != nil {
 		return err
 	}
 
-	if !IsValidSyncPolicy(syncPolicy) {
-		syncPolicy = "always"
+	if !IsValidSyncPolicy(policy) {
+		policy = syncPolicy.ALWAYS
 	}
 
 	for _, remote := range remotes {
@@ -167,16 +147,15 @@ func AddRemote(name, repoDir, remoteBaseUrl string, syncPolicy string) error {
 		Name:          name,
 		RemoteBaseUrl: remoteBaseUrl,
 		RepositoryDir: repoDir,
-		SyncPolicy:    syncPolicy,
+		SyncPolicy:    policy,
 	})
-	viper.Set(EXTRA_REMOTES_KEY, remotes)
+	setValue(EXTRA_REMOTES_KEY, remotes)
 
 	return nil
 }
 
 func RemoveRemote(name string) error {
-	remotes := []ExtraRemote{}
-	err := viper.UnmarshalKey(EXTRA_REMOTES_KEY, &remotes)
+	remotes, err := targetRemotes()
 	if err != nil {
 		return err
 	}
@@ -188,83 +167,127 @@ func RemoveRemote(name string) error {
 		}
 	}
 
-	viper.Set(EXTRA_REMOTES_KEY, new_remotes)
+	setValue(EXTRA_REMOTES_KEY, new_remotes)
 	return nil
 }
 
-func UpdateRemote(name string, syncPolicy string) error {
-	remotes := []ExtraRemote{}
-	err := viper.UnmarshalKey(EXTRA_REMOTES_KEY, &remotes)
+func UpdateRemote(name string, policy string) error {
+	remotes, err := targetRemotes()
 	if err != nil {
 		return err
 	}
 
-	if !IsValidSyncPolicy(syncPolicy) {
-		return fmt.Errorf("invalid sync policy: %s, must be one of: never, always, hourly, daily, weekly, monthly", syncPolicy)
+	if _, err := syncPolicy.Parse(policy); err != nil {
+		return err
 	}
 
 	found := false
 	for i, remote := range remotes {
 		if remote.Name == name {
-			remotes[i].SyncPolicy = syncPolicy
+			remotes[i].SyncPolicy = policy
 			found = true
 			break
 		}
 	}
 
 	if !found {
-		return fmt.Errorf("remote '%s' not found", name)
+		return fmt.Errorf("remote '%s' not found in %s", name, configMetadata.File)
 	}
 
-	viper.Set(EXTRA_REMOTES_KEY, remotes)
+	setValue(EXTRA_REMOTES_KEY, remotes)
 	return nil
 }
 
-func IsValidSyncPolicy(policy string) bool {
-	return policy == "never" || policy == "always" ||
-		policy == "hourly" || policy == "daily" ||
-		policy == "weekly" || policy == "monthly"
-}
+// SetRemoteDisabled disables or enables an extra remote, a disabled remote
+// keeps its local repository but its packages are not loaded
+func SetRemoteDisabled(name string, disabled bool) error {
+	remotes, err := targetRemotes()
+	if err != nil {
+		return err
+	}
 
-func ValidSyncPolicies() []string {
-	return []string{"never", "always", "hourly", "daily", "weekly", "monthly"}
+	found := false
+	for i, remote := range remotes {
+		if remote.Name == name {
+			remotes[i].Disabled = disabled
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("remote '%s' not found in %s", name, configMetadata.File)
+	}
+
+	setValue(EXTRA_REMOTES_KEY, remotes)
+	return nil
 }
 
-func Remotes() ([]ExtraRemote, error) {
-	remotes := []ExtraRemote{}
-	err := viper.UnmarshalKey(EXTRA_REMOTES_KEY, &remotes)
+// SetRemotePackageFilter changes the glob patterns of the packages to
+// install from an extra remote
+func SetRemotePackageFilter(name string, include []string, exclude []string) error {
+	remotes, err := targetRemotes()
 	if err != nil {
-		return remotes, err
+		return err
 	}
-	return remotes, nil
+
+	found := false
+	for i, remote := range remotes {
+		if remote.Name == name {
+			remotes[i].Include = include
+			remotes[i].Exclude = exclude
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("remote '%s' not found in %s", name, configMetadata.File)
+	}
+
+	setValue(EXTRA_REMOTES_KEY, remotes)
+	return nil
 }
 
-func setBooleanConfig(key string, value string) error {
-	if value == "true" {
-		viper.Set(key, true)
-		return nil
-	} else if value == "false" {
-		viper.Set(key, false)
-		return nil
+// StringList returns the comma separated values of a configuration
+func StringList(key string) []string {
+	values := []string{}
+	for _, value := range strings.Split(viper.GetString(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
 	}
-	return fmt.Errorf("invalid format for boolean type")
+	return values
 }
 
-func setDurationConfig(key string, value string) error {
-	d, _ := time.ParseDuration(value)
-	viper.Set(key, d)
-	return nil
+// the consent policies of the non-interactive sessions, where the user
+// cannot answer the consent requests
+const (
+	CONSENT_POLICY_DENY       = "deny"
+	CONSENT_POLICY_ALLOW_LIST = "allow-list"
+	CONSENT_POLICY_FAIL       = "fail"
+)
+
+// ValidConsentPolicies returns the consent policies of the non-interactive sessions
+func ValidConsentPolicies() []string {
+	return []string{CONSENT_POLICY_DENY, CONSENT_POLICY_ALLOW_LIST, CONSENT_POLICY_FAIL}
 }
 
-func setStringConfig(key string, value string) error {
-	viper.Set(key, value)
-	return nil
+// IsValidSyncPolicy checks if the policy is a sync policy keyword, a duration, or a cron expression
+func IsValidSyncPolicy(policy string) bool {
+	return syncPolicy.IsValid(policy)
 }
 
-func setLogLevelConfig(value string) error {
-	_, err := log.ParseLevel(strings.ToLower(value))
-	if err == nil {
-		viper.Set(LOG_LEVEL_KEY, strings.ToLower(value))
+// ValidSyncPolicies returns the sync policy keywords
+func ValidSyncPolicies() []string {
+	return syncPolicy.Keywords()
+}
+
+func Remotes() ([]ExtraRemote, error) {
+	remotes := []ExtraRemote{}
+	err := viper.UnmarshalKey(EXTRA_REMOTES_KEY, &remotes)
+	if err != nil {
+		return remotes, err
 	}
-	return err
+	return remotes, nil
 }
@@ -23,6 +23,10 @@ type LauncherContext interface {
 
 	ConfigurationFileEnvVar() string
 
+	SystemConfigurationFileEnvVar() string
+
+	ProfileEnvVar() string
+
 	RemoteConfigurationUrlEnvVar() string
 
 	CmdPackageDirEnvVar() string
@@ -74,6 +74,14 @@ func (ctx *defaultContext) ConfigurationFileEnvVar() string {
 	return ctx.EnvVarName("CONFIG_FILE")
 }
 
+func (ctx *defaultContext) SystemConfigurationFileEnvVar() string {
+	return ctx.EnvVarName("SYSTEM_CONFIG_FILE")
+}
+
+func (ctx *defaultContext) ProfileEnvVar() string {
+	return ctx.EnvVarName("PROFILE")
+}
+
 func (ctx *defaultContext) RemoteConfigurationUrlEnvVar() string {
 	return ctx.EnvVarName("REMOTE_CONFIG_URL")
 }
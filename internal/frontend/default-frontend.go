@@ -15,6 +15,7 @@ import (
 	"github.com/criteo/command-launcher/internal/console"
 	"github.com/criteo/command-launcher/internal/context"
 	"github.com/criteo/command-launcher/internal/helper"
+	"github.com/criteo/command-launcher/internal/pkg"
 
 	log "github.com/sirupsen/logrus"
 
@@ -98,7 +99,11 @@ func (self *defaultFrontend) addGroupCommands() {
 			Long:               v.LongDescription(),
 			Run: func(cmd *cobra.Command, args []string) {
 				consents, err := consent.GetConsents(group, name, requestedResources, viper.GetBool(config.ENABLE_USER_CONSENT_KEY))
-				if err != nil {
+				if errors.Is(err, consent.ErrConsentRequired) {
+					console.Error("%v\n", err)
+					RootExitCode = 1
+					return
+				} else if err != nil {
 					log.Warnf("failed to get user consent: %v", err)
 				}
 				exitCode, err := self.executeCommand(group, name, args, []string{}, consents)
@@ -151,7 +156,11 @@ func (self *defaultFrontend) addExecutableCommands() {
 			Long:               v.LongDescription(),
 			Run: func(c *cobra.Command, args []string) {
 				consents, err := consent.GetConsents(group, name, requestedResources, viper.GetBool(config.ENABLE_USER_CONSENT_KEY))
-				if err != nil {
+				if errors.Is(err, consent.ErrConsentRequired) {
+					console.Error("%v\n", err)
+					RootExitCode = 1
+					return
+				} else if err != nil {
 					log.Warnf("failed to get user consent: %v", err)
 				}
 
@@ -325,8 +334,18 @@ func (self *defaultFrontend) executeCommand(group, name string, args []string, i
 	// Check workspace consent before executing workspace commands
 	if strings.HasPrefix(iCmd.RepositoryID(), backend.WorkspaceSourcePrefix) {
 		workspaceDir := strings.TrimPrefix(iCmd.RepositoryID(), backend.WorkspaceSourcePrefix)
-		if !consent.CheckWorkspaceConsent(workspaceDir) && !consent.RequestWorkspaceConsent(workspaceDir) {
-			return 1, fmt.Errorf("workspace command execution denied: user did not consent to workspace %s", workspaceDir)
+		snapshot, err := self.workspaceSnapshot(iCmd.RepositoryID())
+		if err != nil {
+			return 1, fmt.Errorf("cannot check the content of the workspace %s: %v", workspaceDir, err)
+		}
+		if !consent.CheckWorkspaceConsent(workspaceDir, snapshot) {
+			trusted, err := consent.RequestWorkspaceConsent(workspaceDir, snapshot)
+			if err != nil {
+				return 1, err
+			}
+			if !trusted {
+				return 1, fmt.Errorf("workspace command execution denied: user did not consent to workspace %s", workspaceDir)
+			}
 		}
 	}
 
@@ -340,6 +359,16 @@ func (self *defaultFrontend) executeCommand(group, name string, args []string, i
 	return exitCode, nil
 }
 
+// workspaceSnapshot computes the snapshot of the content of a workspace source
+func (self *defaultFrontend) workspaceSnapshot(sourceName string) (*backend.WorkspaceSnapshot, error) {
+	for _, src := range self.backend.AllPackageSources() {
+		if src.Name == sourceName {
+			return backend.NewWorkspaceSnapshot(src, self.appCtx.AppName())
+		}
+	}
+	return nil, fmt.Errorf("no package source %s found", sourceName)
+}
+
 // execute the valid args command of the cdt command
 func (self *defaultFrontend) executeValidArgsOfCommand(group, name string, args []string, toComplete string) (string, error) {
 	iCmd, err := self.getExecutableCommand(group, name)
@@ -532,37 +561,7 @@ func (self *defaultFrontend) getCmdEnvContext(cmd command.Command, envVars []str
 	}
 
 	/* append environment variables that do not require consent */
-	// append log level from configuration
-	logLevel := viper.GetString(config.LOG_LEVEL_KEY)
-	vars = append(vars, fmt.Sprintf("%s=%s",
-		self.appCtx.LogLevelEnvVar(),
-		logLevel,
-	))
-
-	// append debug flags from configuration
-	debugFlags := os.Getenv(self.appCtx.DebugFlagsEnvVar())
-	vars = append(vars, fmt.Sprintf("%s=%s,%s",
-		self.appCtx.DebugFlagsEnvVar(),
-		debugFlags,
-		viper.GetString(config.DEBUG_FLAGS_KEY),
-	))
-
-	// append package dir and full command name
-	vars = append(vars, fmt.Sprintf("%s=%s", self.appCtx.CmdPackageDirEnvVar(), cmd.PackageDir()))
-	vars = append(vars, fmt.Sprintf("%s=%s", self.appCtx.FullCmdNameEnvVar(), self.getFullCommandName(cmd.RuntimeGroup(), cmd.RuntimeName())))
-
-	// Enable variable with prefix [binary_name] and COLA
-	// TODO: remove it when in version 1.8 all variables are migrated to COLA prefix
-	outputVars := []string{}
-	for _, v := range vars {
-		prefix := fmt.Sprintf("%s_", strings.ToUpper(self.appCtx.AppName()))
-		if strings.HasPrefix(v, prefix) && prefix != "COLA_" {
-			outputVars = append(outputVars, strings.Replace(v, prefix, "COLA_", 1))
-		}
-		outputVars = append(outputVars, v)
-	}
-
-	return outputVars
+	return pkg.CommandEnvContext(self.appCtx, vars, cmd.PackageDir(), self.getFullCommandName(cmd.RuntimeGroup(), cmd.RuntimeName()))
 }
 
 // return environment variable list, env variable table, original args including flags
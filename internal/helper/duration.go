@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var dayWeekDurationRegex = regexp.MustCompile(`([0-9]+)([dw])`)
+
+// ParseDuration parses a duration string like time.ParseDuration, it also
+// accepts the "d" (day = 24h) and "w" (week = 7d) units, ex: 7d, 1w, 1d12h
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("invalid duration: empty value")
+	}
+
+	var total time.Duration
+	for _, match := range dayWeekDurationRegex.FindAllStringSubmatch(value, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s: %v", value, err)
+		}
+		switch match[2] {
+		case "d":
+			total += time.Duration(n) * 24 * time.Hour
+		case "w":
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		}
+	}
+
+	rest := dayWeekDurationRegex.ReplaceAllString(value, "")
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s: %v", value, err)
+		}
+		total += d
+	}
+
+	return total, nil
+}
@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("6h")
+	assert.Nil(t, err)
+	assert.Equal(t, 6*time.Hour, d)
+
+	d, err = ParseDuration("2d")
+	assert.Nil(t, err)
+	assert.Equal(t, 48*time.Hour, d)
+
+	d, err = ParseDuration("1w")
+	assert.Nil(t, err)
+	assert.Equal(t, 7*24*time.Hour, d)
+
+	d, err = ParseDuration("1d12h")
+	assert.Nil(t, err)
+	assert.Equal(t, 36*time.Hour, d)
+}
+
+func TestParseInvalidDuration(t *testing.T) {
+	_, err := ParseDuration("")
+	assert.NotNil(t, err)
+
+	_, err = ParseDuration("daily")
+	assert.NotNil(t, err)
+
+	_, err = ParseDuration("2x")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,39 @@
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var sizeRegex = regexp.MustCompile(`^([0-9]+)\s*([kmgt]?i?b?)$`)
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// ParseSize parses a size in bytes, with an optional binary unit, ex: 512,
+// 100KB, 512MB, 1G, 2GiB. The units are case insensitive, 1KB = 1024 bytes
+func ParseSize(value string) (int64, error) {
+	match := sizeRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %s, ex: 1024, 512KB, 100MB, 1GB", value)
+	}
+
+	n, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %s: %v", value, err)
+	}
+
+	unit := strings.TrimSuffix(strings.TrimSuffix(match[2], "b"), "i")
+	if match[2] == "ib" || match[2] == "i" {
+		return 0, fmt.Errorf("invalid size %s, ex: 1024, 512KB, 100MB, 1GB", value)
+	}
+	return n * sizeUnits[unit], nil
+}
@@ -0,0 +1,30 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"0":      0,
+		"1024":   1024,
+		"1b":     1,
+		"512KB":  512 * 1024,
+		"100mb":  100 * 1024 * 1024,
+		"1G":     1024 * 1024 * 1024,
+		"2GiB":   2 * 1024 * 1024 * 1024,
+		" 1 TB ": 1024 * 1024 * 1024 * 1024,
+	}
+	for value, expected := range cases {
+		size, err := ParseSize(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, size, value)
+	}
+
+	for _, value := range []string{"", "-1", "1.5GB", "10 apples", "ib", "1ib"} {
+		_, err := ParseSize(value)
+		assert.NotNil(t, err, value)
+	}
+}
@@ -0,0 +1,204 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/console"
+	"github.com/spf13/viper"
+)
+
+/*
+Supported package archive formats.
+
+The format of a package archive is either specified by the "format" field
+of the remote registry index, or detected from the magic bytes of the file.
+*/
+const (
+	ZIP_FORMAT     = "zip"
+	TAR_GZ_FORMAT  = "tar.gz"
+	TAR_ZST_FORMAT = "tar.zst"
+)
+
+// packageFormat reads and extracts a package archive of a given format
+type packageFormat interface {
+	// readManifest reads the manifest.mf file at the root of the archive
+	readManifest(archiveFile string) (command.PackageManifest, error)
+	// extract extracts the content of the archive into the target directory
+	extract(archiveFile string, targetDir string) error
+}
+
+var packageFormats = map[string]packageFormat{
+	ZIP_FORMAT:     &zipFormat{},
+	TAR_GZ_FORMAT:  &tarFormat{decompress: gzipReader},
+	TAR_ZST_FORMAT: &tarFormat{decompress: zstdReader},
+}
+
+var formatMagicBytes = []struct {
+	format string
+	magic  []byte
+}{
+	{ZIP_FORMAT, []byte("PK\x03\x04")},
+	{ZIP_FORMAT, []byte("PK\x05\x06")}, // empty zip
+	{TAR_GZ_FORMAT, []byte{0x1f, 0x8b}},
+	{TAR_ZST_FORMAT, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// PackageFormats returns the supported package archive formats
+func PackageFormats() []string {
+	return []string{ZIP_FORMAT, TAR_GZ_FORMAT, TAR_ZST_FORMAT}
+}
+
+// DetectPackageFormat detects the format of a package archive from its magic bytes
+func DetectPackageFormat(archiveFile string) (string, error) {
+	f, err := os.Open(archiveFile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 4)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("cannot read the package file %s: %v", archiveFile, err)
+	}
+
+	for _, m := range formatMagicBytes {
+		if bytes.HasPrefix(header[:n], m.magic) {
+			return m.format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown package format of %s, supported formats: %v", archiveFile, PackageFormats())
+}
+
+// archivePackage is a package distributed as an archive file
+type archivePackage struct {
+	defaultPackage
+	ArchiveFile string
+	format      packageFormat
+}
+
+// CreateArchivePackage creates a package from an archive file of the given
+// format, the format is detected from the file content when it is empty
+func CreateArchivePackage(archiveFile string, format string) (command.Package, error) {
+	if format == "" {
+		var err error
+		if format, err = DetectPackageFormat(archiveFile); err != nil {
+			return nil, err
+		}
+	}
+
+	pkgFormat, ok := packageFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported package format %s, supported formats: %v", format, PackageFormats())
+	}
+
+	mf, err := pkgFormat.readManifest(archiveFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg = archivePackage{
+		defaultPackage: defaultPackage{
+			Manifest: mf,
+		},
+		ArchiveFile: archiveFile,
+		format:      pkgFormat,
+	}
+
+	return &pkg, nil
+}
+
+func (pkg *archivePackage) InstallTo(targetDir string) (command.PackageManifest, error) {
+	// Backup existing directory if it exists
+	backupDir, err := pkg.createBackup(targetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Install the package
+	err = pkg.installFromArchive(targetDir)
+	if err != nil {
+		// Restore backup on failure, or remove the partially extracted package
+		pkg.rollback(backupDir, targetDir)
+		return nil, err
+	}
+
+	// Clean up backup on success
+	if backupDir != "" {
+		os.RemoveAll(backupDir)
+	}
+
+	return pkg.Manifest, nil
+}
+
+func (pkg *archivePackage) createBackup(targetDir string) (string, error) {
+	backupDir, err := BackupDir(targetDir)
+	if err != nil || backupDir == "" {
+		return backupDir, err
+	}
+
+	if err := os.RemoveAll(targetDir); err != nil {
+		os.RemoveAll(backupDir)
+		return "", fmt.Errorf("cannot remove existing package directory %s: %v", targetDir, err)
+	}
+
+	return backupDir, nil
+}
+
+func (pkg *archivePackage) installFromArchive(targetDir string) error {
+	// Create target directory
+	if err := os.MkdirAll(targetDir, os.ModePerm); err != nil {
+		return fmt.Errorf("cannot create target package directory %s: %v", targetDir, err)
+	}
+
+	// Extract files
+	if err := pkg.format.extract(pkg.ArchiveFile, targetDir); err != nil {
+		return err
+	}
+
+	// Run setup hook if enabled
+	if viper.GetBool(config.ENABLE_PACKAGE_SETUP_HOOK_KEY) {
+		if err := pkg.RunSetup(targetDir); err != nil {
+			return err
+		}
+	}
+
+	// Record the installed files to verify them later
+	if err := WriteFileHashes(targetDir); err != nil {
+		return fmt.Errorf("cannot record the file hashes of the package %s: %v", pkg.Name(), err)
+	}
+
+	return nil
+}
+
+func (pkg *archivePackage) rollback(backupDir, targetDir string) {
+	if backupDir == "" {
+		if err := os.RemoveAll(targetDir); err != nil {
+			console.Error("Failed to remove the partially installed package %s: %v\n", pkg.Name(), err)
+		}
+		return
+	}
+
+	if err := RestoreDir(backupDir, targetDir); err != nil {
+		console.Error("Failed to restore the previous version of the package %s: %v\n", pkg.Name(), err)
+		return
+	}
+	console.Warn("Restored the previous version of the package %s from backup\n", pkg.Name())
+}
+
+func (pkg *archivePackage) VerifyChecksum(checksum string) (bool, error) {
+	sha, err := packageChecksum(pkg.ArchiveFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to calculate checksum of package %s@%s", pkg.Name(), pkg.Version())
+	}
+	remoteChecksum := fmt.Sprintf("%x", sha)
+	if remoteChecksum != checksum {
+		return false, fmt.Errorf("package %s@%s has a wrong checksum, expected %s, but get %s", pkg.Name(), pkg.Version(), checksum, remoteChecksum)
+	}
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BackupDir copies the content of dir into a new temporary directory, and
+// returns the backup location. An empty location is returned when dir doesn't exist.
+//
+// The content is copied instead of moved to avoid cross-filesystem rename issues
+// during restoration.
+func BackupDir(dir string) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "package-backup-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot create temporary backup directory: %v", err)
+	}
+
+	if err := copyDir(dir, backupContentDir(tmpDir, dir)); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("cannot backup existing package directory %s: %v", dir, err)
+	}
+
+	return tmpDir, nil
+}
+
+// RestoreDir replaces dir with the content of a backup created by BackupDir,
+// the backup is removed once restored.
+func RestoreDir(backupDir string, dir string) error {
+	if backupDir == "" {
+		return nil
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove target directory %s: %v", dir, err)
+	}
+
+	contentDir := backupContentDir(backupDir, dir)
+	if err := copyDir(contentDir, dir); err != nil {
+		return fmt.Errorf("failed to restore backup from %s to %s: %v", contentDir, dir, err)
+	}
+
+	return os.RemoveAll(backupDir)
+}
+
+func backupContentDir(backupDir string, dir string) string {
+	return filepath.Join(backupDir, filepath.Base(dir))
+}
+
+// copyDir copies the content of srcDir into dstDir, unlike os.CopyFS, the
+// symbolic links are copied as links
+func copyDir(srcDir string, dstDir string) error {
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dst)
+		case d.IsDir():
+			return os.MkdirAll(dst, 0755)
+		case d.Type().IsRegular():
+			return copyFile(path, dst)
+		default:
+			return &fs.PathError{Op: "copy", Path: path, Err: fs.ErrInvalid}
+		}
+	})
+}
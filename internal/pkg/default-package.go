@@ -70,9 +70,18 @@ func ReadManifest(file fs.File) (command.PackageManifest, error) {
 		return nil, fmt.Errorf("cannot read the manifest (%s)", err)
 	}
 
+	return parseManifest(payload)
+}
+
+// ParseManifest reads a manifest from its JSON or YAML content
+func ParseManifest(payload []byte) (command.PackageManifest, error) {
+	return parseManifest(payload)
+}
+
+func parseManifest(payload []byte) (command.PackageManifest, error) {
 	var mf = defaultPackageManifest{}
 	// YAML is super set of json, should work with JSON as well
-	err = yaml.Unmarshal(payload, &mf)
+	err := yaml.Unmarshal(payload, &mf)
 	if err != nil {
 		return nil, fmt.Errorf("cannot read the manifest content, it is neither a valid JSON nor YAML (%s)", err)
 	}
@@ -143,19 +152,9 @@ func copyFile(src string, dst string) error {
 }
 
 func ExecSetupHookFromPackage(pkg command.PackageManifest, pkgDir string) error {
-	for _, c := range pkg.Commands() {
-		if c.Name() == "__setup__" && c.Type() == "system" {
-			if pkgDir != "" {
-				c.SetPackageDir(pkgDir)
-			}
-			// Execute the __setup__ hook
-			_, err := c.Execute(os.Environ())
-			if err != nil {
-				return fmt.Errorf("setup hook of package %s failed to execute: %v", pkg.Name(), err)
-			}
-			return nil
-		}
+	if !HasHook(pkg, SETUP_HOOK) {
+		log.Warnf("No setup hook defined for package %s", pkg.Name())
+		return nil
 	}
-	log.Warnf("No setup hook defined for package %s", pkg.Name())
-	return nil
+	return ExecHookFromPackage(pkg, SETUP_HOOK, pkgDir)
 }
@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/spf13/viper"
+)
+
+// CommandEnvContext appends the environment variables passed to every command
+// and hook to the given ones: the log level, the debug flags, the package dir
+// and the full command name. Variables prefixed with the application name are
+// also exported with the COLA prefix.
+func CommandEnvContext(appCtx context.LauncherContext, envVars []string, pkgDir string, fullCmdName string) []string {
+	vars := append([]string{}, envVars...)
+
+	// append log level from configuration
+	vars = append(vars, fmt.Sprintf("%s=%s",
+		appCtx.LogLevelEnvVar(),
+		viper.GetString(config.LOG_LEVEL_KEY),
+	))
+
+	// append debug flags from configuration
+	vars = append(vars, fmt.Sprintf("%s=%s,%s",
+		appCtx.DebugFlagsEnvVar(),
+		os.Getenv(appCtx.DebugFlagsEnvVar()),
+		viper.GetString(config.DEBUG_FLAGS_KEY),
+	))
+
+	// append package dir and full command name
+	vars = append(vars, fmt.Sprintf("%s=%s", appCtx.CmdPackageDirEnvVar(), pkgDir))
+	vars = append(vars, fmt.Sprintf("%s=%s", appCtx.FullCmdNameEnvVar(), fullCmdName))
+
+	// Enable variable with prefix [binary_name] and COLA
+	// TODO: remove it when in version 1.8 all variables are migrated to COLA prefix
+	outputVars := []string{}
+	prefix := fmt.Sprintf("%s_", strings.ToUpper(appCtx.AppName()))
+	for _, v := range vars {
+		if strings.HasPrefix(v, prefix) && prefix != "COLA_" {
+			outputVars = append(outputVars, strings.Replace(v, prefix, "COLA_", 1))
+		}
+		outputVars = append(outputVars, v)
+	}
+
+	return outputVars
+}
@@ -0,0 +1,28 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/context"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandEnvContext(t *testing.T) {
+	appCtx := context.InitContext("test", "1.0.0", "1")
+	viper.Set(config.LOG_LEVEL_KEY, "debug")
+	defer viper.Set(config.LOG_LEVEL_KEY, nil)
+
+	vars := CommandEnvContext(appCtx, []string{"TEST_USERNAME=joe", "OTHER=1"}, "/pkg/dir", "test hello")
+
+	assert.Contains(t, vars, "TEST_USERNAME=joe")
+	assert.Contains(t, vars, "COLA_USERNAME=joe")
+	assert.Contains(t, vars, "OTHER=1")
+	assert.NotContains(t, vars, "COLA_OTHER=1")
+	assert.Contains(t, vars, "TEST_LOG_LEVEL=debug")
+	assert.Contains(t, vars, "COLA_LOG_LEVEL=debug")
+	assert.Contains(t, vars, "TEST_PACKAGE_DIR=/pkg/dir")
+	assert.Contains(t, vars, "COLA_PACKAGE_DIR=/pkg/dir")
+	assert.Contains(t, vars, "TEST_FULL_COMMAND_NAME=test hello")
+}
@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// PACKAGE_FILE_MODE_MASK is applied to the permissions of the extracted
+	// files: no write permission for group and others
+	PACKAGE_FILE_MODE_MASK os.FileMode = 0755
+	// PACKAGE_DIR_MODE is the permission of the extracted directories
+	PACKAGE_DIR_MODE os.FileMode = 0755
+)
+
+// extractor extracts the entries of a package archive into the target
+// directory. Each entry is checked before being written:
+//
+//   - absolute paths and paths escaping the target directory are rejected
+//   - symbolic links must point inside the target directory
+//   - no entry is written through a symbolic link
+//   - the total size and the number of entries are limited by the
+//     PACKAGE_MAX_EXTRACT_SIZE and PACKAGE_MAX_FILE_COUNT configurations
+type extractor struct {
+	targetDir string
+	maxSize   int64
+	maxFiles  int
+	size      int64
+	files     int
+}
+
+func newExtractor(targetDir string) *extractor {
+	return &extractor{
+		targetDir: targetDir,
+		maxSize:   viper.GetInt64(config.PACKAGE_MAX_EXTRACT_SIZE_KEY),
+		maxFiles:  viper.GetInt(config.PACKAGE_MAX_FILE_COUNT_KEY),
+	}
+}
+
+// entryPath checks the name of an archive entry, and returns its path in the target directory
+func (e *extractor) entryPath(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("unsafe package archive: empty entry name")
+	}
+	if strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("unsafe package archive: entry %s has an absolute path", name)
+	}
+	localName := filepath.FromSlash(path.Clean(strings.ReplaceAll(name, `\`, "/")))
+	if !filepath.IsLocal(localName) {
+		return "", fmt.Errorf("unsafe package archive: entry %s is outside of the package folder", name)
+	}
+
+	e.files++
+	if e.maxFiles > 0 && e.files > e.maxFiles {
+		return "", fmt.Errorf("unsafe package archive: more than %d entries (package_max_file_count)", e.maxFiles)
+	}
+
+	if err := e.checkParents(localName); err != nil {
+		return "", err
+	}
+	return filepath.Join(e.targetDir, localName), nil
+}
+
+// checkParents makes sure that the entry is not written through a symbolic link
+func (e *extractor) checkParents(localName string) error {
+	current := e.targetDir
+	parts := strings.Split(localName, string(filepath.Separator))
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot check the folder %s: %v", current, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("unsafe package archive: entry %s is written through the symbolic link %s", filepath.ToSlash(localName), part)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("unsafe package archive: entry %s is under the file %s", filepath.ToSlash(localName), part)
+		}
+	}
+	return nil
+}
+
+func (e *extractor) mkdir(name string) error {
+	dirPath, err := e.entryPath(name)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Lstat(dirPath); err == nil && !info.IsDir() {
+		return fmt.Errorf("unsafe package archive: folder %s replaces a file or a symbolic link", name)
+	}
+	log.Println("Directory Created:", dirPath)
+	if err := os.MkdirAll(dirPath, PACKAGE_DIR_MODE); err != nil {
+		return fmt.Errorf("directory extraction failed: %s", err)
+	}
+	if err := os.Chmod(dirPath, PACKAGE_DIR_MODE); err != nil {
+		return fmt.Errorf("failed to chmod %s to %o: %s", dirPath, PACKAGE_DIR_MODE, err)
+	}
+	return nil
+}
+
+func (e *extractor) writeFile(name string, mode os.FileMode, content io.Reader) error {
+	filePath, err := e.entryPath(name)
+	if err != nil {
+		return err
+	}
+	log.Println("File extracted:", name)
+	if err := e.prepare(filePath); err != nil {
+		return err
+	}
+
+	outputFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()&PACKAGE_FILE_MODE_MASK)
+	if err != nil {
+		return fmt.Errorf("file extraction failed: %s", err)
+	}
+	defer outputFile.Close()
+
+	if e.maxSize <= 0 {
+		if _, err := io.Copy(outputFile, content); err != nil {
+			return fmt.Errorf("file data extraction failed: %s", err)
+		}
+		return nil
+	}
+
+	// copy one more byte than allowed to detect the overflow
+	n, err := io.CopyN(outputFile, content, e.maxSize-e.size+1)
+	e.size += n
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("file data extraction failed: %s", err)
+	}
+	if e.size > e.maxSize {
+		return fmt.Errorf("unsafe package archive: extracted content exceeds %d bytes (package_max_extract_size)", e.maxSize)
+	}
+	return nil
+}
+
+func (e *extractor) symlink(name string, linkname string) error {
+	linkPath, err := e.entryPath(name)
+	if err != nil {
+		return err
+	}
+	if linkname == "" || filepath.IsAbs(linkname) || strings.HasPrefix(linkname, "/") {
+		return fmt.Errorf("unsafe package archive: symbolic link %s points to the absolute path %s", name, linkname)
+	}
+	// ".." is only allowed at the beginning of the link target, otherwise
+	// it could go up from a folder that is itself a symbolic link
+	if !isForwardLink(linkname) {
+		return fmt.Errorf("unsafe package archive: symbolic link %s points to %s, '..' is only allowed at the beginning", name, linkname)
+	}
+	resolved, err := filepath.Rel(e.targetDir, filepath.Join(filepath.Dir(linkPath), filepath.FromSlash(linkname)))
+	if err != nil || !filepath.IsLocal(resolved) {
+		return fmt.Errorf("unsafe package archive: symbolic link %s points to %s, outside of the package folder", name, linkname)
+	}
+
+	log.Println("Symlink extracted:", name)
+	if err := e.prepare(linkPath); err != nil {
+		return err
+	}
+	if err := os.Symlink(linkname, linkPath); err != nil {
+		return fmt.Errorf("symlink extraction failed: %s", err)
+	}
+	return nil
+}
+
+// prepare creates the parent folders of an entry, and removes the file that
+// it replaces, so that a file is never written through an existing link
+func (e *extractor) prepare(entryPath string) error {
+	if err := os.MkdirAll(filepath.Dir(entryPath), PACKAGE_DIR_MODE); err != nil {
+		return fmt.Errorf("directory extraction failed: %s", err)
+	}
+	if info, err := os.Lstat(entryPath); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("unsafe package archive: entry %s replaces a folder", entryPath)
+		}
+		if err := os.Remove(entryPath); err != nil {
+			return fmt.Errorf("cannot replace %s: %v", entryPath, err)
+		}
+	}
+	return nil
+}
+
+func isForwardLink(linkname string) bool {
+	forward := false
+	for _, part := range strings.Split(strings.ReplaceAll(linkname, `\`, "/"), "/") {
+		switch {
+		case part == "..":
+			if forward {
+				return false
+			}
+		case part != "" && part != ".":
+			forward = true
+		}
+	}
+	return true
+}
@@ -0,0 +1,223 @@
+package pkg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const extractTestManifest = `{"pkgName": "fake_zip", "version": "1.0.0", "cmds": []}`
+
+type zipTestEntry struct {
+	name    string
+	mode    os.FileMode
+	content string
+}
+
+func createZipPackage(t *testing.T, entries []zipTestEntry) string {
+	t.Helper()
+	pkgFile := filepath.Join(t.TempDir(), "fake-zip-1.0.0.pkg")
+	f, err := os.Create(pkgFile)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		hdr.SetMode(e.mode)
+		w, err := zw.CreateHeader(hdr)
+		assert.Nil(t, err)
+		_, err = w.Write([]byte(e.content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+	return pkgFile
+}
+
+func withZipManifest(entries ...zipTestEntry) []zipTestEntry {
+	return append([]zipTestEntry{{name: "manifest.mf", mode: 0644, content: extractTestManifest}}, entries...)
+}
+
+func withExtractLimits(t *testing.T, maxSize int64, maxFiles int) {
+	previousSize := viper.Get(config.PACKAGE_MAX_EXTRACT_SIZE_KEY)
+	previousFiles := viper.Get(config.PACKAGE_MAX_FILE_COUNT_KEY)
+	t.Cleanup(func() {
+		viper.Set(config.PACKAGE_MAX_EXTRACT_SIZE_KEY, previousSize)
+		viper.Set(config.PACKAGE_MAX_FILE_COUNT_KEY, previousFiles)
+	})
+	viper.Set(config.PACKAGE_MAX_EXTRACT_SIZE_KEY, maxSize)
+	viper.Set(config.PACKAGE_MAX_FILE_COUNT_KEY, maxFiles)
+}
+
+func installFails(t *testing.T, pkgFile string, expectedError string) string {
+	t.Helper()
+	pkg, err := CreateArchivePackage(pkgFile, "")
+	assert.Nil(t, err)
+
+	target := filepath.Join(t.TempDir(), "repo", pkg.Name())
+	_, err = pkg.InstallTo(target)
+	assert.NotNil(t, err)
+	if err != nil {
+		assert.Contains(t, err.Error(), expectedError)
+	}
+
+	// the partially extracted package is rolled back
+	_, statErr := os.Stat(target)
+	assert.True(t, os.IsNotExist(statErr))
+	return target
+}
+
+func TestExtractRejectsPathTraversal(t *testing.T) {
+	target := installFails(t, createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "../../evil.sh", mode: 0755, content: "evil"},
+	)), "outside of the package folder")
+	_, err := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(target)), "evil.sh"))
+	assert.True(t, os.IsNotExist(err))
+
+	installFails(t, createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "bin/../../evil.sh", mode: 0644, content: "evil"},
+	)), "outside of the package folder")
+
+	installFails(t, createTarPackage(t, TAR_GZ_FORMAT, append(tarTestEntries,
+		tarTestEntry{name: "../evil.sh", typeflag: tar.TypeReg, mode: 0644, content: "evil"},
+	)), "outside of the package folder")
+}
+
+func TestExtractRejectsAbsolutePath(t *testing.T) {
+	installFails(t, createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "/tmp/evil.sh", mode: 0644, content: "evil"},
+	)), "absolute path")
+
+	installFails(t, createTarPackage(t, TAR_ZST_FORMAT, append(tarTestEntries,
+		tarTestEntry{name: "/tmp/evil.sh", typeflag: tar.TypeReg, mode: 0644, content: "evil"},
+	)), "absolute path")
+}
+
+func TestExtractRejectsUnsafeSymlinks(t *testing.T) {
+	cases := map[string][]tarTestEntry{
+		"absolute path": {
+			{name: "./passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+		},
+		"outside of the package folder": {
+			{name: "./bin/home", typeflag: tar.TypeSymlink, linkname: "../../.."},
+		},
+		"'..' is only allowed at the beginning": {
+			{name: "./up", typeflag: tar.TypeSymlink, linkname: "bin/../bin"},
+		},
+		"written through the symbolic link": {
+			{name: "./lib", typeflag: tar.TypeSymlink, linkname: "bin"},
+			{name: "./lib/tool", typeflag: tar.TypeReg, mode: 0644, content: "overwritten"},
+		},
+	}
+	for expectedError, entries := range cases {
+		installFails(t, createTarPackage(t, TAR_GZ_FORMAT, append(append([]tarTestEntry{}, tarTestEntries...), entries...)), expectedError)
+	}
+
+	// zip symlinks are checked as well
+	installFails(t, createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "passwd", mode: os.ModeSymlink | 0777, content: "../../etc/passwd"},
+	)), "outside of the package folder")
+}
+
+func TestExtractZipSymlink(t *testing.T) {
+	pkg, err := CreateArchivePackage(createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "bin/tool", mode: 0755, content: "#!/bin/sh\n"},
+		zipTestEntry{name: "tool", mode: os.ModeSymlink | 0777, content: "bin/tool"},
+	)), "")
+	assert.Nil(t, err)
+
+	target := filepath.Join(t.TempDir(), "fake_zip")
+	_, err = pkg.InstallTo(target)
+	assert.Nil(t, err)
+
+	link, err := os.Readlink(filepath.Join(target, "tool"))
+	assert.Nil(t, err)
+	assert.Equal(t, "bin/tool", link)
+}
+
+func TestExtractLimits(t *testing.T) {
+	withExtractLimits(t, 100, 3)
+
+	installFails(t, createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "big", mode: 0644, content: strings.Repeat("x", 101)},
+	)), "package_max_extract_size")
+
+	installFails(t, createTarPackage(t, TAR_GZ_FORMAT, []tarTestEntry{
+		{name: "./manifest.mf", typeflag: tar.TypeReg, mode: 0644, content: `{"pkgName": "fake_tar", "version": "1.0.0", "cmds": []}`},
+		{name: "./a", typeflag: tar.TypeReg, mode: 0644, content: strings.Repeat("x", 30)},
+		{name: "./b", typeflag: tar.TypeReg, mode: 0644, content: strings.Repeat("x", 30)},
+	}), "package_max_extract_size")
+
+	installFails(t, createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "a", mode: 0644},
+		zipTestEntry{name: "b", mode: 0644},
+		zipTestEntry{name: "c", mode: 0644},
+	)), "package_max_file_count")
+
+	// no limit
+	withExtractLimits(t, 0, 0)
+	pkg, err := CreateArchivePackage(createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "big", mode: 0644, content: strings.Repeat("x", 101)},
+		zipTestEntry{name: "a", mode: 0644},
+		zipTestEntry{name: "b", mode: 0644},
+		zipTestEntry{name: "c", mode: 0644},
+	)), "")
+	assert.Nil(t, err)
+	_, err = pkg.InstallTo(filepath.Join(t.TempDir(), "fake_zip"))
+	assert.Nil(t, err)
+}
+
+func TestExtractPermissionMask(t *testing.T) {
+	pkg, err := CreateArchivePackage(createZipPackage(t, withZipManifest(
+		zipTestEntry{name: "bin/", mode: os.ModeDir | 0777},
+		zipTestEntry{name: "bin/tool", mode: os.ModeSetuid | 0777, content: "#!/bin/sh\n"},
+		zipTestEntry{name: "data", mode: 0666, content: "data"},
+	)), "")
+	assert.Nil(t, err)
+
+	target := filepath.Join(t.TempDir(), "fake_zip")
+	_, err = pkg.InstallTo(target)
+	assert.Nil(t, err)
+
+	info, err := os.Stat(filepath.Join(target, "bin"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	info, err = os.Stat(filepath.Join(target, "bin", "tool"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode()&(os.ModePerm|os.ModeSetuid))
+
+	info, err = os.Stat(filepath.Join(target, "data"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+func TestUpdatePackageWithSymlinks(t *testing.T) {
+	pkgFile := createTarPackage(t, TAR_GZ_FORMAT, tarTestEntries)
+	target := filepath.Join(t.TempDir(), "fake_tar")
+
+	for i := 0; i < 2; i++ {
+		pkg, err := CreateArchivePackage(pkgFile, "")
+		assert.Nil(t, err)
+		_, err = pkg.InstallTo(target)
+		assert.Nil(t, err)
+	}
+
+	// the symlink is restored from the backup on failure
+	entries := append(append([]tarTestEntry{}, tarTestEntries...), tarTestEntry{name: "../evil", typeflag: tar.TypeReg, mode: 0644})
+	pkg, err := CreateArchivePackage(createTarPackage(t, TAR_GZ_FORMAT, entries), "")
+	assert.Nil(t, err)
+	_, err = pkg.InstallTo(target)
+	assert.NotNil(t, err)
+
+	link, err := os.Readlink(filepath.Join(target, "tool"))
+	assert.Nil(t, err)
+	assert.Equal(t, "bin/tool", link)
+}
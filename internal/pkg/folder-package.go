@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -41,15 +42,17 @@ func (pkg *folderPackage) InstallTo(targetDir string) (command.PackageManifest,
 		return nil, err
 	}
 
-	var err error
 	if viper.GetBool(config.ENABLE_PACKAGE_SETUP_HOOK_KEY) {
-		err = pkg.RunSetup(dstDir)
-		if err != nil {
+		if err := pkg.RunSetup(dstDir); err != nil {
 			return nil, err
 		}
 	}
 
-	return pkg.Manifest, err
+	if err := WriteFileHashes(dstDir); err != nil {
+		return nil, fmt.Errorf("cannot record the file hashes of the package %s: %v", pkg.Manifest.Name(), err)
+	}
+
+	return pkg.Manifest, nil
 }
 
 func (pkg *folderPackage) VerifyChecksum(checksum string) (bool, error) {
@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -11,59 +12,168 @@ import (
 	"github.com/criteo/command-launcher/internal/command"
 )
 
+// GIT_ORIGIN_FILE records where a git package has been installed from, it is
+// written in the installed package folder
+const GIT_ORIGIN_FILE = ".git-origin.json"
+
+// GitOrigin is the origin of a package installed from a git repository
+type GitOrigin struct {
+	Url    string `json:"url"`
+	Ref    string `json:"ref,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Commit string `json:"commit"`
+}
+
 type gitPackage struct {
 	folderPackage
+	origin   GitOrigin
+	cloneDir string
 }
 
-func CreateGitRepoPackage(urlAsString string) (command.Package, error) {
+// CreateGitRepoPackage clones a git repository to create a package from it.
+// The clone is kept in a temporary folder until RemoveGitClone is called.
+//
+// The ref is a tag, a branch, or a commit sha to checkout, the default branch
+// is used when it is empty. The subPath is the folder of the package inside
+// the repository, it must be relative to the repository root.
+func CreateGitRepoPackage(urlAsString string, ref string, subPath string) (command.Package, error) {
 	if _, err := url.Parse(urlAsString); err != nil {
 		return nil, fmt.Errorf("invalid url or pathname: %s (%v)", urlAsString, err)
 	}
 
+	if subPath != "" && !filepath.IsLocal(subPath) {
+		return nil, fmt.Errorf("invalid package path %s: must be a relative path inside the repository", subPath)
+	}
+
 	tmpDir, err := os.MkdirTemp("", "git-package-*")
 	if err != nil {
 		return nil, fmt.Errorf("cannot create the folder to clone the git repo: %v", err)
 	}
 
-	cloneDir, mf, err := cloneRepo(urlAsString, tmpDir)
+	cloneDir, commit, err := cloneRepo(urlAsString, ref, tmpDir)
 	if err != nil {
+		os.RemoveAll(tmpDir)
 		return nil, fmt.Errorf("git command has failed: %v", err)
 	}
 
+	pkgDir := filepath.Join(cloneDir, subPath)
+	mf, err := readManifestFromDir(pkgDir)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("cannot read the package manifest in %s: %v", subPath, err)
+	}
+
 	pkg := gitPackage{
 		folderPackage: folderPackage{
 			defaultPackage: defaultPackage{
 				Manifest: mf,
 			},
-			sourceDir: cloneDir,
+			sourceDir: pkgDir,
+		},
+		origin: GitOrigin{
+			Url:    urlAsString,
+			Ref:    ref,
+			Path:   subPath,
+			Commit: commit,
 		},
+		cloneDir: tmpDir,
 	}
 
 	return &pkg, nil
 }
 
-func cloneRepo(gitUrl string, targetDir string) (string, command.PackageManifest, error) {
-	ctx := exec.Command("git", "clone", gitUrl)
-	ctx.Dir = targetDir
-	ctx.Stdout = os.Stdout
-	ctx.Stderr = os.Stderr
-	ctx.Stdin = os.Stdin
+// InstallTo installs the package like a folder package, and records its origin
+func (pkg *gitPackage) InstallTo(targetDir string) (command.PackageManifest, error) {
+	mf, err := pkg.folderPackage.InstallTo(targetDir)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := ctx.Run(); err != nil {
-		return "", nil, fmt.Errorf("git command has failed: %v", err)
+	if err := pkg.origin.WriteToDir(filepath.Join(targetDir, pkg.Manifest.Name())); err != nil {
+		return nil, fmt.Errorf("cannot record the origin of the package %s: %v", pkg.Manifest.Name(), err)
 	}
 
-	repoDir := strings.ReplaceAll(filepath.Base(gitUrl), filepath.Ext(gitUrl), "")
-	manifestFile, err := os.Open(filepath.Join(targetDir, repoDir, "manifest.mf"))
+	return mf, nil
+}
+
+// GitOriginOf returns the origin of a package created from a git repository
+func GitOriginOf(p command.Package) (GitOrigin, bool) {
+	if gitPkg, ok := p.(*gitPackage); ok {
+		return gitPkg.origin, true
+	}
+	return GitOrigin{}, false
+}
+
+// RemoveGitClone removes the temporary clone of a package created from a git
+// repository, the other packages are left untouched
+func RemoveGitClone(p command.Package) error {
+	if gitPkg, ok := p.(*gitPackage); ok {
+		return os.RemoveAll(gitPkg.cloneDir)
+	}
+	return nil
+}
+
+// ReadGitOrigin reads the origin of a package installed from a git repository,
+// os.ErrNotExist is returned when the package was not installed from git
+func ReadGitOrigin(pkgDir string) (*GitOrigin, error) {
+	data, err := os.ReadFile(filepath.Join(pkgDir, GIT_ORIGIN_FILE))
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
-	defer manifestFile.Close()
 
-	mf, err := ReadManifest(manifestFile)
+	origin := GitOrigin{}
+	if err := json.Unmarshal(data, &origin); err != nil {
+		return nil, err
+	}
+	return &origin, nil
+}
+
+// WriteToDir writes the origin in the installed package folder
+func (origin GitOrigin) WriteToDir(pkgDir string) error {
+	data, err := json.MarshalIndent(origin, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(pkgDir, GIT_ORIGIN_FILE), data, 0644)
+}
+
+func cloneRepo(gitUrl string, ref string, targetDir string) (string, string, error) {
+	repoDir := filepath.Join(targetDir, "repo")
+	if err := runGit(targetDir, "clone", gitUrl, repoDir); err != nil {
+		return "", "", err
+	}
+
+	if ref != "" {
+		if err := runGit(repoDir, "-c", "advice.detachedHead=false", "checkout", ref); err != nil {
+			return "", "", fmt.Errorf("cannot checkout %s: %v", ref, err)
+		}
+	}
+
+	ctx := exec.Command("git", "rev-parse", "HEAD")
+	ctx.Dir = repoDir
+	out, err := ctx.Output()
 	if err != nil {
-		return "", nil, err
+		return "", "", fmt.Errorf("cannot get the commit of %s: %v", gitUrl, err)
 	}
 
-	return filepath.Join(targetDir, repoDir), mf, nil
+	return repoDir, strings.TrimSpace(string(out)), nil
+}
+
+func runGit(dir string, args ...string) error {
+	ctx := exec.Command("git", args...)
+	ctx.Dir = dir
+	ctx.Stdout = os.Stdout
+	ctx.Stderr = os.Stderr
+	ctx.Stdin = os.Stdin
+	return ctx.Run()
+}
+
+func readManifestFromDir(dir string) (command.PackageManifest, error) {
+	manifestFile, err := os.Open(filepath.Join(dir, "manifest.mf"))
+	if err != nil {
+		return nil, err
+	}
+	defer manifestFile.Close()
+
+	return ReadManifest(manifestFile)
 }
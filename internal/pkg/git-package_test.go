@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/criteo/command-launcher/internal/helper"
@@ -40,14 +41,14 @@ func createGitRepo(t *testing.T) string {
 }
 
 func TestGitRepo_Create_WrongRepo(t *testing.T) {
-	p, err := CreateGitRepoPackage("assets/folder-package")
+	p, err := CreateGitRepoPackage("assets/folder-package", "", "")
 	assert.Nil(t, p)
 	assert.NotNil(t, err)
 }
 
 func TestGitRepo_Create(t *testing.T) {
 	repo := createGitRepo(t)
-	p, err := CreateGitRepoPackage(repo)
+	p, err := CreateGitRepoPackage(repo, "", "")
 	assert.NotNil(t, p)
 	assert.Nil(t, err)
 
@@ -57,7 +58,7 @@ func TestGitRepo_Create(t *testing.T) {
 
 func TestGitRepo_InstallTo(t *testing.T) {
 	repo := createGitRepo(t)
-	p, err := CreateGitRepoPackage(repo)
+	p, err := CreateGitRepoPackage(repo, "", "")
 	assert.NotNil(t, p)
 	assert.Nil(t, err)
 
@@ -68,4 +69,73 @@ func TestGitRepo_InstallTo(t *testing.T) {
 
 	_, err = os.Stat(filepath.Join(targetDir, "fake_test", "manifest.mf"))
 	assert.Nil(t, err)
+
+	// the clone is removed, the installed package is kept
+	cloneDir := p.(*gitPackage).cloneDir
+	assert.Nil(t, RemoveGitClone(p))
+	_, err = os.Stat(cloneDir)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(targetDir, "fake_test", "manifest.mf"))
+	assert.Nil(t, err)
+}
+
+func gitInRepo(t *testing.T, repoDir string, args ...string) string {
+	ctx := exec.Command("git", args...)
+	ctx.Dir = repoDir
+	out, err := ctx.Output()
+	assert.Nil(t, err)
+	return strings.TrimSpace(string(out))
+}
+
+func TestGitRepo_InstallTo_RecordsOrigin(t *testing.T) {
+	repo := createGitRepo(t)
+	commit := gitInRepo(t, repo, "rev-parse", "HEAD")
+
+	p, err := CreateGitRepoPackage(repo, "", "")
+	assert.Nil(t, err)
+
+	targetDir := t.TempDir()
+	_, err = p.InstallTo(targetDir)
+	assert.Nil(t, err)
+
+	origin, err := ReadGitOrigin(filepath.Join(targetDir, "fake_test"))
+	assert.Nil(t, err)
+	assert.Equal(t, repo, origin.Url)
+	assert.Equal(t, "", origin.Ref)
+	assert.Equal(t, commit, origin.Commit)
+
+	_, err = ReadGitOrigin(t.TempDir())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGitRepo_Create_WithRefAndPath(t *testing.T) {
+	repo := createGitRepo(t)
+	gitInRepo(t, repo, "tag", "v1.0.0")
+	tagCommit := gitInRepo(t, repo, "rev-parse", "HEAD")
+
+	// move the package into a sub folder in a later commit
+	err := os.MkdirAll(filepath.Join(repo, "packages", "fake"), 0755)
+	assert.Nil(t, err)
+	gitInRepo(t, repo, "mv", "manifest.mf", "packages/fake/manifest.mf")
+	gitInRepo(t, repo, "commit", "-m", "move package")
+
+	p, err := CreateGitRepoPackage(repo, "", "packages/fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "fake_test", p.Name())
+	origin, ok := GitOriginOf(p)
+	assert.True(t, ok)
+	assert.Equal(t, "packages/fake", origin.Path)
+
+	p, err = CreateGitRepoPackage(repo, "v1.0.0", "")
+	assert.Nil(t, err)
+	origin, _ = GitOriginOf(p)
+	assert.Equal(t, "v1.0.0", origin.Ref)
+	assert.Equal(t, tagCommit, origin.Commit)
+
+	_, err = CreateGitRepoPackage(repo, "unknown-ref", "")
+	assert.NotNil(t, err)
+
+	_, err = CreateGitRepoPackage(repo, "", "../outside")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid package path")
 }
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/criteo/command-launcher/internal/context"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+/*
+Package lifecycle hooks are system commands defined in the package manifest.
+
+- __setup__: called once the package is installed
+- __pre_update__: called on the installed version before it is replaced, receives the old and new versions
+- __post_update__: called on the new version once it is installed, receives the old and new versions
+- __migrate__: called on the new version once it is installed, receives the old and new versions
+- __uninstall__: called before the package is removed from the local repository
+*/
+const (
+	SETUP_HOOK       = "__setup__"
+	PRE_UPDATE_HOOK  = "__pre_update__"
+	POST_UPDATE_HOOK = "__post_update__"
+	MIGRATE_HOOK     = "__migrate__"
+	UNINSTALL_HOOK   = "__uninstall__"
+)
+
+// HasHook checks if the package defines the given hook
+func HasHook(pkg command.PackageManifest, hookName string) bool {
+	return findHook(pkg, hookName) != nil
+}
+
+// ExecHookFromPackage runs the hook system command of a package with the given arguments.
+// The hook is killed once the configured PACKAGE_HOOK_TIMEOUT is reached.
+// It returns nil when the package doesn't define the hook.
+func ExecHookFromPackage(pkg command.PackageManifest, hookName string, pkgDir string, args ...string) error {
+	hook := findHook(pkg, hookName)
+	if hook == nil {
+		log.Debugf("No %s hook defined for package %s", hookName, pkg.Name())
+		return nil
+	}
+
+	if pkgDir != "" {
+		hook.SetPackageDir(pkgDir)
+	}
+
+	timeout := viper.GetDuration(config.PACKAGE_HOOK_TIMEOUT_KEY)
+	if _, err := hook.ExecuteWithTimeout(hookEnvContext(hook), timeout, args...); err != nil {
+		return fmt.Errorf("%s hook of package %s failed to execute: %v", hookLabel(hookName), pkg.Name(), err)
+	}
+	return nil
+}
+
+func findHook(pkg command.PackageManifest, hookName string) command.Command {
+	for _, c := range pkg.Commands() {
+		if c.Name() == hookName && c.Type() == "system" {
+			return c
+		}
+	}
+	return nil
+}
+
+// hook label used in messages, ex: __pre_update__ -> pre_update
+func hookLabel(hookName string) string {
+	return strings.Trim(hookName, "_")
+}
+
+// hookEnvContext returns the environment variables passed to a hook, they
+// are the same ones passed to a normal command that doesn't request any resource
+func hookEnvContext(hook command.Command) []string {
+	appCtx, err := context.AppContext()
+	if err != nil {
+		return []string{}
+	}
+	return CommandEnvContext(appCtx, []string{}, hook.PackageDir(), fmt.Sprintf("%s %s", appCtx.AppName(), hook.Name()))
+}
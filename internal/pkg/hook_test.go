@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func hookManifest(hookName string, executable string, args ...string) command.PackageManifest {
+	return &defaultPackageManifest{
+		PkgName:    "hooked",
+		PkgVersion: "1.0.0",
+		PkgCommands: []*command.DefaultCommand{
+			{CmdName: hookName, CmdType: "system", CmdExecutable: executable, CmdArguments: args},
+			{CmdName: "hooked", CmdType: "executable", CmdExecutable: "echo"},
+		},
+	}
+}
+
+func TestExecHookFromPackage(t *testing.T) {
+	mf := hookManifest(PRE_UPDATE_HOOK, "true")
+	assert.True(t, HasHook(mf, PRE_UPDATE_HOOK))
+	assert.False(t, HasHook(mf, POST_UPDATE_HOOK))
+
+	assert.Nil(t, ExecHookFromPackage(mf, PRE_UPDATE_HOOK, "", "1.0.0", "2.0.0"))
+	// undefined hooks are ignored
+	assert.Nil(t, ExecHookFromPackage(mf, POST_UPDATE_HOOK, ""))
+
+	err := ExecHookFromPackage(hookManifest(UNINSTALL_HOOK, "false"), UNINSTALL_HOOK, "")
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "uninstall hook of package hooked failed to execute"))
+}
+
+func TestExecHookFromPackage_Timeout(t *testing.T) {
+	previous := viper.GetDuration(config.PACKAGE_HOOK_TIMEOUT_KEY)
+	defer viper.Set(config.PACKAGE_HOOK_TIMEOUT_KEY, previous)
+	viper.Set(config.PACKAGE_HOOK_TIMEOUT_KEY, 100*time.Millisecond)
+
+	start := time.Now()
+	err := ExecHookFromPackage(hookManifest(MIGRATE_HOOK, "sleep", "5"), MIGRATE_HOOK, "")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
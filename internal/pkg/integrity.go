@@ -0,0 +1,140 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FILE_HASHES_FILE records the sha256 hash of each file of an installed
+// package, it is written in the installed package folder
+const FILE_HASHES_FILE = ".file-hashes.json"
+
+// the files written by command launcher in the installed package folder
+var integrityExcludedFiles = map[string]bool{
+	FILE_HASHES_FILE:   true,
+	GIT_ORIGIN_FILE:    true,
+	REMOTE_ORIGIN_FILE: true,
+}
+
+// FileHashes maps the path of each file, relative to the package folder, to
+// its hash: "sha256:<hex>" for a regular file, "symlink:<target>" for a link
+type FileHashes map[string]string
+
+// IntegrityReport lists the differences between the installed files of a
+// package and the file hashes recorded during its installation
+type IntegrityReport struct {
+	Modified []string
+	Missing  []string
+	// Extra files are added after the installation, usually written by the
+	// package itself at runtime (caches, virtual envs), they don't break the
+	// integrity of the installed files
+	Extra []string
+}
+
+// IsIntact checks if the installed files are the ones recorded during
+// installation, the extra files are ignored
+func (report *IntegrityReport) IsIntact() bool {
+	return len(report.Modified) == 0 && len(report.Missing) == 0
+}
+
+// ComputeFileHashes computes the hashes of the files in the package folder
+func ComputeFileHashes(pkgDir string) (FileHashes, error) {
+	hashes := FileHashes{}
+	err := filepath.WalkDir(pkgDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(pkgDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." || d.IsDir() || integrityExcludedFiles[rel] {
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			hashes[rel] = "symlink:" + target
+			return nil
+		}
+
+		sha, err := packageChecksum(path)
+		if err != nil {
+			return err
+		}
+		hashes[rel] = fmt.Sprintf("sha256:%x", sha)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute the file hashes of %s: %v", pkgDir, err)
+	}
+	return hashes, nil
+}
+
+// WriteFileHashes records the hashes of the files in the installed package folder
+func WriteFileHashes(pkgDir string) error {
+	hashes, err := ComputeFileHashes(pkgDir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(hashes, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(pkgDir, FILE_HASHES_FILE), data, 0644)
+}
+
+// ReadFileHashes reads the file hashes recorded during the package installation,
+// os.ErrNotExist is returned when the package has been installed without them
+func ReadFileHashes(pkgDir string) (FileHashes, error) {
+	data, err := os.ReadFile(filepath.Join(pkgDir, FILE_HASHES_FILE))
+	if err != nil {
+		return nil, err
+	}
+	hashes := FileHashes{}
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return nil, fmt.Errorf("cannot read the file hashes of %s: %v", pkgDir, err)
+	}
+	return hashes, nil
+}
+
+// VerifyPackageFiles compares the files in the package folder with the file
+// hashes recorded during the package installation
+func VerifyPackageFiles(pkgDir string) (*IntegrityReport, error) {
+	expected, err := ReadFileHashes(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := ComputeFileHashes(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := IntegrityReport{}
+	for file, hash := range expected {
+		actualHash, exists := actual[file]
+		if !exists {
+			report.Missing = append(report.Missing, file)
+		} else if actualHash != hash {
+			report.Modified = append(report.Modified, file)
+		}
+	}
+	for file := range actual {
+		if _, exists := expected[file]; !exists {
+			report.Extra = append(report.Extra, file)
+		}
+	}
+
+	sort.Strings(report.Modified)
+	sort.Strings(report.Missing)
+	sort.Strings(report.Extra)
+	return &report, nil
+}
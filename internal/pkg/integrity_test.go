@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPackageFiles(t *testing.T) {
+	pkg, err := CreateArchivePackage(createTarPackage(t, TAR_GZ_FORMAT, tarTestEntries), "")
+	assert.Nil(t, err)
+
+	target := filepath.Join(t.TempDir(), "fake_tar")
+	_, err = pkg.InstallTo(target)
+	assert.Nil(t, err)
+
+	hashes, err := ReadFileHashes(target)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(hashes))
+	assert.Equal(t, "symlink:bin/tool", hashes["tool"])
+	assert.Contains(t, hashes["bin/tool"], "sha256:")
+
+	report, err := VerifyPackageFiles(target)
+	assert.Nil(t, err)
+	assert.True(t, report.IsIntact())
+
+	// files written at runtime are extra, the package stays intact
+	assert.Nil(t, os.WriteFile(filepath.Join(target, "bin", "extra"), []byte("extra"), 0644))
+	report, err = VerifyPackageFiles(target)
+	assert.Nil(t, err)
+	assert.True(t, report.IsIntact())
+	assert.Equal(t, []string{"bin/extra"}, report.Extra)
+
+	// change the installed files
+	assert.Nil(t, os.WriteFile(filepath.Join(target, "bin", "tool"), []byte("changed"), 0755))
+	assert.Nil(t, os.Remove(filepath.Join(target, "tool")))
+	assert.Nil(t, os.WriteFile(filepath.Join(target, GIT_ORIGIN_FILE), []byte("{}"), 0644))
+	assert.Nil(t, RemoteOrigin{Remote: "default", Version: "1.0.0"}.WriteToDir(target))
+
+	report, err = VerifyPackageFiles(target)
+	assert.Nil(t, err)
+	assert.False(t, report.IsIntact())
+	assert.Equal(t, []string{"bin/tool"}, report.Modified)
+	assert.Equal(t, []string{"tool"}, report.Missing)
+	assert.Equal(t, []string{"bin/extra"}, report.Extra)
+}
+
+func TestVerifyPackageFiles_NoHashes(t *testing.T) {
+	_, err := VerifyPackageFiles(t.TempDir())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFolderPackageWritesFileHashes(t *testing.T) {
+	pkg, err := CreateFolderPackage("assets/folder-package")
+	assert.Nil(t, err)
+
+	target := t.TempDir()
+	mf, err := pkg.InstallTo(target)
+	assert.Nil(t, err)
+
+	report, err := VerifyPackageFiles(filepath.Join(target, mf.Name()))
+	assert.Nil(t, err)
+	assert.True(t, report.IsIntact())
+}
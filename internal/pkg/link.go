@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/command"
+)
+
+// LinkPackage links a package folder into the target repository folder, the
+// package is not copied: a symbolic link named after the package is created,
+// so that changes in the package folder are visible without re-installation.
+func LinkPackage(srcDir string, targetDir string) (command.PackageManifest, error) {
+	absSrcDir, err := filepath.Abs(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid package folder %s: %v", srcDir, err)
+	}
+	if info, err := os.Stat(absSrcDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("invalid package folder %s: not a directory", srcDir)
+	}
+
+	mf, err := readManifestFromDir(absSrcDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the package manifest in %s: %v", srcDir, err)
+	}
+	if mf.Name() == "" || !filepath.IsLocal(mf.Name()) || strings.ContainsAny(mf.Name(), `/\`) {
+		return nil, fmt.Errorf("invalid package name '%s' in the manifest of %s", mf.Name(), srcDir)
+	}
+
+	linkPath := filepath.Join(targetDir, mf.Name())
+	if _, err := os.Lstat(linkPath); err == nil {
+		return nil, fmt.Errorf("cannot link the package %s: %s already exists", mf.Name(), linkPath)
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create the folder %s: %v", targetDir, err)
+	}
+	if err := os.Symlink(absSrcDir, linkPath); err != nil {
+		return nil, fmt.Errorf("cannot link the package %s: %v", mf.Name(), err)
+	}
+
+	return mf, nil
+}
+
+// UnlinkPackage removes the link of a linked package, the linked folder is kept
+func UnlinkPackage(pkgDir string) error {
+	if _, linked := LinkTarget(pkgDir); !linked {
+		return fmt.Errorf("%s is not a linked package", pkgDir)
+	}
+	return os.Remove(pkgDir)
+}
+
+// LinkTarget returns the folder that a linked package points to
+func LinkTarget(pkgDir string) (string, bool) {
+	info, err := os.Lstat(pkgDir)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+	target, err := os.Readlink(pkgDir)
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
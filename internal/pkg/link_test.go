@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkPackage(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "my-pkg")
+	assert.Nil(t, os.Mkdir(srcDir, 0755))
+	err := helper.CopyLocalFile("assets/folder-package/manifest.mf", filepath.Join(srcDir, "manifest.mf"), false)
+	assert.Nil(t, err)
+
+	dropinDir := filepath.Join(t.TempDir(), "dropins")
+	mf, err := LinkPackage(srcDir, dropinDir)
+	assert.Nil(t, err)
+	assert.Equal(t, "fake_test", mf.Name())
+
+	linkPath := filepath.Join(dropinDir, "fake_test")
+	target, linked := LinkTarget(linkPath)
+	assert.True(t, linked)
+	assert.Equal(t, srcDir, target)
+
+	// the package is visible through the link
+	_, err = os.Stat(filepath.Join(linkPath, "manifest.mf"))
+	assert.Nil(t, err)
+
+	// link twice
+	_, err = LinkPackage(srcDir, dropinDir)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+
+	assert.Nil(t, UnlinkPackage(linkPath))
+	_, err = os.Lstat(linkPath)
+	assert.True(t, os.IsNotExist(err))
+	// the linked folder is kept
+	_, err = os.Stat(filepath.Join(srcDir, "manifest.mf"))
+	assert.Nil(t, err)
+}
+
+func TestLinkPackage_InvalidFolder(t *testing.T) {
+	dropinDir := t.TempDir()
+
+	_, err := LinkPackage(filepath.Join(t.TempDir(), "not-exist"), dropinDir)
+	assert.NotNil(t, err)
+
+	// no manifest
+	_, err = LinkPackage(t.TempDir(), dropinDir)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cannot read the package manifest")
+}
+
+func TestUnlinkPackage_NotLinked(t *testing.T) {
+	pkgDir := t.TempDir()
+	_, linked := LinkTarget(pkgDir)
+	assert.False(t, linked)
+
+	err := UnlinkPackage(pkgDir)
+	assert.NotNil(t, err)
+	_, err = os.Stat(pkgDir)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// REMOTE_ORIGIN_FILE records the remote registry a dropin package has been
+// installed from, it is written in the installed package folder
+const REMOTE_ORIGIN_FILE = ".remote-origin.json"
+
+// RemoteOrigin is the origin of a dropin package installed from a remote
+// registry, managed packages don't need it: their remote is the one of their
+// repository
+type RemoteOrigin struct {
+	Remote  string `json:"remote"`
+	Version string `json:"version"`
+}
+
+// ReadRemoteOrigin reads the origin of a dropin package installed from a
+// remote, os.ErrNotExist is returned when the package was not installed from
+// a remote
+func ReadRemoteOrigin(pkgDir string) (*RemoteOrigin, error) {
+	data, err := os.ReadFile(filepath.Join(pkgDir, REMOTE_ORIGIN_FILE))
+	if err != nil {
+		return nil, err
+	}
+
+	origin := RemoteOrigin{}
+	if err := json.Unmarshal(data, &origin); err != nil {
+		return nil, err
+	}
+	return &origin, nil
+}
+
+// WriteToDir writes the origin in the installed package folder
+func (origin RemoteOrigin) WriteToDir(pkgDir string) error {
+	data, err := json.MarshalIndent(origin, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(pkgDir, REMOTE_ORIGIN_FILE), data, 0644)
+}
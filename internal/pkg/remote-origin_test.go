@@ -0,0 +1,20 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteOrigin(t *testing.T) {
+	pkgDir := t.TempDir()
+	_, err := ReadRemoteOrigin(pkgDir)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Nil(t, RemoteOrigin{Remote: "default", Version: "0.0.2"}.WriteToDir(pkgDir))
+	origin, err := ReadRemoteOrigin(pkgDir)
+	assert.Nil(t, err)
+	assert.Equal(t, "default", origin.Remote)
+	assert.Equal(t, "0.0.2", origin.Version)
+}
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// tarFormat is a tar archive compressed with gzip or zstd, unlike zip, it
+// keeps the symbolic links and the permissions of the packaged files
+type tarFormat struct {
+	decompress func(r io.Reader) (io.ReadCloser, error)
+}
+
+func gzipReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func zstdReader(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
+
+func (f *tarFormat) readManifest(archiveFile string) (command.PackageManifest, error) {
+	var mf command.PackageManifest
+	err := f.walk(archiveFile, func(hdr *tar.Header, name string, content io.Reader) error {
+		if name != "manifest.mf" || hdr.Typeflag != tar.TypeReg {
+			return nil
+		}
+		payload, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("failed to open the manifest: %s", err)
+		}
+		if mf, err = parseManifest(payload); err != nil {
+			return fmt.Errorf("failed to read the manifest: %s", err)
+		}
+		return io.EOF
+	})
+	if err != nil {
+		return nil, err
+	}
+	if mf == nil {
+		return nil, fmt.Errorf("failed to open the manifest: manifest.mf not found in %s", archiveFile)
+	}
+	return mf, nil
+}
+
+func (f *tarFormat) extract(archiveFile string, targetDir string) error {
+	e := newExtractor(targetDir)
+	return f.walk(archiveFile, func(hdr *tar.Header, name string, content io.Reader) error {
+		return extractTarEntry(e, hdr, name, content)
+	})
+}
+
+// walk calls the handler on each entry of the archive, with the entry name
+// relative to the archive root, the walk stops once the handler returns io.EOF
+func (f *tarFormat) walk(archiveFile string, handler func(hdr *tar.Header, name string, content io.Reader) error) error {
+	file, err := os.Open(archiveFile)
+	if err != nil {
+		return fmt.Errorf("failed to open: %s", err)
+	}
+	defer file.Close()
+
+	reader, err := f.decompress(file)
+	if err != nil {
+		return fmt.Errorf("failed to decompress %s: %v", archiveFile, err)
+	}
+	defer reader.Close()
+
+	tarReader := tar.NewReader(reader)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", archiveFile, err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if err := handler(hdr, name, tarReader); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func extractTarEntry(e *extractor, hdr *tar.Header, name string, content io.Reader) error {
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return e.mkdir(name)
+	case tar.TypeReg:
+		return e.writeFile(name, hdr.FileInfo().Mode(), content)
+	case tar.TypeSymlink:
+		return e.symlink(name, hdr.Linkname)
+	default:
+		log.Warnf("Skip unsupported entry %s in the package archive", name)
+	}
+
+	return nil
+}
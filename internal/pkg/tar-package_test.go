@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+type tarTestEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+var tarTestEntries = []tarTestEntry{
+	{name: "./", typeflag: tar.TypeDir, mode: 0755},
+	{name: "./manifest.mf", typeflag: tar.TypeReg, mode: 0644, content: `{"pkgName": "fake_tar", "version": "1.0.0", "cmds": []}`},
+	{name: "./bin/", typeflag: tar.TypeDir, mode: 0755},
+	{name: "./bin/tool", typeflag: tar.TypeReg, mode: 0755, content: "#!/bin/sh\necho tool\n"},
+	{name: "./tool", typeflag: tar.TypeSymlink, linkname: "bin/tool"},
+}
+
+func createTarPackage(t *testing.T, format string, entries []tarTestEntry) string {
+	t.Helper()
+	pkgFile := filepath.Join(t.TempDir(), "fake-tar-1.0.0.pkg")
+	f, err := os.Create(pkgFile)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	var compressed io.WriteCloser
+	switch format {
+	case TAR_GZ_FORMAT:
+		compressed = gzip.NewWriter(f)
+	case TAR_ZST_FORMAT:
+		compressed, err = zstd.NewWriter(f)
+		assert.Nil(t, err)
+	}
+
+	tw := tar.NewWriter(compressed)
+	for _, e := range entries {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     e.mode,
+			Size:     int64(len(e.content)),
+			Linkname: e.linkname,
+		})
+		assert.Nil(t, err)
+		if e.typeflag == tar.TypeReg {
+			_, err = tw.Write([]byte(e.content))
+			assert.Nil(t, err)
+		}
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, compressed.Close())
+	return pkgFile
+}
+
+func TestDetectPackageFormat(t *testing.T) {
+	format, err := DetectPackageFormat("assets/fake-1.0.0.pkg")
+	assert.Nil(t, err)
+	assert.Equal(t, ZIP_FORMAT, format)
+
+	format, err = DetectPackageFormat(createTarPackage(t, TAR_GZ_FORMAT, tarTestEntries))
+	assert.Nil(t, err)
+	assert.Equal(t, TAR_GZ_FORMAT, format)
+
+	format, err = DetectPackageFormat(createTarPackage(t, TAR_ZST_FORMAT, tarTestEntries))
+	assert.Nil(t, err)
+	assert.Equal(t, TAR_ZST_FORMAT, format)
+
+	_, err = DetectPackageFormat("assets/fake.mf")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown package format")
+}
+
+func TestInstallTarPackage(t *testing.T) {
+	for _, format := range []string{TAR_GZ_FORMAT, TAR_ZST_FORMAT} {
+		pkgFile := createTarPackage(t, format, tarTestEntries)
+
+		// both the specified format and the detected one
+		for _, specifiedFormat := range []string{format, ""} {
+			pkg, err := CreateArchivePackage(pkgFile, specifiedFormat)
+			assert.Nil(t, err, format)
+			assert.Equal(t, "fake_tar", pkg.Name())
+			assert.Equal(t, "1.0.0", pkg.Version())
+
+			target := filepath.Join(t.TempDir(), "fake_tar")
+			_, err = pkg.InstallTo(target)
+			assert.Nil(t, err, format)
+
+			// permissions and symlinks are kept
+			info, err := os.Stat(filepath.Join(target, "bin", "tool"))
+			assert.Nil(t, err)
+			assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+			link, err := os.Readlink(filepath.Join(target, "tool"))
+			assert.Nil(t, err)
+			assert.Equal(t, "bin/tool", link)
+		}
+	}
+}
+
+func TestInstallTarPackageRestoresBackup(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "fake_tar")
+	assert.Nil(t, os.MkdirAll(target, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(target, "VERSION"), []byte("0.9.0"), 0644))
+
+	// a tar entry that cannot be extracted: a file under a symlink to a file
+	entries := append([]tarTestEntry{}, tarTestEntries...)
+	entries = append(entries, tarTestEntry{name: "./tool/broken", typeflag: tar.TypeReg, mode: 0644, content: "broken"})
+	pkg, err := CreateArchivePackage(createTarPackage(t, TAR_GZ_FORMAT, entries), "")
+	assert.Nil(t, err)
+
+	_, err = pkg.InstallTo(target)
+	assert.NotNil(t, err)
+
+	content, err := os.ReadFile(filepath.Join(target, "VERSION"))
+	assert.Nil(t, err)
+	assert.Equal(t, "0.9.0", string(content))
+}
+
+func TestCreateArchivePackage_WrongFormat(t *testing.T) {
+	_, err := CreateArchivePackage("assets/fake-1.0.0.pkg", TAR_GZ_FORMAT)
+	assert.NotNil(t, err)
+
+	_, err = CreateArchivePackage("assets/fake-1.0.0.pkg", "rar")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unsupported package format rar")
+
+	_, err = CreateArchivePackage(createTarPackage(t, TAR_GZ_FORMAT, tarTestEntries[2:]), "")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "manifest.mf not found")
+}
+
+func TestVerifyTarPackageChecksum(t *testing.T) {
+	pkgFile := createTarPackage(t, TAR_ZST_FORMAT, tarTestEntries)
+	pkg, err := CreateArchivePackage(pkgFile, "")
+	assert.Nil(t, err)
+
+	sha, err := packageChecksum(pkgFile)
+	assert.Nil(t, err)
+
+	ok, err := pkg.VerifyChecksum(fmt.Sprintf("%x", sha))
+	assert.True(t, ok)
+	assert.Nil(t, err)
+
+	ok, err = pkg.VerifyChecksum("wrong-checksum")
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+}
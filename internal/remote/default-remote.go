@@ -144,7 +144,12 @@ func (remote *defaultRemoteRepository) Package(pkgName string, pkgVersion string
 		return nil, fmt.Errorf("error downloading %s: %v", url, err)
 	}
 
-	pkg, err := pkg.CreateZipPackage(pkgPathname)
+	format := ""
+	if pkgInfo, err := remote.findPackage(pkgName, pkgVersion); err == nil {
+		format = pkgInfo.Format
+	}
+
+	pkg, err := pkg.CreateArchivePackage(pkgPathname, format)
 	if err != nil {
 		return nil, fmt.Errorf("invalid package %s: %v", url, err)
 	}
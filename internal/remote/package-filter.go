@@ -0,0 +1,49 @@
+package remote
+
+import (
+	"fmt"
+	"path"
+)
+
+// PackageFilter selects the packages of a remote registry with glob
+// patterns on their names, ex: "infra-*". A package is selected when it
+// matches one of the include patterns, or when there is no include pattern,
+// and when it matches none of the exclude patterns.
+type PackageFilter struct {
+	Include []string
+	Exclude []string
+}
+
+func NewPackageFilter(include []string, exclude []string) (PackageFilter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return PackageFilter{}, fmt.Errorf("invalid package pattern %s: %v", pattern, err)
+		}
+	}
+	return PackageFilter{Include: include, Exclude: exclude}, nil
+}
+
+// IsEmpty checks if the filter selects all packages
+func (f PackageFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Match checks if the package is selected by the filter
+func (f PackageFilter) Match(name string) bool {
+	included := len(f.Include) == 0
+	for _, pattern := range f.Include {
+		if matched, _ := path.Match(pattern, name); matched {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range f.Exclude {
+		if matched, _ := path.Match(pattern, name); matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageFilter(t *testing.T) {
+	all := PackageFilter{}
+	assert.True(t, all.IsEmpty())
+	assert.True(t, all.Match("anything"))
+
+	filter, err := NewPackageFilter([]string{"infra-*", "hotfix"}, []string{"infra-legacy*"})
+	assert.Nil(t, err)
+	assert.True(t, filter.Match("infra-tools"))
+	assert.True(t, filter.Match("hotfix"))
+	assert.False(t, filter.Match("hotfix-2"))
+	assert.False(t, filter.Match("infra-legacy-tools"))
+	assert.False(t, filter.Match("data-tools"))
+
+	filter, err = NewPackageFilter([]string{}, []string{"*-experimental"})
+	assert.Nil(t, err)
+	assert.True(t, filter.Match("infra-tools"))
+	assert.False(t, filter.Match("infra-experimental"))
+
+	_, err = NewPackageFilter([]string{"infra-["}, []string{})
+	assert.NotNil(t, err)
+}
@@ -11,6 +11,7 @@ type PackageInfo struct {
 	Version        string `json:"version"`
 	Url            string `json:"url"`
 	Checksum       string `json:"checksum"`
+	Format         string `json:"format,omitempty"` // zip, tar.gz, or tar.zst, detected from the file content when empty
 	StartPartition uint8  `json:"startPartition"`
 	EndPartition   uint8  `json:"endPartition"`
 }
@@ -0,0 +1,123 @@
+package remote
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// VersionConstraint selects the package versions, it is a space separated
+// list of conditions that must all be satisfied:
+//
+//	2.1.0     exactly the version 2.1.0
+//	^2.1      compatible versions: >=2.1.0 and <3.0.0 (<0.3.0 for ^0.2)
+//	~2.1      patch versions: >=2.1.0 and <2.2.0
+//	>=2.1 <3  comparisons with >, >=, <, <=, or =
+//
+// An empty constraint, or "*", selects all versions.
+type VersionConstraint struct {
+	raw        string
+	conditions []versionCondition
+}
+
+type versionCondition struct {
+	op      string
+	version defaultVersion
+}
+
+var constraintVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(\.[0-9]+)?([-_][a-zA-Z0-9]+)?$`)
+
+func ParseVersionConstraint(constraint string) (VersionConstraint, error) {
+	c := VersionConstraint{raw: strings.TrimSpace(constraint), conditions: []versionCondition{}}
+	if c.raw == "" || c.raw == "*" {
+		return c, nil
+	}
+
+	for _, field := range strings.Fields(c.raw) {
+		op := ""
+		for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(field, prefix) {
+				op = prefix
+				break
+			}
+		}
+		value := strings.TrimPrefix(field, op)
+		if !constraintVersionPattern.MatchString(value) {
+			return VersionConstraint{}, fmt.Errorf("invalid version constraint %s", constraint)
+		}
+		var v defaultVersion
+		if err := ParseVersion(value, &v); err != nil {
+			return VersionConstraint{}, fmt.Errorf("invalid version constraint %s: %v", constraint, err)
+		}
+
+		switch op {
+		case "^":
+			upper := defaultVersion{Major: v.Major + 1}
+			if v.Major == 0 {
+				upper = defaultVersion{Minor: v.Minor + 1}
+			}
+			c.conditions = append(c.conditions, versionCondition{">=", v}, versionCondition{"<", upper})
+		case "~":
+			upper := defaultVersion{Major: v.Major, Minor: v.Minor + 1}
+			if strings.Count(value, ".") == 0 {
+				upper = defaultVersion{Major: v.Major + 1}
+			}
+			c.conditions = append(c.conditions, versionCondition{">=", v}, versionCondition{"<", upper})
+		case "":
+			c.conditions = append(c.conditions, versionCondition{"=", v})
+		default:
+			c.conditions = append(c.conditions, versionCondition{op, v})
+		}
+	}
+	return c, nil
+}
+
+// Check checks if a version satisfies the constraint
+func (c VersionConstraint) Check(version string) bool {
+	if len(c.conditions) == 0 {
+		return true
+	}
+	var v defaultVersion
+	if err := ParseVersion(version, &v); err != nil {
+		return false
+	}
+	for _, cond := range c.conditions {
+		less, greater := Less(v, cond.version), Less(cond.version, v)
+		ok := false
+		switch cond.op {
+		case "=":
+			ok = !less && !greater
+		case ">":
+			ok = greater
+		case ">=":
+			ok = !less
+		case "<":
+			ok = less
+		case "<=":
+			ok = !greater
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Latest returns the highest version satisfying the constraint, or an empty
+// string if none
+func (c VersionConstraint) Latest(versions []string) string {
+	latest := ""
+	for _, v := range versions {
+		if c.Check(v) && (latest == "" || IsVersionSmaller(latest, v)) {
+			latest = v
+		}
+	}
+	return latest
+}
+
+func (c VersionConstraint) String() string {
+	if c.raw == "" {
+		return "*"
+	}
+	return c.raw
+}
@@ -0,0 +1,49 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionConstraint(t *testing.T) {
+	cases := []struct {
+		constraint string
+		accepted   []string
+		rejected   []string
+	}{
+		{"", []string{"0.0.1", "2.1.0"}, []string{}},
+		{"*", []string{"0.0.1", "2.1.0"}, []string{}},
+		{"2.1.0", []string{"2.1.0"}, []string{"2.1.1", "2.0.9"}},
+		{"^2.1", []string{"2.1.0", "2.1.5", "2.9.0"}, []string{"2.0.9", "3.0.0", "1.9.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.2.2", "0.3.0"}},
+		{"~2.1", []string{"2.1.0", "2.1.9"}, []string{"2.2.0", "2.0.0"}},
+		{"~2", []string{"2.0.0", "2.9.0"}, []string{"3.0.0"}},
+		{">=2.1 <3", []string{"2.1.0", "2.5.1"}, []string{"2.0.0", "3.0.0"}},
+		{">1.0.0 <=1.2.0", []string{"1.0.1", "1.2.0"}, []string{"1.0.0", "1.2.1"}},
+	}
+	for _, c := range cases {
+		constraint, err := ParseVersionConstraint(c.constraint)
+		assert.Nil(t, err)
+		for _, v := range c.accepted {
+			assert.True(t, constraint.Check(v), "%s should accept %s", c.constraint, v)
+		}
+		for _, v := range c.rejected {
+			assert.False(t, constraint.Check(v), "%s should reject %s", c.constraint, v)
+		}
+	}
+}
+
+func TestVersionConstraintLatest(t *testing.T) {
+	constraint, err := ParseVersionConstraint("^2.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "2.10.0", constraint.Latest([]string{"2.0.0", "2.1.0", "2.10.0", "2.9.1", "3.0.0"}))
+	assert.Equal(t, "", constraint.Latest([]string{"1.0.0", "3.0.0"}))
+}
+
+func TestInvalidVersionConstraint(t *testing.T) {
+	for _, c := range []string{"abc", "^", ">=2.x", "2.1.0.4", "!2"} {
+		_, err := ParseVersionConstraint(c)
+		assert.NotNil(t, err, c)
+	}
+}
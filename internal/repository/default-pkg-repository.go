@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/config"
 	"github.com/criteo/command-launcher/internal/console"
 	"github.com/criteo/command-launcher/internal/context"
+	"github.com/criteo/command-launcher/internal/pkg"
+	"github.com/criteo/command-launcher/internal/updateConfig"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 const (
@@ -66,7 +71,8 @@ func (repo *defaultPackageRepository) Install(pkg command.Package) error {
 	pkgDir := filepath.Join(repo.RepoDir, pkg.Name())
 	_, err := pkg.InstallTo(pkgDir)
 	if err != nil {
-		err := repo.repoIndex.PausePackageUpdate(pkg.Name())
+		err := repo.repoIndex.PausePackageUpdate(pkg.Name(), updateConfig.DEFAULT_UPDATE_PAUSE_DURATION,
+			fmt.Sprintf("installation failed: %v", err))
 		if err != nil {
 			console.Warn("Failed to pause update for package %s: %v", pkg.Name(), err)
 		} else {
@@ -90,12 +96,20 @@ func (repo *defaultPackageRepository) Install(pkg command.Package) error {
 }
 
 func (repo *defaultPackageRepository) Uninstall(name string) error {
+	pkgDir := filepath.Join(repo.RepoDir, name)
+	if installed, err := repo.repoIndex.Package(name); err == nil && lifecycleHookEnabled() {
+		// a failing uninstall hook keeps the package installed
+		if err := pkg.ExecHookFromPackage(installed, pkg.UNINSTALL_HOOK, pkgDir); err != nil {
+			return fmt.Errorf("cannot uninstall the package %s: %v", name, err)
+		}
+	}
+
 	err := repo.repoIndex.Remove(name, repo.RepoDir)
 	if err != nil {
 		return fmt.Errorf("cannot remove the command %s: %v", name, err)
 	}
 
-	err = os.RemoveAll(filepath.Join(repo.RepoDir, name))
+	err = os.RemoveAll(pkgDir)
 	if err != nil {
 		return fmt.Errorf("cannot remove the command folder %v", err)
 	}
@@ -103,13 +117,73 @@ func (repo *defaultPackageRepository) Uninstall(name string) error {
 	return nil
 }
 
-func (repo *defaultPackageRepository) Update(pkg command.Package) error {
-	err := repo.Uninstall(pkg.Name())
+// Update replaces the installed version of the package with the new one.
+//
+// When the lifecycle hooks are enabled, the __pre_update__ hook of the installed
+// version is called first, then the __migrate__ and the __post_update__ hooks of
+// the new version once it is installed. All of them receive the old and the new
+// versions as arguments. When any of these hooks fails, the installed version
+// is restored.
+func (repo *defaultPackageRepository) Update(newPkg command.Package) error {
+	installed, err := repo.repoIndex.Package(newPkg.Name())
+	if err != nil {
+		// nothing to update
+		return repo.Install(newPkg)
+	}
+
+	if !lifecycleHookEnabled() {
+		if err := repo.Uninstall(newPkg.Name()); err != nil {
+			return err
+		}
+		return repo.Install(newPkg)
+	}
+
+	pkgDir := filepath.Join(repo.RepoDir, newPkg.Name())
+	oldVersion, newVersion := installed.Version(), newPkg.Version()
+	if err := pkg.ExecHookFromPackage(installed, pkg.PRE_UPDATE_HOOK, pkgDir, oldVersion, newVersion); err != nil {
+		return fmt.Errorf("cannot update the package %s: %v", newPkg.Name(), err)
+	}
+
+	backupDir, err := pkg.BackupDir(pkgDir)
 	if err != nil {
+		return fmt.Errorf("cannot update the package %s: %v", newPkg.Name(), err)
+	}
+	defer os.RemoveAll(backupDir)
+
+	if err := repo.Install(newPkg); err != nil {
 		return err
 	}
 
-	return repo.Install(pkg)
+	for _, hook := range []string{pkg.MIGRATE_HOOK, pkg.POST_UPDATE_HOOK} {
+		if err := pkg.ExecHookFromPackage(newPkg, hook, pkgDir, oldVersion, newVersion); err != nil {
+			repo.rollbackUpdate(installed, backupDir, pkgDir, err)
+			return fmt.Errorf("cannot update the package %s: %v", newPkg.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// restore the previously installed version of a package after a failed update
+func (repo *defaultPackageRepository) rollbackUpdate(installed command.PackageManifest, backupDir string, pkgDir string, cause error) {
+	if err := pkg.RestoreDir(backupDir, pkgDir); err != nil {
+		console.Error("Failed to restore the package %s@%s: %v\n", installed.Name(), installed.Version(), err)
+		return
+	}
+	if err := repo.repoIndex.Update(installed, repo.RepoDir, installed.Name()); err != nil {
+		console.Error("Failed to restore the package %s@%s: %v\n", installed.Name(), installed.Version(), err)
+		return
+	}
+	console.Warn("Restored the previous version %s of the package %s\n", installed.Version(), installed.Name())
+
+	reason := fmt.Sprintf("update failed: %v", cause)
+	if err := repo.repoIndex.PausePackageUpdate(installed.Name(), updateConfig.DEFAULT_UPDATE_PAUSE_DURATION, reason); err != nil {
+		console.Warn("Failed to pause update for package %s: %v\n", installed.Name(), err)
+	}
+}
+
+func lifecycleHookEnabled() bool {
+	return viper.GetBool(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY)
 }
 
 func (repo *defaultPackageRepository) InstalledPackages() []command.PackageManifest {
@@ -143,8 +217,12 @@ func (repo *defaultPackageRepository) IsPackageUpdatePaused(name string) (bool,
 	return repo.repoIndex.IsPackageUpdatePaused(name)
 }
 
-func (repo *defaultPackageRepository) PausePackageUpdate(name string) error {
-	return repo.repoIndex.PausePackageUpdate(name)
+func (repo *defaultPackageRepository) PausePackageUpdate(name string, duration time.Duration, reason string) error {
+	return repo.repoIndex.PausePackageUpdate(name, duration, reason)
+}
+
+func (repo *defaultPackageRepository) ResumePackageUpdate(name string) error {
+	return repo.repoIndex.ResumePackageUpdate(name)
 }
 
 func (repo *defaultPackageRepository) Command(pkg string, group string, name string) (command.Command, error) {
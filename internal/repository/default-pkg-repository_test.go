@@ -1,14 +1,19 @@
 package repository
 
 import (
+	"archive/zip"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/criteo/command-launcher/internal/config"
 	"github.com/criteo/command-launcher/internal/helper"
+	"github.com/criteo/command-launcher/internal/pkg"
 	"github.com/criteo/command-launcher/internal/remote"
+	"github.com/criteo/command-launcher/internal/updateConfig"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -227,3 +232,103 @@ func Test_Load_Symlink(t *testing.T) {
 	assert.Equal(t, "wf", repo.InstalledGroupCommands()[0].Name())
 	assert.Equal(t, "debug-cdt-env", repo.InstalledExecutableCommands()[0].Name())
 }
+
+func TestUpdatePackageWithLifecycleHooks(t *testing.T) {
+	previous := viper.GetBool(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY)
+	defer viper.Set(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY, previous)
+	viper.Set(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY, true)
+
+	localRepoPath := filepath.Join(t.TempDir(), "local-repo-test")
+	localRepo, err := CreateLocalRepository("default", localRepoPath, nil)
+	assert.Nil(t, err)
+
+	v1, err := pkg.CreateZipPackage(createHookPackage(t, "hooked", "1.0.0", "true"))
+	assert.Nil(t, err)
+	assert.Nil(t, localRepo.Install(v1))
+
+	// the migrate hook of the new version fails, the update is rolled back
+	v2, err := pkg.CreateZipPackage(createHookPackage(t, "hooked", "2.0.0", "false"))
+	assert.Nil(t, err)
+	err = localRepo.Update(v2)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "migrate hook of package hooked failed to execute")
+
+	installed, err := localRepo.Package("hooked")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0.0", installed.Version())
+	content, err := os.ReadFile(filepath.Join(localRepoPath, "hooked", "VERSION"))
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0.0", string(content))
+
+	paused, err := localRepo.IsPackageUpdatePaused("hooked")
+	assert.Nil(t, err)
+	assert.True(t, paused)
+	uConfig, err := updateConfig.ReadFromDir(localRepoPath)
+	assert.Nil(t, err)
+	assert.Contains(t, uConfig.PauseReason("hooked"), "migrate hook of package hooked failed to execute")
+	assert.Nil(t, localRepo.ResumePackageUpdate("hooked"))
+
+	// the migrate hook of the new version succeeds
+	v3, err := pkg.CreateZipPackage(createHookPackage(t, "hooked", "3.0.0", "true"))
+	assert.Nil(t, err)
+	assert.Nil(t, localRepo.Update(v3))
+
+	installed, err = localRepo.Package("hooked")
+	assert.Nil(t, err)
+	assert.Equal(t, "3.0.0", installed.Version())
+}
+
+func TestUninstallPackageWithFailingHook(t *testing.T) {
+	previous := viper.GetBool(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY)
+	defer viper.Set(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY, previous)
+	viper.Set(config.ENABLE_PACKAGE_LIFECYCLE_HOOK_KEY, true)
+
+	localRepoPath := filepath.Join(t.TempDir(), "local-repo-test")
+	localRepo, err := CreateLocalRepository("default", localRepoPath, nil)
+	assert.Nil(t, err)
+
+	p, err := pkg.CreateZipPackage(createHookPackage(t, "hooked", "1.0.0", "false"))
+	assert.Nil(t, err)
+	assert.Nil(t, localRepo.Install(p))
+
+	err = localRepo.Uninstall("hooked")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "uninstall hook of package hooked failed to execute")
+
+	_, err = localRepo.Package("hooked")
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(localRepoPath, "hooked", "manifest.mf"))
+	assert.Nil(t, err)
+}
+
+// create a zip package whose __migrate__ and __uninstall__ hooks run the hookExecutable
+func createHookPackage(t *testing.T, name string, version string, hookExecutable string) string {
+	t.Helper()
+	pkgFile := filepath.Join(t.TempDir(), fmt.Sprintf("%s-%s.pkg", name, version))
+	f, err := os.Create(pkgFile)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	files := map[string]string{
+		"manifest.mf": fmt.Sprintf(`{
+  "pkgName": "%s",
+  "version": "%s",
+  "cmds": [
+    { "name": "__migrate__", "type": "system", "executable": "%s" },
+    { "name": "__uninstall__", "type": "system", "executable": "%s" },
+    { "name": "%s", "type": "executable", "executable": "echo" }
+  ]
+}`, name, version, hookExecutable, hookExecutable, name),
+		"VERSION": version,
+	}
+	for filename, content := range files {
+		entry, err := w.Create(filename)
+		assert.Nil(t, err)
+		_, err = entry.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+
+	return pkgFile
+}
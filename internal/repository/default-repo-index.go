@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -161,21 +162,38 @@ func (repoIndex *defaultRepoIndex) IsPackageUpdatePaused(name string) (bool, err
 	return uConfig.IsPackagePaused(name), nil
 }
 
-func (repoIndex *defaultRepoIndex) PausePackageUpdate(name string) error {
-	// Read or create pause config at repository root directory
+func (repoIndex *defaultRepoIndex) PausePackageUpdate(name string, duration time.Duration, reason string) error {
+	uConfig, err := repoIndex.readUpdateConfig()
+	if err != nil {
+		return err
+	}
+	uConfig.PausePackageWithReason(name, duration, reason)
+	return uConfig.WriteToDir(repoIndex.repoDir)
+}
+
+func (repoIndex *defaultRepoIndex) ResumePackageUpdate(name string) error {
+	uConfig, err := repoIndex.readUpdateConfig()
+	if err != nil {
+		return err
+	}
+	uConfig.ResumePackage(name)
+	return uConfig.WriteToDir(repoIndex.repoDir)
+}
+
+// read or create pause config at repository root directory
+func (repoIndex *defaultRepoIndex) readUpdateConfig() (*updateConfig.UpdateConfig, error) {
 	var uConfig *updateConfig.UpdateConfig
 	if exists, err := updateConfig.IsUpdateConfigExists(repoIndex.repoDir); err != nil {
-		return err
+		return nil, err
 	} else if exists {
 		uConfig, err = updateConfig.ReadFromDir(repoIndex.repoDir)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else {
 		uConfig = updateConfig.NewUpdateConfig()
 	}
-	uConfig.PausePackage(name, updateConfig.DEFAULT_UPDATE_PAUSE_DURATION)
-	return uConfig.WriteToDir(repoIndex.repoDir)
+	return uConfig, nil
 }
 
 func (repoIndex *defaultRepoIndex) Command(pkg string, group string, name string) (command.Command, error) {
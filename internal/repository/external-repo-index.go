@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/pkg"
+)
+
+/*
+An external package source provider is an executable, which writes the
+packages of the source to its standard output in JSON:
+
+	{
+	  "packages": [
+	    { "dir": "/absolute/path/to/package" },
+	    { "dir": "/absolute/path/to/another/package", "manifest": { "pkgName": "...", "cmds": [...] } }
+	  ]
+	}
+
+The manifest is read from the manifest.mf file of the package directory when
+it is not provided. The provider is called in the repository directory of the
+source when the packages are loaded, its output is cached when a cache is
+configured.
+*/
+
+// ExternalSourceOutput is the JSON output of an external package source provider
+type ExternalSourceOutput struct {
+	Packages []ExternalSourcePackage `json:"packages"`
+}
+
+type ExternalSourcePackage struct {
+	Dir      string          `json:"dir"`
+	Manifest json.RawMessage `json:"manifest,omitempty"`
+}
+
+// ExternalProviderCache caches the output of a provider in a file, so that
+// the provider is not called each time the packages are loaded
+type ExternalProviderCache struct {
+	File string
+	// the cached output is used until it is older than the TTL
+	TTL time.Duration
+	// the provider is never called, the cached output is used whatever its
+	// age, ex: during the shell completion
+	ReadOnly bool
+}
+
+// externalRepoIndex implements RepoIndex for the packages returned by an
+// external package source provider. A provider failure is reported as a
+// warning, the source has no package in this case.
+type externalRepoIndex struct {
+	defaultRepoIndex
+	provider []string // the executable of the provider and its arguments
+	timeout  time.Duration
+	cache    *ExternalProviderCache // nil when the output is not cached
+}
+
+// NewExternalRepoIndex creates the index of an external package source, the
+// cache is optional
+func NewExternalRepoIndex(id string, provider []string, timeout time.Duration, cache *ExternalProviderCache) (RepoIndex, error) {
+	if len(provider) == 0 {
+		return nil, fmt.Errorf("no provider executable for the package source %s", id)
+	}
+
+	base, err := newDefaultRepoIndex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &externalRepoIndex{
+		defaultRepoIndex: *base.(*defaultRepoIndex),
+		provider:         provider,
+		timeout:          timeout,
+		cache:            cache,
+	}, nil
+}
+
+// Load calls the provider in repoDir, and loads the packages it returns.
+func (idx *externalRepoIndex) Load(repoDir string) error {
+	idx.packages = make(map[string]command.PackageManifest)
+	idx.packageDirs = make(map[string]string)
+
+	output, err := idx.providerOutput(repoDir)
+	if err != nil {
+		log.Warnf("external package source %s: %v", idx.id, err)
+		idx.extractCmds("")
+		return nil
+	}
+
+	for _, p := range output.Packages {
+		manifest, err := readExternalPackageManifest(p)
+		if err != nil {
+			log.Warnf("external package source %s: skipping %q: %v", idx.id, p.Dir, err)
+			continue
+		}
+		if manifest.Name() == "" {
+			log.Warnf("external package source %s: skipping %q: empty package name", idx.id, p.Dir)
+			continue
+		}
+		idx.packages[manifest.Name()] = manifest
+		idx.packageDirs[manifest.Name()] = p.Dir
+	}
+
+	idx.extractCmds("")
+	return nil
+}
+
+// providerOutput returns the cached output of the provider, or calls the
+// provider when the cache is missing or expired
+func (idx *externalRepoIndex) providerOutput(workDir string) (*ExternalSourceOutput, error) {
+	if idx.cache == nil {
+		return idx.callProvider(workDir)
+	}
+
+	if stat, err := os.Stat(idx.cache.File); err == nil && (idx.cache.ReadOnly || time.Since(stat.ModTime()) < idx.cache.TTL) {
+		if data, err := os.ReadFile(idx.cache.File); err == nil {
+			output := ExternalSourceOutput{}
+			if err := json.Unmarshal(data, &output); err == nil {
+				return &output, nil
+			}
+		}
+		log.Warnf("external package source %s: cannot read the cached output %s", idx.id, idx.cache.File)
+	}
+	if idx.cache.ReadOnly {
+		return &ExternalSourceOutput{}, nil
+	}
+
+	output, err := idx.callProvider(workDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeProviderCache(idx.cache.File, output); err != nil {
+		log.Warnf("external package source %s: cannot cache the provider output: %v", idx.id, err)
+	}
+	return output, nil
+}
+
+func writeProviderCache(file string, output *ExternalSourceOutput) error {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+func (idx *externalRepoIndex) callProvider(workDir string) (*ExternalSourceOutput, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, idx.provider[0], idx.provider[1:]...)
+	cmd.Dir = workDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// do not wait for the children of a killed provider holding its output
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("the provider %s timed out after %s", idx.provider[0], idx.timeout)
+		}
+		return nil, fmt.Errorf("the provider %s failed: %v %s", idx.provider[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := ExternalSourceOutput{}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("cannot read the output of the provider %s: %v", idx.provider[0], err)
+	}
+	return &output, nil
+}
+
+func readExternalPackageManifest(p ExternalSourcePackage) (command.PackageManifest, error) {
+	if !filepath.IsAbs(p.Dir) {
+		return nil, fmt.Errorf("the package directory must be an absolute path")
+	}
+	if stat, err := os.Stat(p.Dir); err != nil || !stat.IsDir() {
+		return nil, fmt.Errorf("the package directory does not exist")
+	}
+
+	if len(p.Manifest) > 0 && string(p.Manifest) != "null" {
+		return pkg.ParseManifest(p.Manifest)
+	}
+
+	manifestFile, err := os.Open(filepath.Join(p.Dir, "manifest.mf"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the manifest: %v", err)
+	}
+	defer manifestFile.Close()
+	return pkg.ReadManifest(manifestFile)
+}
+
+// Add returns an error because external packages are read-only.
+func (idx *externalRepoIndex) Add(p command.PackageManifest, repoDir string, pkgDirName string) error {
+	return fmt.Errorf("external packages are read-only")
+}
+
+// Remove returns an error because external packages are read-only.
+func (idx *externalRepoIndex) Remove(pkgName string, repoDir string) error {
+	return fmt.Errorf("external packages are read-only")
+}
+
+// Update returns an error because external packages are read-only.
+func (idx *externalRepoIndex) Update(p command.PackageManifest, repoDir string, pkgDirName string) error {
+	return fmt.Errorf("external packages are read-only")
+}
+
+// IsPackageUpdatePaused always returns false for external packages.
+func (idx *externalRepoIndex) IsPackageUpdatePaused(name string) (bool, error) {
+	return false, nil
+}
+
+// PausePackageUpdate returns an error because external packages are read-only.
+func (idx *externalRepoIndex) PausePackageUpdate(name string, duration time.Duration, reason string) error {
+	return fmt.Errorf("external packages are read-only")
+}
+
+// ResumePackageUpdate returns an error because external packages are read-only.
+func (idx *externalRepoIndex) ResumePackageUpdate(name string) error {
+	return fmt.Errorf("external packages are read-only")
+}
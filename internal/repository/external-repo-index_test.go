@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestProvider(t *testing.T, script string) []string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the test provider is a shell script")
+	}
+	provider := filepath.Join(t.TempDir(), "provider.sh")
+	err := os.WriteFile(provider, []byte("#!/bin/sh\n"+script), 0755)
+	assert.Nil(t, err)
+	return []string{provider}
+}
+
+func TestExternalRepoIndex_Load(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg1Dir := createTestPkgDir(t, tmpDir, "pkg1", "cmd1", "executable")
+	pkg2Dir := filepath.Join(tmpDir, "pkg2")
+	assert.Nil(t, os.MkdirAll(pkg2Dir, 0755))
+
+	provider := createTestProvider(t, `cat <<EOF
+{
+  "packages": [
+    { "dir": "`+pkg1Dir+`" },
+    { "dir": "`+pkg2Dir+`", "manifest": { "pkgName": "pkg2", "version": "2.0.0", "cmds": [ { "name": "cmd2", "type": "executable", "executable": "echo" } ] } },
+    { "dir": "relative/pkg3" },
+    { "dir": "`+filepath.Join(tmpDir, "missing")+`" }
+  ]
+}
+EOF
+`)
+
+	idx, err := NewExternalRepoIndex("bazel", provider, 5*time.Second, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, idx.Load(tmpDir))
+
+	assert.Len(t, idx.AllPackages(), 2)
+	assert.Len(t, idx.ExecutableCommands(), 2)
+
+	p2, err := idx.Package("pkg2")
+	assert.Nil(t, err)
+	assert.Equal(t, "2.0.0", p2.Version())
+
+	cmd, err := idx.Command("pkg2", "", "cmd2")
+	assert.Nil(t, err)
+	assert.Equal(t, pkg2Dir, cmd.PackageDir())
+	assert.Equal(t, "cmd2@@pkg2@bazel", cmd.FullName())
+}
+
+func TestExternalRepoIndex_ProviderWorkDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestPkgDir(t, tmpDir, "pkg1", "cmd1", "executable")
+
+	provider := createTestProvider(t, `echo "{\"packages\": [{\"dir\": \"$(pwd)/pkg1\"}]}"`)
+
+	idx, err := NewExternalRepoIndex("catalog", provider, 5*time.Second, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, idx.Load(tmpDir))
+	assert.Len(t, idx.AllPackages(), 1)
+}
+
+func TestExternalRepoIndex_ProviderFailure(t *testing.T) {
+	cases := map[string]string{
+		"exit code":    "echo failure >&2; exit 1",
+		"invalid json": "echo not-json",
+		"timeout":      "sleep 2",
+	}
+	for name, script := range cases {
+		t.Run(name, func(t *testing.T) {
+			idx, err := NewExternalRepoIndex("nix", createTestProvider(t, script), 500*time.Millisecond, nil)
+			assert.Nil(t, err)
+
+			// a failing provider does not prevent the other sources to load
+			assert.Nil(t, idx.Load(t.TempDir()))
+			assert.Len(t, idx.AllPackages(), 0)
+			assert.Len(t, idx.AllCommands(), 0)
+		})
+	}
+}
+
+func TestExternalRepoIndex_ReadOnly(t *testing.T) {
+	idx, err := NewExternalRepoIndex("nix", []string{"true"}, time.Second, nil)
+	assert.Nil(t, err)
+
+	assert.NotNil(t, idx.Remove("pkg", ""))
+	assert.NotNil(t, idx.PausePackageUpdate("pkg", time.Hour, ""))
+
+	_, err = NewExternalRepoIndex("nix", []string{}, time.Second, nil)
+	assert.NotNil(t, err)
+}
+
+func TestExternalRepoIndex_Cache(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestPkgDir(t, tmpDir, "pkg1", "cmd1", "executable")
+	calls := filepath.Join(tmpDir, "calls")
+	provider := createTestProvider(t, `echo called >> "`+calls+`"; echo "{\"packages\": [{\"dir\": \"$(pwd)/pkg1\"}]}"`)
+	countCalls := func() int {
+		data, _ := os.ReadFile(calls)
+		return len(strings.Fields(string(data)))
+	}
+	cache := &ExternalProviderCache{File: filepath.Join(tmpDir, "cache", "catalog.json"), TTL: time.Hour, ReadOnly: true}
+
+	// no cached output during the completion, the provider is not called
+	idx, err := NewExternalRepoIndex("catalog", provider, 5*time.Second, cache)
+	assert.Nil(t, err)
+	assert.Nil(t, idx.Load(tmpDir))
+	assert.Len(t, idx.AllPackages(), 0)
+	assert.Equal(t, 0, countCalls())
+
+	// the provider is called once, then its output is cached
+	cache.ReadOnly = false
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, idx.Load(tmpDir))
+		assert.Len(t, idx.AllPackages(), 1)
+	}
+	assert.Equal(t, 1, countCalls())
+
+	// the expired cache is used during the completion
+	expired := time.Now().Add(-2 * time.Hour)
+	assert.Nil(t, os.Chtimes(cache.File, expired, expired))
+	cache.ReadOnly = true
+	assert.Nil(t, idx.Load(tmpDir))
+	assert.Len(t, idx.AllPackages(), 1)
+	assert.Equal(t, 1, countCalls())
+
+	// the provider is called again once the cache is expired
+	cache.ReadOnly = false
+	assert.Nil(t, idx.Load(tmpDir))
+	assert.Equal(t, 2, countCalls())
+}
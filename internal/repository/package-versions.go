@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/criteo/command-launcher/internal/command"
+	"github.com/criteo/command-launcher/internal/pkg"
+	"github.com/criteo/command-launcher/internal/remote"
+)
+
+/*
+Side-by-side versions of a package are installed in the repository folder
+under .versions/[package name]/[version]. The packages in this folder are not
+loaded by the repository index: their commands are only available when a
+version is explicitly requested, the installed version stays the default one.
+*/
+const (
+	PACKAGE_VERSIONS_DIR = ".versions"
+)
+
+func (repo *defaultPackageRepository) versionDir(name string, version string) (string, error) {
+	for _, segment := range []string{name, version} {
+		if segment == "" || !filepath.IsLocal(segment) || strings.ContainsAny(segment, `/\`) {
+			return "", fmt.Errorf("invalid package version %s@%s", name, version)
+		}
+	}
+	return filepath.Join(repo.RepoDir, PACKAGE_VERSIONS_DIR, name, version), nil
+}
+
+func (repo *defaultPackageRepository) InstallVersion(pkg command.Package) error {
+	if installed, err := repo.repoIndex.Package(pkg.Name()); err == nil && installed.Version() == pkg.Version() {
+		return fmt.Errorf("version %s of the package %s is already the installed version", pkg.Version(), pkg.Name())
+	}
+
+	pkgDir, err := repo.versionDir(pkg.Name(), pkg.Version())
+	if err != nil {
+		return err
+	}
+	if _, err := pkg.InstallTo(pkgDir); err != nil {
+		return fmt.Errorf("cannot install the version %s of the package %s: %v", pkg.Version(), pkg.Name(), err)
+	}
+	return nil
+}
+
+func (repo *defaultPackageRepository) UninstallVersion(name string, version string) error {
+	pkgDir, err := repo.versionDir(name, version)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(pkgDir); os.IsNotExist(err) {
+		return fmt.Errorf("version %s of the package %s is not installed side by side", version, name)
+	}
+	if err := os.RemoveAll(pkgDir); err != nil {
+		return fmt.Errorf("cannot remove the version %s of the package %s: %v", version, name, err)
+	}
+	// remove the package folder once its last version is removed
+	os.Remove(filepath.Dir(pkgDir))
+	return nil
+}
+
+func (repo *defaultPackageRepository) PackageVersions(name string) []string {
+	versions := []string{}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return versions
+	}
+	entries, err := os.ReadDir(filepath.Join(repo.RepoDir, PACKAGE_VERSIONS_DIR, name))
+	if err != nil {
+		return versions
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return remote.IsVersionSmaller(versions[i], versions[j])
+	})
+	return versions
+}
+
+func (repo *defaultPackageRepository) PackageVersion(name string, version string) (command.PackageManifest, error) {
+	if installed, err := repo.repoIndex.Package(name); err == nil && installed.Version() == version {
+		return installed, nil
+	}
+
+	pkgDir, err := repo.versionDir(name, version)
+	if err != nil {
+		return nil, err
+	}
+	manifestFile, err := os.Open(filepath.Join(pkgDir, "manifest.mf"))
+	if err != nil {
+		return nil, fmt.Errorf("version %s of the package %s is not installed", version, name)
+	}
+	defer manifestFile.Close()
+
+	mf, err := pkg.ReadManifest(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the manifest of the package %s@%s: %v", name, version, err)
+	}
+	for _, cmd := range mf.Commands() {
+		cmd.SetPackageDir(pkgDir)
+		cmd.SetNamespace(repo.ID, mf.Name())
+	}
+	return mf, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criteo/command-launcher/internal/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallPackageVersionSideBySide(t *testing.T) {
+	localRepoPath := filepath.Join(t.TempDir(), "local-repo-test")
+	localRepo, err := CreateLocalRepository("default", localRepoPath, nil)
+	assert.Nil(t, err)
+
+	v2, err := pkg.CreateZipPackage(createHookPackage(t, "versioned", "2.0.0", "true"))
+	assert.Nil(t, err)
+	assert.Nil(t, localRepo.Install(v2))
+
+	// the installed version cannot be installed side by side
+	assert.NotNil(t, localRepo.InstallVersion(v2))
+
+	for _, version := range []string{"1.10.0", "1.2.0"} {
+		p, err := pkg.CreateZipPackage(createHookPackage(t, "versioned", version, "true"))
+		assert.Nil(t, err)
+		assert.Nil(t, localRepo.InstallVersion(p))
+	}
+	assert.Equal(t, []string{"1.2.0", "1.10.0"}, localRepo.PackageVersions("versioned"))
+
+	// the installed version stays the default one
+	installed, err := localRepo.Package("versioned")
+	assert.Nil(t, err)
+	assert.Equal(t, "2.0.0", installed.Version())
+	assert.Equal(t, 1, len(localRepo.InstalledExecutableCommands()))
+
+	// the side by side versions are not loaded by the repository index
+	localRepo, err = CreateLocalRepository("default", localRepoPath, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(localRepo.InstalledPackages()))
+
+	mf, err := localRepo.PackageVersion("versioned", "1.2.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2.0", mf.Version())
+	for _, cmd := range mf.Commands() {
+		assert.Equal(t, filepath.Join(localRepoPath, PACKAGE_VERSIONS_DIR, "versioned", "1.2.0"), cmd.PackageDir())
+		assert.Equal(t, "default", cmd.RepositoryID())
+	}
+
+	mf, err = localRepo.PackageVersion("versioned", "2.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "2.0.0", mf.Version())
+	assert.Equal(t, filepath.Join(localRepoPath, "versioned"), mf.Commands()[0].PackageDir())
+
+	_, err = localRepo.PackageVersion("versioned", "3.0.0")
+	assert.NotNil(t, err)
+	_, err = localRepo.PackageVersion("versioned", "../..")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, localRepo.UninstallVersion("versioned", "1.2.0"))
+	assert.Nil(t, localRepo.UninstallVersion("versioned", "1.10.0"))
+	assert.NotNil(t, localRepo.UninstallVersion("versioned", "1.10.0"))
+	assert.Equal(t, []string{}, localRepo.PackageVersions("versioned"))
+	_, err = os.Stat(filepath.Join(localRepoPath, PACKAGE_VERSIONS_DIR, "versioned"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/criteo/command-launcher/internal/command"
+import (
+	"time"
+
+	"github.com/criteo/command-launcher/internal/command"
+)
 
 /*
 PackageRepository is responsible for managing the local installed packages.
@@ -32,7 +36,11 @@ type PackageRepository interface {
 
 	IsPackageUpdatePaused(name string) (bool, error)
 
-	PausePackageUpdate(name string) error
+	// pause the update of a package for the given duration, the reason is
+	// recorded to be shown to the user
+	PausePackageUpdate(name string, duration time.Duration, reason string) error
+
+	ResumePackageUpdate(name string) error
 
 	// package repository doesn't resolve the the conflicts, to identify a command, we have to
 	// provide the full path of the command: repo > pkg > group > name
@@ -41,6 +49,20 @@ type PackageRepository interface {
 	Command(pkg string, group string, name string) (command.Command, error)
 
 	RepositoryFolder() (string, error)
+
+	// install a version of a package side by side with the installed one,
+	// without changing the installed version
+	InstallVersion(pkg command.Package) error
+
+	// remove a version of a package installed side by side
+	UninstallVersion(name string, version string) error
+
+	// the versions of a package installed side by side, sorted by version
+	PackageVersions(name string) []string
+
+	// get a version of a package, either the installed one or one installed
+	// side by side, the commands of the returned package are ready to execute
+	PackageVersion(name string, version string) (command.PackageManifest, error)
 }
 
 /*
@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/criteo/command-launcher/internal/command"
+import (
+	"time"
+
+	"github.com/criteo/command-launcher/internal/command"
+)
 
 type RepoIndex interface {
 	/* write interfaces */
@@ -18,6 +22,7 @@ type RepoIndex interface {
 	SystemMetricsCommand() command.Command
 	Package(name string) (command.PackageManifest, error)
 	IsPackageUpdatePaused(name string) (bool, error)
-	PausePackageUpdate(name string) error
+	PausePackageUpdate(name string, duration time.Duration, reason string) error
+	ResumePackageUpdate(name string) error
 	Command(pkg string, group string, name string) (command.Command, error)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -77,6 +78,11 @@ func (idx *workspaceRepoIndex) IsPackageUpdatePaused(name string) (bool, error)
 }
 
 // PausePackageUpdate returns an error because workspace packages are read-only.
-func (idx *workspaceRepoIndex) PausePackageUpdate(name string) error {
+func (idx *workspaceRepoIndex) PausePackageUpdate(name string, duration time.Duration, reason string) error {
+	return fmt.Errorf("workspace packages are read-only")
+}
+
+// ResumePackageUpdate returns an error because workspace packages are read-only.
+func (idx *workspaceRepoIndex) ResumePackageUpdate(name string) error {
 	return fmt.Errorf("workspace packages are read-only")
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -76,7 +77,11 @@ func TestWorkspaceRepoIndex_ReadOnly(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "read-only")
 
-	err = idx.PausePackageUpdate("foo")
+	err = idx.PausePackageUpdate("foo", time.Hour, "")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "read-only")
+
+	err = idx.ResumePackageUpdate("foo")
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "read-only")
 }
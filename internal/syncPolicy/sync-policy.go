@@ -0,0 +1,116 @@
+package syncPolicy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/criteo/command-launcher/internal/helper"
+)
+
+/*
+A sync policy defines how often a managed repository is synchronized with its remote.
+
+It could be one of:
+- a keyword: never, always, hourly, daily, weekly, monthly
+- a duration: 6h, 36h, 2d, 1w
+- a 5-field cron expression: "0 9 * * MON"
+*/
+const (
+	NEVER   = "never"
+	ALWAYS  = "always"
+	HOURLY  = "hourly"
+	DAILY   = "daily"
+	WEEKLY  = "weekly"
+	MONTHLY = "monthly"
+)
+
+type SyncPolicy struct {
+	value    string
+	interval time.Duration
+	months   int
+	cron     *cronSchedule
+}
+
+// Parse parses a sync policy, it returns an error when the policy is neither a
+// keyword, a duration, nor a cron expression
+func Parse(policy string) (*SyncPolicy, error) {
+	value := strings.TrimSpace(policy)
+	keyword := strings.ToLower(value)
+	p := SyncPolicy{value: keyword}
+
+	switch keyword {
+	case NEVER, ALWAYS:
+	case HOURLY:
+		p.interval = time.Hour
+	case DAILY:
+		p.interval = 24 * time.Hour
+	case WEEKLY:
+		p.interval = 7 * 24 * time.Hour
+	case MONTHLY:
+		p.months = 1
+	default:
+		p.value = value
+		if strings.Contains(value, " ") {
+			cron, err := parseCron(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sync policy: %v", err)
+			}
+			if cron.next(time.Now()).IsZero() {
+				return nil, fmt.Errorf("invalid sync policy: cron expression '%s' never matches", value)
+			}
+			p.cron = cron
+			return &p, nil
+		}
+		d, err := helper.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid sync policy: %s, must be one of: %s, a duration (ex: 6h, 2d), or a cron expression (ex: \"0 9 * * MON\")",
+				policy, strings.Join(Keywords(), ", "))
+		}
+		p.interval = d
+	}
+
+	return &p, nil
+}
+
+// IsValid checks if the policy could be parsed
+func IsValid(policy string) bool {
+	_, err := Parse(policy)
+	return err == nil
+}
+
+// Keywords returns the predefined sync policies
+func Keywords() []string {
+	return []string{NEVER, ALWAYS, HOURLY, DAILY, WEEKLY, MONTHLY}
+}
+
+func (p *SyncPolicy) String() string {
+	return p.value
+}
+
+func (p *SyncPolicy) IsNever() bool {
+	return p.value == NEVER
+}
+
+func (p *SyncPolicy) IsAlways() bool {
+	return p.value == ALWAYS
+}
+
+// Next returns the next time that the repository should be synchronized
+// after its last synchronization.
+//
+// The zero time is returned when the repository has never been synchronized
+// or the policy is "always", which means the synchronization is due.
+// The zero time is also returned for the "never" policy, check IsNever first.
+func (p *SyncPolicy) Next(lastSync time.Time) time.Time {
+	if lastSync.IsZero() || p.IsAlways() || p.IsNever() {
+		return time.Time{}
+	}
+	if p.cron != nil {
+		return p.cron.next(lastSync)
+	}
+	if p.months > 0 {
+		return lastSync.AddDate(0, p.months, 0)
+	}
+	return lastSync.Add(p.interval)
+}
@@ -0,0 +1,95 @@
+package syncPolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeywords(t *testing.T) {
+	last := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+	p, err := Parse("never")
+	assert.Nil(t, err)
+	assert.True(t, p.IsNever())
+
+	p, err = Parse("Always")
+	assert.Nil(t, err)
+	assert.True(t, p.IsAlways())
+	assert.True(t, p.Next(last).IsZero())
+
+	p, err = Parse("hourly")
+	assert.Nil(t, err)
+	assert.Equal(t, last.Add(time.Hour), p.Next(last))
+
+	p, err = Parse("weekly")
+	assert.Nil(t, err)
+	assert.Equal(t, last.AddDate(0, 0, 7), p.Next(last))
+
+	p, err = Parse("monthly")
+	assert.Nil(t, err)
+	assert.Equal(t, last.AddDate(0, 1, 0), p.Next(last))
+}
+
+func TestParseDurationPolicy(t *testing.T) {
+	last := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+	p, err := Parse("6h")
+	assert.Nil(t, err)
+	assert.Equal(t, "6h", p.String())
+	assert.Equal(t, last.Add(6*time.Hour), p.Next(last))
+
+	p, err = Parse("2d")
+	assert.Nil(t, err)
+	assert.Equal(t, last.Add(48*time.Hour), p.Next(last))
+
+	assert.True(t, p.Next(time.Time{}).IsZero())
+}
+
+func TestParseCronPolicy(t *testing.T) {
+	// 2024-01-31 is a Wednesday
+	last := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+	p, err := Parse("0 9 * * MON")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, time.February, 5, 9, 0, 0, 0, time.UTC), p.Next(last))
+
+	p, err = Parse("*/15 * * * *")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, time.January, 31, 10, 15, 0, 0, time.UTC), p.Next(last))
+
+	p, err = Parse("30 2 1,15 * *")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, time.February, 1, 2, 30, 0, 0, time.UTC), p.Next(last))
+
+	// day of month OR day of week when both are restricted
+	p, err = Parse("0 0 15 * 5")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC), p.Next(last))
+
+	// 7 is Sunday
+	p, err = Parse("0 12 * * 7")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, time.February, 4, 12, 0, 0, 0, time.UTC), p.Next(last))
+}
+
+func TestParseInvalidPolicy(t *testing.T) {
+	invalids := []string{
+		"",
+		"sometimes",
+		"-1h",
+		"0 9 * *",
+		"60 * * * *",
+		"0 9 * * FOO",
+		"0 9 5-1 * *",
+		"*/0 * * * *",
+		"0 0 30 FEB *",
+	}
+	for _, policy := range invalids {
+		_, err := Parse(policy)
+		assert.NotNil(t, err, policy)
+		assert.Contains(t, err.Error(), "invalid sync policy", policy)
+		assert.False(t, IsValid(policy), policy)
+	}
+}
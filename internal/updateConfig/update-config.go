@@ -11,6 +11,8 @@ import (
 type UpdateConfig struct {
 	// PausedUntil maps package name to the time until which updates are paused
 	PausedUntil map[string]time.Time `json:"pausedUntil"`
+	// Reasons maps package name to the reason of its pause
+	Reasons map[string]string `json:"reasons,omitempty"`
 }
 
 const DEFAULT_UPDATE_PAUSE_DURATION = 24 * time.Hour
@@ -34,6 +36,9 @@ func ReadFromDir(dir string) (*UpdateConfig, error) {
 	if config.PausedUntil == nil {
 		config.PausedUntil = make(map[string]time.Time)
 	}
+	if config.Reasons == nil {
+		config.Reasons = make(map[string]string)
+	}
 
 	return &config, nil
 }
@@ -80,10 +85,38 @@ func (config *UpdateConfig) IsPackagePaused(packageName string) bool {
 
 // PausePackage sets the pause duration for a specific package
 func (config *UpdateConfig) PausePackage(packageName string, duration time.Duration) {
+	config.PausePackageWithReason(packageName, duration, "")
+}
+
+// PausePackageWithReason sets the pause duration for a specific package, and
+// records the reason of the pause
+func (config *UpdateConfig) PausePackageWithReason(packageName string, duration time.Duration, reason string) {
 	if config.PausedUntil == nil {
 		config.PausedUntil = make(map[string]time.Time)
 	}
+	if config.Reasons == nil {
+		config.Reasons = make(map[string]string)
+	}
 	config.PausedUntil[packageName] = time.Now().Add(duration)
+	if reason == "" {
+		delete(config.Reasons, packageName)
+	} else {
+		config.Reasons[packageName] = reason
+	}
+}
+
+// ResumePackage removes the pause of a specific package
+func (config *UpdateConfig) ResumePackage(packageName string) {
+	delete(config.PausedUntil, packageName)
+	delete(config.Reasons, packageName)
+}
+
+// PauseReason returns the reason of the pause of a specific package
+func (config *UpdateConfig) PauseReason(packageName string) string {
+	if config.Reasons == nil {
+		return ""
+	}
+	return config.Reasons[packageName]
 }
 
 // RemoveExpiredPauses removes all expired pause entries from the config
@@ -95,6 +128,7 @@ func (config *UpdateConfig) RemoveExpiredPauses() {
 	for pkg, updateAfter := range config.PausedUntil {
 		if now.After(updateAfter) {
 			delete(config.PausedUntil, pkg)
+			delete(config.Reasons, pkg)
 		}
 	}
 }
@@ -103,5 +137,6 @@ func (config *UpdateConfig) RemoveExpiredPauses() {
 func NewUpdateConfig() *UpdateConfig {
 	return &UpdateConfig{
 		PausedUntil: make(map[string]time.Time),
+		Reasons:     make(map[string]string),
 	}
 }
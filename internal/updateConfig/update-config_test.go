@@ -141,3 +141,40 @@ func TestMultiplePackages(t *testing.T) {
 	assert.True(t, readConfig.IsPackagePaused("package-b"))
 	assert.False(t, readConfig.IsPackagePaused("package-c"))
 }
+
+func TestPausePackageWithReason(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := NewUpdateConfig()
+	config.PausePackageWithReason("package-a", DEFAULT_UPDATE_PAUSE_DURATION, "installation failed: checksum mismatch")
+	config.PausePackage("package-b", DEFAULT_UPDATE_PAUSE_DURATION)
+	err := config.WriteToDir(tmpDir)
+	assert.NoError(t, err)
+
+	readConfig, err := ReadFromDir(tmpDir)
+	assert.NoError(t, err)
+	assert.True(t, readConfig.IsPackagePaused("package-a"))
+	assert.Equal(t, "installation failed: checksum mismatch", readConfig.PauseReason("package-a"))
+	assert.Equal(t, "", readConfig.PauseReason("package-b"))
+}
+
+func TestResumePackage(t *testing.T) {
+	config := NewUpdateConfig()
+	config.PausePackageWithReason("test-package", DEFAULT_UPDATE_PAUSE_DURATION, "manually paused")
+	assert.True(t, config.IsPackagePaused("test-package"))
+
+	config.ResumePackage("test-package")
+	assert.False(t, config.IsPackagePaused("test-package"))
+	assert.Equal(t, "", config.PauseReason("test-package"))
+}
+
+func TestRemoveExpiredPausesWithReason(t *testing.T) {
+	config := NewUpdateConfig()
+	config.PausedUntil["expired-package"] = time.Now().Add(-1 * time.Hour)
+	config.Reasons["expired-package"] = "manually paused"
+
+	config.RemoveExpiredPauses()
+
+	_, reasonExists := config.Reasons["expired-package"]
+	assert.False(t, reasonExists, "reason of expired package should be removed")
+}
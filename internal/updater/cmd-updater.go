@@ -13,6 +13,8 @@ import (
 	"github.com/criteo/command-launcher/internal/helper"
 	"github.com/criteo/command-launcher/internal/remote"
 	"github.com/criteo/command-launcher/internal/repository"
+	"github.com/criteo/command-launcher/internal/syncPolicy"
+	"github.com/criteo/command-launcher/internal/updateConfig"
 	"github.com/criteo/command-launcher/internal/user"
 
 	log "github.com/sirupsen/logrus"
@@ -39,6 +41,9 @@ type CmdUpdater struct {
 	VerifyChecksum       bool
 	VerifySignature      bool
 	SyncPolicy           string
+	// only the packages selected by the filter are installed and updated,
+	// the installed packages not selected anymore are deleted
+	PackageFilter remote.PackageFilter
 }
 
 func (u *CmdUpdater) CheckUpdateAsync() {
@@ -62,16 +67,6 @@ func (u *CmdUpdater) Update() error {
 
 	errPool := []error{}
 
-	// check if we are following the syncPolicy
-	// TODO: for now we check the sync policy to block update during the update phase,
-	// This is no optimal, as we still check remote repository in check update async.
-	// We should move the sync policy check to the check update async, which will save
-	// time to check the remote repo in order to make the check done in the timeout period.
-	if err := u.reachSyncSchedule(); err != nil {
-		log.Info(err.Error())
-		return err
-	}
-
 	remoteRepo, err := u.getRemoteRepository()
 	if err != nil {
 		// TODO: handle error here
@@ -110,13 +105,13 @@ func (u *CmdUpdater) Update() error {
 			if err != nil {
 				errPool = append(errPool, err)
 				fmt.Printf("Cannot get the package %s: %v\n", pkgName, err)
-				u.pausePackageOnFailure(pkgName)
+				u.pausePackageOnFailure(pkgName, fmt.Errorf("cannot get the package: %v", err))
 				continue
 			}
 			if ok, err := remoteRepo.Verify(pkg, u.VerifyChecksum, u.VerifySignature); !ok || err != nil {
 				errPool = append(errPool, err)
 				fmt.Printf("Failed to verify package %s, skip it: %v\n", pkgName, err)
-				u.pausePackageOnFailure(pkgName)
+				u.pausePackageOnFailure(pkgName, fmt.Errorf("verification failed: %v", err))
 				continue
 			}
 			if err = repo.Update(pkg); err != nil {
@@ -137,13 +132,13 @@ func (u *CmdUpdater) Update() error {
 				if err != nil {
 					errPool = append(errPool, err)
 					fmt.Printf("Cannot get the package %s: %v\n", pkgName, err)
-					u.pausePackageOnFailure(pkgName)
+					u.pausePackageOnFailure(pkgName, fmt.Errorf("cannot get the package: %v", err))
 					continue
 				}
 				if ok, err := remoteRepo.Verify(pkg, u.VerifyChecksum, u.VerifySignature); !ok || err != nil {
 					errPool = append(errPool, err)
 					fmt.Printf("Failed to verify package %s, skip it: %v\n", pkgName, err)
-					u.pausePackageOnFailure(pkgName)
+					u.pausePackageOnFailure(pkgName, fmt.Errorf("verification failed: %v", err))
 					continue
 				}
 				if err = repo.Install(pkg); err != nil {
@@ -168,6 +163,7 @@ func (u *CmdUpdater) Update() error {
 		fmt.Println("Update done! Enjoy coding!")
 		return nil
 	} else {
+		u.recordSyncFailure(errPool[0])
 		return errPool[0]
 	}
 }
@@ -176,8 +172,19 @@ func (u *CmdUpdater) checkUpdateCommands() <-chan bool {
 	ch := make(chan bool, 1)
 	canBeUpdated := false
 	go func() {
+		// check the sync policy before any network access, so that the
+		// update timeout is not wasted on remotes not yet due for sync
+		if err := u.reachSyncSchedule(); err != nil {
+			log.Info(err.Error())
+			canBeUpdated = false
+			ch <- canBeUpdated
+			return
+		}
+
+		u.recordSyncAttempt()
 		remoteRepo, err := u.getRemoteRepository()
 		if err != nil {
+			u.recordSyncFailure(err)
 			canBeUpdated = false
 			ch <- canBeUpdated
 			return
@@ -191,6 +198,9 @@ func (u *CmdUpdater) checkUpdateCommands() <-chan bool {
 		availablePkgs := map[string]string{}
 		if remotePkgNames, err := remoteRepo.PackageNames(); err == nil {
 			for _, remotePkgName := range remotePkgNames {
+				if !u.PackageFilter.Match(remotePkgName) {
+					continue
+				}
 				latest, err := remoteRepo.QueryLatestPackageInfo(remotePkgName, func(pkgInfo *remote.PackageInfo) bool {
 					return u.User.InPartition(pkgInfo.StartPartition, pkgInfo.EndPartition)
 				})
@@ -209,7 +219,9 @@ func (u *CmdUpdater) checkUpdateCommands() <-chan bool {
 				for k, v := range lockedPkgs {
 					log.Infof("package %s is locked to version %s", k, v)
 					if _, ok := availablePkgs[k]; !ok {
-						log.Infoln(fmt.Errorf("package %s@%s is not available on the remote registry", k, v))
+						err := fmt.Errorf("package %s@%s is not available on the remote registry", k, v)
+						log.Infoln(err)
+						u.recordSyncFailure(err)
 						canBeUpdated = false
 						ch <- canBeUpdated
 						return
@@ -280,6 +292,10 @@ func (u *CmdUpdater) checkUpdateCommands() <-chan bool {
 		if len(u.toBeDeleted) > 0 || len(u.toBeUpdated) > 0 || len(u.toBeInstalled) > 0 {
 			canBeUpdated = true
 		} else {
+			// already up-to-date, the synchronization is done
+			if err := u.UpdateSyncTimestamp(); err != nil {
+				log.Error(err)
+			}
 			canBeUpdated = false
 		}
 
@@ -317,67 +333,97 @@ func (u *CmdUpdater) LoadLockedPackages(lockFile string) (map[string]string, err
 
 // check sync policy
 func (u *CmdUpdater) reachSyncSchedule() error {
-	// check if we are following the syncPolicy
-	if u.SyncPolicy == "never" {
+	policy, err := syncPolicy.Parse(u.SyncPolicy)
+	if err != nil {
+		return fmt.Errorf("Remote '%s': %v", u.LocalRepo.Name(), err)
+	}
+	if policy.IsNever() {
 		return errors.New(fmt.Sprintf("Remote '%s': Sync policy is set to never, no update will be performed", u.LocalRepo.Name()))
 	}
-	if u.SyncPolicy == "always" {
+	if policy.IsAlways() {
 		return nil
 	}
-	// now load the sync timestamp
+
 	localRepoFolder, err := u.LocalRepo.RepositoryFolder()
 	if err != nil {
 		return err
 	}
-	data, err := os.ReadFile(path.Join(localRepoFolder, "sync.timestamp"))
-	if err != nil {
-		// error read the file, we assume the sync time is passed
-		return nil
-	}
-	syncTime, err := time.Parse(time.RFC3339, string(data))
+	_, next, err := SyncSchedule(localRepoFolder, u.SyncPolicy)
 	if err != nil {
 		return err
 	}
 
 	// now check if we passed the sync time
-	if time.Now().Before(syncTime) {
+	if time.Now().Before(next) {
 		return errors.New(fmt.Sprintf("Remote '%s': Not yet reach the sync time", u.LocalRepo.Name()))
 	}
 
 	return nil
 }
 
+// UpdateSyncTimestamp records a successful synchronization, and the next
+// time that the repository should be synchronized following the sync policy
 func (u *CmdUpdater) UpdateSyncTimestamp() error {
 	localRepoFolder, err := u.LocalRepo.RepositoryFolder()
 	if err != nil {
 		return err
 	}
 
-	var delay time.Duration = 24
-	switch u.SyncPolicy {
-	case "always":
-		return errors.New(fmt.Sprintf("Remote '%s': Sync policy is set to always, no need to update the sync timestamp", u.LocalRepo.Name()))
-	case "never":
+	policy, err := syncPolicy.Parse(u.SyncPolicy)
+	if err != nil {
+		return fmt.Errorf("Remote '%s': %v", u.LocalRepo.Name(), err)
+	}
+	if policy.IsNever() {
 		return errors.New(fmt.Sprintf("Remote '%s': Sync policy is set to never, no need to update the sync timestamp", u.LocalRepo.Name()))
-	case "hourly":
-		delay = 1
-	case "daily":
-		delay = 24
-	case "weekly":
-		delay = 24 * 7
-	case "monthly":
-		delay = 24 * 30
 	}
 
-	err = os.WriteFile(path.Join(localRepoFolder, "sync.timestamp"), []byte(time.Now().Add(time.Hour*delay).Format(time.RFC3339)), 0644)
+	now := time.Now()
+	if err := updateSyncState(localRepoFolder, func(state *SyncState) {
+		state.RecordSuccess(now)
+	}); err != nil {
+		return err
+	}
+
+	if policy.IsAlways() {
+		return nil
+	}
 
-	log.Infof("Remote '%s': Sync timestamp updated to %s", u.LocalRepo.Name(), time.Now().Add(time.Hour*delay).Format(time.RFC3339))
+	next := policy.Next(now)
+	err = os.WriteFile(path.Join(localRepoFolder, SYNC_TIMESTAMP_FILE), []byte(next.Format(time.RFC3339)), 0644)
+
+	log.Infof("Remote '%s': Sync timestamp updated to %s", u.LocalRepo.Name(), next.Format(time.RFC3339))
 	return err
 }
 
-// pausePackageOnFailure pauses a package after an installation failure
-func (u *CmdUpdater) pausePackageOnFailure(pkgName string) {
-	if err := u.LocalRepo.PausePackageUpdate(pkgName); err != nil {
+func (u *CmdUpdater) recordSyncAttempt() {
+	localRepoFolder, err := u.LocalRepo.RepositoryFolder()
+	if err != nil {
+		return
+	}
+	if err := updateSyncState(localRepoFolder, func(state *SyncState) {
+		state.RecordAttempt(time.Now())
+	}); err != nil {
+		log.Errorf("Remote '%s': cannot record the sync attempt: %v", u.LocalRepo.Name(), err)
+	}
+}
+
+func (u *CmdUpdater) recordSyncFailure(syncErr error) {
+	localRepoFolder, err := u.LocalRepo.RepositoryFolder()
+	if err != nil {
+		return
+	}
+	if err := updateSyncState(localRepoFolder, func(state *SyncState) {
+		state.RecordFailure(syncErr)
+	}); err != nil {
+		log.Errorf("Remote '%s': cannot record the sync failure: %v", u.LocalRepo.Name(), err)
+	}
+}
+
+// pausePackageOnFailure pauses a package after an installation failure,
+// the failure is recorded as the reason of the pause
+func (u *CmdUpdater) pausePackageOnFailure(pkgName string, failure error) {
+	reason := fmt.Sprintf("automatically paused: %v", failure)
+	if err := u.LocalRepo.PausePackageUpdate(pkgName, updateConfig.DEFAULT_UPDATE_PAUSE_DURATION, reason); err != nil {
 		console.Warn("Failed to pause update for package %s: %v", pkgName, err)
 	} else {
 		console.Reminder(
@@ -0,0 +1,117 @@
+package updater
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/criteo/command-launcher/internal/syncPolicy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SYNC_TIMESTAMP_FILE stores the next time that the repository should be synchronized
+	SYNC_TIMESTAMP_FILE = "sync.timestamp"
+	// SYNC_STATE_FILE stores the synchronization history of the repository
+	SYNC_STATE_FILE = "sync-state.json"
+)
+
+// SyncState is persisted in the local repository folder to track its
+// synchronization with the remote repository
+type SyncState struct {
+	LastAttempt         time.Time `json:"lastAttempt"`
+	LastSuccess         time.Time `json:"lastSuccess"`
+	LastError           string    `json:"lastError,omitempty"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+}
+
+// ReadSyncState reads the sync state from the local repository folder, an
+// empty state is returned when the repository has never been synchronized
+func ReadSyncState(repoFolder string) (*SyncState, error) {
+	state := SyncState{}
+	data, err := os.ReadFile(filepath.Join(repoFolder, SYNC_STATE_FILE))
+	if os.IsNotExist(err) {
+		return &state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// WriteToDir writes the sync state to the local repository folder
+func (state *SyncState) WriteToDir(repoFolder string) error {
+	if err := os.MkdirAll(repoFolder, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(repoFolder, SYNC_STATE_FILE), data, 0644)
+}
+
+// RecordAttempt marks the beginning of a synchronization
+func (state *SyncState) RecordAttempt(at time.Time) {
+	state.LastAttempt = at
+}
+
+// RecordSuccess marks a successful synchronization, and resets the failures
+func (state *SyncState) RecordSuccess(at time.Time) {
+	state.LastSuccess = at
+	state.LastError = ""
+	state.ConsecutiveFailures = 0
+}
+
+// RecordFailure marks a failed synchronization with its error
+func (state *SyncState) RecordFailure(err error) {
+	state.LastError = err.Error()
+	state.ConsecutiveFailures++
+}
+
+// updateSyncState reads the sync state, applies the change, and writes it back
+func updateSyncState(repoFolder string, change func(state *SyncState)) error {
+	state, err := ReadSyncState(repoFolder)
+	if err != nil {
+		log.Warnf("cannot read the sync state in %s, reset it: %v", repoFolder, err)
+		state = &SyncState{}
+	}
+	change(state)
+	return state.WriteToDir(repoFolder)
+}
+
+// SyncSchedule returns the last successful synchronization time and the next
+// time that the repository should be synchronized following its sync policy.
+//
+// The next time is zero when the synchronization is due on the next run.
+func SyncSchedule(repoFolder string, policy string) (time.Time, time.Time, error) {
+	p, err := syncPolicy.Parse(policy)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	state, err := ReadSyncState(repoFolder)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !state.LastSuccess.IsZero() || p.IsAlways() {
+		return state.LastSuccess, p.Next(state.LastSuccess), nil
+	}
+
+	// fallback to the sync timestamp written by previous versions
+	data, err := os.ReadFile(filepath.Join(repoFolder, SYNC_TIMESTAMP_FILE))
+	if err != nil {
+		return time.Time{}, time.Time{}, nil
+	}
+	next, err := time.Parse(time.RFC3339, string(data))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return time.Time{}, next, nil
+}
@@ -0,0 +1,130 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/criteo/command-launcher/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncScheduleNeverSynced(t *testing.T) {
+	repoDir := t.TempDir()
+
+	last, next, err := SyncSchedule(repoDir, "daily")
+	assert.Nil(t, err)
+	assert.True(t, last.IsZero())
+	assert.True(t, next.IsZero())
+}
+
+func TestSyncScheduleFromState(t *testing.T) {
+	repoDir := t.TempDir()
+	lastSuccess := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+	state := SyncState{LastSuccess: lastSuccess}
+	assert.Nil(t, state.WriteToDir(repoDir))
+
+	last, next, err := SyncSchedule(repoDir, "6h")
+	assert.Nil(t, err)
+	assert.True(t, lastSuccess.Equal(last))
+	assert.True(t, lastSuccess.Add(6*time.Hour).Equal(next))
+
+	_, next, err = SyncSchedule(repoDir, "0 9 * * MON")
+	assert.Nil(t, err)
+	assert.True(t, time.Date(2024, time.February, 5, 9, 0, 0, 0, time.UTC).Equal(next))
+
+	_, _, err = SyncSchedule(repoDir, "sometimes")
+	assert.NotNil(t, err)
+}
+
+func TestSyncScheduleFromLegacyTimestamp(t *testing.T) {
+	repoDir := t.TempDir()
+	nextSync := time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC)
+	err := os.WriteFile(filepath.Join(repoDir, SYNC_TIMESTAMP_FILE), []byte(nextSync.Format(time.RFC3339)), 0644)
+	assert.Nil(t, err)
+
+	last, next, err := SyncSchedule(repoDir, "daily")
+	assert.Nil(t, err)
+	assert.True(t, last.IsZero())
+	assert.True(t, nextSync.Equal(next))
+}
+
+func TestRecordSyncState(t *testing.T) {
+	repoDir := t.TempDir()
+	now := time.Now()
+
+	assert.Nil(t, updateSyncState(repoDir, func(state *SyncState) {
+		state.RecordAttempt(now)
+		state.RecordFailure(errors.New("remote unreachable"))
+	}))
+	assert.Nil(t, updateSyncState(repoDir, func(state *SyncState) {
+		state.RecordFailure(errors.New("remote still unreachable"))
+	}))
+
+	state, err := ReadSyncState(repoDir)
+	assert.Nil(t, err)
+	assert.True(t, now.Equal(state.LastAttempt))
+	assert.True(t, state.LastSuccess.IsZero())
+	assert.Equal(t, "remote still unreachable", state.LastError)
+	assert.Equal(t, 2, state.ConsecutiveFailures)
+
+	assert.Nil(t, updateSyncState(repoDir, func(state *SyncState) {
+		state.RecordSuccess(now)
+	}))
+
+	state, err = ReadSyncState(repoDir)
+	assert.Nil(t, err)
+	assert.True(t, now.Equal(state.LastSuccess))
+	assert.Equal(t, "", state.LastError)
+	assert.Equal(t, 0, state.ConsecutiveFailures)
+}
+
+func TestCheckUpdateSkipsRemoteBeforeSyncTime(t *testing.T) {
+	repoDir := t.TempDir()
+	repo, err := repository.CreateLocalRepository("test", repoDir, nil)
+	assert.Nil(t, err)
+
+	state := SyncState{LastSuccess: time.Now()}
+	assert.Nil(t, state.WriteToDir(repoDir))
+
+	u := CmdUpdater{
+		LocalRepo: repo,
+		// an unreachable remote, which must not be fetched before the sync time
+		CmdRepositoryBaseUrl: "http://127.0.0.1:0/remote",
+		SyncPolicy:           "daily",
+		Timeout:              time.Second,
+	}
+	u.CheckUpdateAsync()
+	assert.Nil(t, u.Update())
+	assert.Nil(t, u.remoteRepo)
+
+	state2, err := ReadSyncState(repoDir)
+	assert.Nil(t, err)
+	assert.True(t, state2.LastAttempt.IsZero())
+	assert.Equal(t, 0, state2.ConsecutiveFailures)
+}
+
+func TestCheckUpdateRecordsSyncFailure(t *testing.T) {
+	repoDir := t.TempDir()
+	repo, err := repository.CreateLocalRepository("test", repoDir, nil)
+	assert.Nil(t, err)
+
+	u := CmdUpdater{
+		LocalRepo:            repo,
+		CmdRepositoryBaseUrl: "http://127.0.0.1:0/remote",
+		SyncPolicy:           "daily",
+		Timeout:              5 * time.Second,
+	}
+	u.CheckUpdateAsync()
+	assert.Nil(t, u.Update())
+
+	state, err := ReadSyncState(repoDir)
+	assert.Nil(t, err)
+	assert.False(t, state.LastAttempt.IsZero())
+	assert.True(t, state.LastSuccess.IsZero())
+	assert.NotEqual(t, "", state.LastError)
+	assert.Equal(t, 1, state.ConsecutiveFailures)
+}